@@ -162,3 +162,60 @@ func (ebg *eventBasedGatewayActivity) SetOutboundCompleted(id string) {
 func (ebg *eventBasedGatewayActivity) OutboundCompleted() bool {
 	return len(ebg.OutboundActivityCompleted) > 0
 }
+
+// -------------------------------------------------------------------------
+
+// conditionalEventActivity waits at a conditional intermediate catch event, boundary event,
+// or start event of an event sub-process, until its condition becomes true
+type conditionalEventActivity struct {
+	key     int64
+	state   ActivityState
+	element *BPMN20.BaseElement
+	// satisfied is the result of the last evaluation, so that a non-interrupting event
+	// is only triggered again, when its condition becomes true again
+	satisfied bool
+}
+
+func (cea *conditionalEventActivity) Key() int64 {
+	return cea.key
+}
+
+func (cea *conditionalEventActivity) State() ActivityState {
+	return cea.state
+}
+
+func (cea *conditionalEventActivity) SetState(state ActivityState) {
+	cea.state = state
+}
+
+func (cea *conditionalEventActivity) Element() *BPMN20.BaseElement {
+	return cea.element
+}
+
+// -------------------------------------------------------------------------
+
+// loopActivity counts the iterations of an activity with standard loop characteristics;
+// it's active, while the activity loops, and every iteration has its own job or sub-process
+type loopActivity struct {
+	key     int64
+	state   ActivityState
+	element *BPMN20.BaseElement
+	// loopCounter is the number of the current iteration, starting with 1
+	loopCounter int
+}
+
+func (la *loopActivity) Key() int64 {
+	return la.key
+}
+
+func (la *loopActivity) State() ActivityState {
+	return la.state
+}
+
+func (la *loopActivity) SetState(state ActivityState) {
+	la.state = state
+}
+
+func (la *loopActivity) Element() *BPMN20.BaseElement {
+	return la.element
+}
@@ -0,0 +1,208 @@
+package bpmn_engine
+
+import (
+	"maps"
+	"sort"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// graphMessageFlow is a message flow of a collaboration, which delivers a message to another process
+type graphMessageFlow struct {
+	BPMN20.TMessageFlow
+	targetProcessId string
+	targetElementId string // empty, when the message flow targets the pool of the other process
+	messageName     string
+	// receiverElementIds are the elements of the target process, whose message subscriptions receive the message
+	receiverElementIds []string
+	// startsInstance is true, when the message creates a new instance of the target process via its message start event
+	startsInstance bool
+}
+
+// addMessageFlows adds the message flows between the given processes' graphs to the graphs of their sources,
+// the graphs are in order of the processes of the definitions;
+// message flows from or to black box pools are ignored, because the application exchanges these messages
+func addMessageFlows(definitions BPMN20.TDefinitions, graphs []*processGraph) error {
+	graphsByProcessId := map[string]*processGraph{}
+	for i, process := range definitions.Processes {
+		graphsByProcessId[process.Id] = graphs[i]
+	}
+	participantProcessIds := map[string]string{}
+	for _, collaboration := range definitions.Collaborations {
+		for _, participant := range collaboration.Participants {
+			participantProcessIds[participant.Id] = participant.ProcessRef
+		}
+	}
+	for _, collaboration := range definitions.Collaborations {
+		for _, messageFlow := range collaboration.MessageFlows {
+			source := graphOfElement(graphs, messageFlow.SourceRef)
+			if source == nil {
+				continue
+			}
+			flow := &graphMessageFlow{TMessageFlow: messageFlow}
+			target, isParticipant := graphsByProcessId[participantProcessIds[messageFlow.TargetRef]]
+			if !isParticipant {
+				target = graphOfElement(graphs, messageFlow.TargetRef)
+				if target == nil {
+					continue
+				}
+				flow.targetElementId = messageFlow.TargetRef
+				flow.messageName = target.messageNames[messageRefOf(*target.elements[messageFlow.TargetRef])]
+			}
+			flow.targetProcessId = target.processId
+			if flow.messageName == "" && messageFlow.MessageRef != "" {
+				flow.messageName = target.messageNames[messageFlow.MessageRef]
+			}
+			if flow.messageName == "" {
+				flow.messageName = source.messageNames[messageRefOf(*source.elements[messageFlow.SourceRef])]
+			}
+			if flow.messageName == "" {
+				return newEngineErrorf("message flow id=%s has no message, neither by its target nor by its source", messageFlow.Id)
+			}
+			flow.startsInstance = target.isMessageStartEvent(flow.targetElementId, flow.messageName)
+			flow.receiverElementIds = target.messageReceiverElementIds(flow.targetElementId, flow.messageName)
+			source.messageFlows[messageFlow.SourceRef] = append(source.messageFlows[messageFlow.SourceRef], flow)
+		}
+	}
+	return nil
+}
+
+// graphOfElement returns the graph, which contains the element with the given ID, or nil
+func graphOfElement(graphs []*processGraph, elementId string) *processGraph {
+	for _, g := range graphs {
+		if _, found := g.elements[elementId]; found && g.processId != elementId {
+			return g
+		}
+	}
+	return nil
+}
+
+// messageRefOf returns the message reference of message events, send and receive tasks, or an empty string
+func messageRefOf(element BPMN20.BaseElement) string {
+	switch e := element.(type) {
+	case BPMN20.TStartEvent:
+		return e.MessageEventDefinition.MessageRef
+	case BPMN20.TIntermediateCatchEvent:
+		return e.MessageEventDefinition.MessageRef
+	case BPMN20.TIntermediateThrowEvent:
+		return e.MessageEventDefinition.MessageRef
+	case BPMN20.TEndEvent:
+		return e.MessageEventDefinition.MessageRef
+	case BPMN20.TReceiveTask:
+		return e.MessageRef
+	case BPMN20.TSendTask:
+		return e.MessageRef
+	}
+	return ""
+}
+
+// isMessageStartEvent returns true, when the element is a message start event of the process,
+// or when the message flow targets the pool (empty element ID) and the process has a message start event for the message
+func (g *processGraph) isMessageStartEvent(elementId string, messageName string) bool {
+	for _, startEvent := range g.startEvents[g.processId] {
+		if messageRef := messageRefOf(*startEvent); messageRef != "" {
+			if (*startEvent).GetId() == elementId || (elementId == "" && g.messageNames[messageRef] == messageName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// messageReceiverElementIds returns the given element, or, when the message flow targets the pool (empty element ID),
+// the elements of the process, which receive the message, sorted by ID
+func (g *processGraph) messageReceiverElementIds(elementId string, messageName string) []string {
+	if elementId != "" {
+		return []string{elementId}
+	}
+	var ids []string
+	for id, element := range g.elements {
+		if messageRef := messageRefOf(*element); messageRef != "" && g.messageNames[messageRef] == messageName {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sendMessages delivers a message along every message flow of the element, with a copy of the instance's variables;
+// the message starts a new instance of the other process, or is published to its instance, which waits for it,
+// preferring the instance, which this instance exchanges messages with already
+func (state *BpmnEngineState) sendMessages(instance *processInstanceInfo, element BPMN20.BaseElement) error {
+	for _, flow := range instance.ProcessInfo.graph.messageFlows[element.GetId()] {
+		variables := maps.Clone(instance.VariableHolder.Variables())
+		if flow.startsInstance {
+			receiver, err := state.CreateInstanceById(flow.targetProcessId, variables)
+			if err != nil {
+				return err
+			}
+			receiver.MessageSenderKey = instance.InstanceKey
+			instance.messageReceiverKeys = append(instance.messageReceiverKeys, receiver.InstanceKey)
+			state.runMessageReceiver(instance, element, receiver)
+			continue
+		}
+		receiver := state.findMessageReceiver(instance, flow)
+		if receiver == nil {
+			// like any published message, it's dropped, when no instance waits for it
+			continue
+		}
+		if err := state.PublishEventForInstance(receiver.InstanceKey, flow.messageName, variables); err != nil {
+			return err
+		}
+		if !receiver.running && state.waitsForMessage(receiver, flow) {
+			state.runMessageReceiver(instance, element, receiver)
+		}
+	}
+	return nil
+}
+
+// runMessageReceiver runs the instance, which received the message; its failure isn't the sender's one,
+// so the receiver is left failed, and an exporter.MessageReceiverFailed event is exported for the sending element
+func (state *BpmnEngineState) runMessageReceiver(sender *processInstanceInfo, element BPMN20.BaseElement, receiver *processInstanceInfo) {
+	if err := state.run(receiver.ProcessInfo.definitions.Process, receiver, receiver); err != nil || receiver.ActivityState == Failed {
+		state.exportElementEvent(sender.ProcessInfo.graph.scopes[element.GetId()], *sender, element, exporter.MessageReceiverFailed)
+	}
+}
+
+// findMessageReceiver returns the active instance of the message flow's target process, which has started the sender
+// or was started by the sender; otherwise the oldest instance, which waits for the message; or nil
+func (state *BpmnEngineState) findMessageReceiver(sender *processInstanceInfo, flow *graphMessageFlow) *processInstanceInfo {
+	isReceiver := func(pi *processInstanceInfo) bool {
+		return pi != nil && pi.ActivityState == Active && pi.ProcessInfo.BpmnProcessId == flow.targetProcessId
+	}
+	if pi := state.FindProcessInstance(sender.MessageSenderKey); isReceiver(pi) {
+		return pi
+	}
+	for _, key := range sender.messageReceiverKeys {
+		if pi := state.FindProcessInstance(key); isReceiver(pi) {
+			return pi
+		}
+	}
+	var waiting *processInstanceInfo
+	for _, elementId := range flow.receiverElementIds {
+		for _, ms := range state.messageSubscriptions.withElementId(elementId) {
+			if ms.State() != Active {
+				continue
+			}
+			if pi := state.FindProcessInstance(ms.ProcessInstanceKey); isReceiver(pi) && (waiting == nil || pi.InstanceKey < waiting.InstanceKey) {
+				waiting = pi
+			}
+		}
+	}
+	return waiting
+}
+
+// waitsForMessage returns true, when the instance has an active subscription for the message flow's message
+func (state *BpmnEngineState) waitsForMessage(instance *processInstanceInfo, flow *graphMessageFlow) bool {
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if ms.State() != Active {
+			continue
+		}
+		if flow.targetElementId == ms.ElementId ||
+			flow.targetElementId == "" && instance.ProcessInfo.graph.messageNames[messageRefOf(*ms.Element())] == flow.messageName {
+			return true
+		}
+	}
+	return false
+}
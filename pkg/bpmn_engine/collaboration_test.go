@@ -0,0 +1,223 @@
+package bpmn_engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+)
+
+func Test_loading_a_collaboration_deploys_every_process(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, process.BpmnProcessId, is.EqualTo("order-customer"))
+	shops := bpmnEngine.FindProcessesById("order-shop")
+	then.AssertThat(t, shops, has.Length(1))
+	then.AssertThat(t, shops[0].ProcessKey, is.Not(is.EqualTo(process.ProcessKey)))
+}
+
+func Test_loading_all_processes_of_a_collaboration_returns_every_process(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	processes, err := bpmnEngine.LoadAllFromFile("../../test-cases/collaboration-message-flows.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, processes, has.Length(2))
+	then.AssertThat(t, processes[0].BpmnProcessId, is.EqualTo("order-customer"))
+	then.AssertThat(t, processes[1].BpmnProcessId, is.EqualTo("order-shop"))
+	then.AssertThat(t, bpmnEngine.FindProcessesById("order-shop")[0].ProcessKey, is.EqualTo(processes[1].ProcessKey))
+}
+
+func Test_loading_a_collaboration_again_deploys_no_new_versions(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	_, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	_, err = bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, bpmnEngine.processes, has.Length(2))
+}
+
+func Test_message_flows_start_the_other_pool_and_deliver_its_replies(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("place-order").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("prepare-order").Handler(func(job ActivatedJob) {
+		job.SetVariable("orderState", "prepared")
+		cp.TaskHandler(job)
+	})
+
+	// when
+	customer, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"orderId": 42})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("place-order,prepare-order"))
+	then.AssertThat(t, customer.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, customer.GetVariable("orderState"), is.EqualTo("prepared"))
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(2))
+	shop := bpmnEngine.ProcessInstances()[1]
+	then.AssertThat(t, shop.ProcessInfo.BpmnProcessId, is.EqualTo("order-shop"))
+	then.AssertThat(t, shop.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, shop.GetVariable("orderId"), is.EqualTo(42))
+	then.AssertThat(t, shop.MessageSenderKey, is.EqualTo(customer.InstanceKey))
+}
+
+func Test_message_flows_deliver_the_reply_of_the_other_pool_after_it_was_continued(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		bpmnEngine.NewTaskHandler().Type("place-order").Handler(func(job ActivatedJob) {
+			job.Complete()
+		})
+
+		// given
+		customer, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, customer.GetState(), is.EqualTo(Active))
+		shop := bpmnEngine.ProcessInstances()[1]
+		then.AssertThat(t, shop.GetState(), is.EqualTo(Active))
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		restored.NewTaskHandler().Type("prepare-order").Handler(func(job ActivatedJob) {
+			job.Complete()
+		})
+
+		// when
+		_, err = restored.RunOrContinueInstance(shop.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restored.FindProcessesById("order-shop"), has.Length(1))
+		then.AssertThat(t, restored.FindProcessInstance(shop.InstanceKey).MessageSenderKey, is.EqualTo(customer.InstanceKey))
+		then.AssertThat(t, restored.FindProcessInstance(shop.InstanceKey).GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, restored.FindProcessInstance(customer.InstanceKey).GetState(), is.EqualTo(Completed))
+	})
+}
+
+func Test_message_flows_are_delivered_to_the_waiting_instance_of_the_other_pool(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	shopProcess := bpmnEngine.FindProcessesById("order-shop")[0]
+	bpmnEngine.NewTaskHandler().Type("place-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// given
+	customer, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, customer.GetState(), is.EqualTo(Active))
+	ordered := bpmnEngine.ProcessInstances()[1]
+	then.AssertThat(t, ordered.GetState(), is.EqualTo(Active))
+	bpmnEngine.NewTaskHandler().Type("prepare-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	shop, err := bpmnEngine.CreateAndRunInstance(shopProcess.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, shop.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, customer.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, ordered.GetState(), is.EqualTo(Active))
+}
+
+func Test_message_flows_are_delivered_to_the_oldest_waiting_instance_of_the_other_pool(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	shopProcess := bpmnEngine.FindProcessesById("order-shop")[0]
+	bpmnEngine.NewTaskHandler().Type("place-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// given
+	first, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	second, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("prepare-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(shopProcess.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, first.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, second.GetState(), is.EqualTo(Active))
+}
+
+func Test_failing_message_receiver_is_exported_for_the_sending_element(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	recorder := &elementRecordingExporter{}
+	bpmnEngine.AddEventExporter(recorder)
+	data, err := os.ReadFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData := strings.Replace(string(data), `<zeebe:taskDefinition type="prepare-order" />`,
+		`<zeebe:taskDefinition type="prepare-order" /><zeebe:ioMapping><zeebe:output source="=unknown_function()" target="orderState" /></zeebe:ioMapping>`, 1)
+	process, err := bpmnEngine.LoadFromBytes([]byte(xmlData))
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("place-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+	bpmnEngine.NewTaskHandler().Type("prepare-order").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	customer, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, customer.GetState(), is.EqualTo(Active))
+	shop := bpmnEngine.ProcessInstances()[1]
+	then.AssertThat(t, shop.GetState(), is.EqualTo(Failed))
+	then.AssertThat(t, recorder.elements, is.ValueContaining(
+		"SEND_TASK:place-order:"+string(exporter.MessageReceiverFailed),
+	))
+}
+
+func Test_loading_fails_on_message_flow_without_message(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	data, err := os.ReadFile("../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData := strings.Replace(string(data), ` messageRef="Message_confirmation"`, "", -1)
+
+	// when
+	_, err = bpmnEngine.LoadFromBytes([]byte(xmlData))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("message flow id=Flow_confirmation has no message"))
+	then.AssertThat(t, bpmnEngine.processes, has.Length(0))
+}
@@ -0,0 +1,125 @@
+package bpmn_engine
+
+import (
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// compensableActivity is a completed activity instance, which has a compensation handler;
+// its variables are a snapshot of the process instance's variables, taken when the activity completed
+type compensableActivity struct {
+	ElementId string                 `json:"e"`
+	Key       int64                  `json:"k"`
+	State     ActivityState          `json:"s"` // Completed, Compensating while its handler runs, or Compensated
+	Variables map[string]interface{} `json:"v,omitempty"`
+	ThrowerId string                 `json:"t,omitempty"` // the throw event, which waits for the compensation
+}
+
+// recordCompensableActivity remembers the completed activity instance, when it has a compensation handler
+func (pii *processInstanceInfo) recordCompensableActivity(element *BPMN20.BaseElement, activity activity) {
+	if _, found := pii.ProcessInfo.graph.compensationHandlers[(*element).GetId()]; !found {
+		return
+	}
+	variables := map[string]interface{}{}
+	for k, v := range pii.VariableHolder.Variables() {
+		variables[k] = v
+	}
+	pii.CompensableActivities = append(pii.CompensableActivities, compensableActivity{
+		ElementId: (*element).GetId(),
+		Key:       activity.Key(),
+		State:     Completed,
+		Variables: variables,
+	})
+}
+
+// compensatingActivity returns the latest activity instance, which is compensated right now, or nil when there's none
+func (pii *processInstanceInfo) compensatingActivity(elementId string) *compensableActivity {
+	for i := len(pii.CompensableActivities) - 1; i >= 0; i-- {
+		if ca := &pii.CompensableActivities[i]; ca.ElementId == elementId && ca.State == Compensating {
+			return ca
+		}
+	}
+	return nil
+}
+
+// throwCompensation invokes the compensation handlers of the completed activity instances in the thrower's scope,
+// or of the referenced activity only, in reverse order of their completion. A thrower within an event sub-process
+// compensates the activities of the event sub-process's parent scope. Every activity instance is compensated once.
+// Returns false, when a handler's job isn't completed yet; the thrower waits then, and is handled again,
+// when the job gets completed, to invoke the remaining handlers.
+func (state *BpmnEngineState) throwCompensation(instance *processInstanceInfo, thrower *BPMN20.BaseElement, activityRef string) (done bool) {
+	graph := instance.ProcessInfo.graph
+	scopeId := graph.scopes[(*thrower).GetId()].GetId()
+	if esp := graph.eventSubProcessOf((*thrower).GetId()); esp != nil {
+		scopeId = graph.scopes[esp.Id].GetId()
+	}
+	for i := len(instance.CompensableActivities) - 1; i >= 0; i-- {
+		ca := &instance.CompensableActivities[i]
+		if ca.State == Compensating && ca.ThrowerId == (*thrower).GetId() {
+			// still waiting for the handler
+			return false
+		}
+		if ca.State != Completed {
+			continue
+		}
+		if activityRef != "" && ca.ElementId != activityRef || activityRef == "" && !graph.isInScope(ca.ElementId, scopeId) {
+			continue
+		}
+		ca.State = Compensating
+		ca.ThrowerId = (*thrower).GetId()
+		handler := graph.compensationHandlers[ca.ElementId]
+		scope := graph.scopes[(*handler).GetId()]
+		state.exportElementEvent(scope, *instance, *handler, exporter.ElementActivated)
+		startedAt := state.history.currentTime()
+		activity := state.handleCompensationHandler(instance, handler, ca)
+		state.recordElementHistory(instance, handler, instance, activity, false, startedAt, nil)
+		if activity.State() != Completed {
+			return false
+		}
+		state.exportElementEvent(scope, *instance, *handler, exporter.ElementCompleted)
+	}
+	return true
+}
+
+// continueCompensationHandler continues the compensation handler, whose job wasn't completed, when it was invoked;
+// when the job is completed now, the waiting thrower is handled again
+func (state *BpmnEngineState) continueCompensationHandler(instance *processInstanceInfo, handler *BPMN20.BaseElement, act activity) (activity, []command) {
+	graph := instance.ProcessInfo.graph
+	ca := instance.compensatingActivity(graph.compensatedActivities[(*handler).GetId()])
+	activity := state.handleCompensationHandler(instance, handler, ca)
+	if ca == nil || ca.State != Compensated || ca.ThrowerId == "" {
+		return activity, nil
+	}
+	return activity, []command{activityCommand{
+		sourceId:       (*handler).GetId(),
+		element:        graph.element(ca.ThrowerId),
+		originActivity: act,
+	}}
+}
+
+// handleCompensationHandler calls the task handler of the compensation handler, with the variables of the
+// compensated activity instance; the compensation is done, when the job is completed. A handler, whose job isn't
+// completed right away, is continued like any other job. Compensation handlers, which aren't tasks
+// with a job, just pass through.
+func (state *BpmnEngineState) handleCompensationHandler(instance *processInstanceInfo, handler *BPMN20.BaseElement, ca *compensableActivity) activity {
+	taskElement, isTask := (*handler).(BPMN20.TaskElement)
+	if !isTask {
+		if ca != nil {
+			ca.State = Compensated
+		}
+		return &elementActivity{
+			key:     state.generateKey(),
+			state:   Completed,
+			element: handler,
+		}
+	}
+	var variables map[string]interface{}
+	if ca != nil {
+		variables = ca.Variables
+	}
+	_, j := state.handleJob(instance, &taskElement, variables)
+	if j.JobState == Completed && ca != nil {
+		ca.State = Compensated
+	}
+	return j
+}
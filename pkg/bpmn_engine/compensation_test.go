@@ -0,0 +1,123 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+// bookingHandlers book hotels and flights, and record the cancellations with the booking ID they see
+type bookingHandlers struct {
+	cancellations []string
+}
+
+func (bh *bookingHandlers) book(bookingId string) func(job ActivatedJob) {
+	return func(job ActivatedJob) {
+		job.SetVariable("bookingId", bookingId)
+		job.Complete()
+	}
+}
+
+func (bh *bookingHandlers) cancel(job ActivatedJob) {
+	bh.cancellations = append(bh.cancellations, fmt.Sprintf("%s:%v", job.ElementId(), job.Variable("bookingId")))
+	job.Complete()
+}
+
+func (bh *bookingHandlers) register(bpmnEngine *BpmnEngineState, taskTypes ...string) {
+	handlers := map[string]func(job ActivatedJob){
+		"book-hotel":    bh.book("hotel-1"),
+		"book-flight":   bh.book("flight-1"),
+		"charge-card":   func(job ActivatedJob) { job.Complete() },
+		"cancel-hotel":  bh.cancel,
+		"cancel-flight": bh.cancel,
+	}
+	for _, taskType := range taskTypes {
+		bpmnEngine.NewTaskHandler().Type(taskType).Handler(handlers[taskType])
+	}
+}
+
+func Test_compensation_invokes_the_handlers_in_reverse_order_with_the_variable_snapshots(t *testing.T) {
+	tests := []struct {
+		payment       string
+		cancellations string
+	}{
+		{"ok", ""},
+		{"declined", "cancel-flight:flight-1,cancel-hotel:hotel-1"},
+		{"partial", "cancel-flight:flight-1"},
+		{"fraud", "cancel-flight:flight-1,cancel-hotel:hotel-1"},
+	}
+	for _, test := range tests {
+		t.Run(test.payment, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/compensation-booking.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			bh := &bookingHandlers{}
+			bh.register(&bpmnEngine, "book-hotel", "book-flight", "charge-card", "cancel-hotel", "cancel-flight")
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": test.payment})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, strings.Join(bh.cancellations, ","), is.EqualTo(test.cancellations))
+			then.AssertThat(t, instance.GetVariable("bookingId"), is.EqualTo("flight-1"))
+		})
+	}
+}
+
+func Test_compensation_throw_event_waits_for_the_handler_jobs(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/compensation-booking.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bh := &bookingHandlers{}
+	bh.register(&bpmnEngine, "book-hotel", "book-flight", "charge-card")
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": "declined"})
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "cancel-flight"), has.Length(1))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "cancel-hotel"), has.Length(0))
+
+	// when
+	bh.register(&bpmnEngine, "cancel-hotel", "cancel-flight")
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, strings.Join(bh.cancellations, ","), is.EqualTo("cancel-flight:flight-1,cancel-hotel:hotel-1"))
+}
+
+func Test_compensable_activities_survive_marshalling(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/compensation-booking.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		bh := &bookingHandlers{}
+		bh.register(&bpmnEngine, "book-hotel", "book-flight")
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": "declined"})
+		then.AssertThat(t, err, is.Nil())
+
+		// given
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		bh.register(&restored, "charge-card", "cancel-hotel", "cancel-flight")
+
+		// when
+		restoredInstance, err := restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restoredInstance.GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, strings.Join(bh.cancellations, ","), is.EqualTo("cancel-flight:flight-1,cancel-hotel:hotel-1"))
+	})
+}
@@ -0,0 +1,112 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// activationCountVariable holds the number of tokens, which arrived at a converging complex gateway,
+// while its activation condition is evaluated
+const activationCountVariable = "_activationCount"
+
+// handleComplexGateway forwards the token of a diverging complex gateway right away. A converging one
+// collects the arriving tokens, and continues once, when its activation condition becomes true,
+// or, without activation condition, when tokens arrived on all incoming flows. After that, the gateway
+// consumes the remaining tokens of the same activation, and resets, when tokens arrived on all incoming
+// flows, or when no other token can arrive anymore, see resetComplexGateways. A token, which arrives
+// on an incoming flow a second time, belongs to the next activation, e.g. in loops.
+func (state *BpmnEngineState) handleComplexGateway(instance *processInstanceInfo, element *BPMN20.BaseElement, originActivity activity) (continueFlow bool, resultActivity activity, err error) {
+	graph := instance.ProcessInfo.graph
+	if _, converging := graph.joinSources[(*element).GetId()]; !converging {
+		return true, &elementActivity{
+			key:     state.generateKey(),
+			state:   Active,
+			element: element,
+		}, nil
+	}
+	var flowId string
+	if originActivity != nil {
+		if sourceFlow := graph.incomingFlowFrom((*originActivity.Element()).GetId(), (*element).GetId()); sourceFlow != nil {
+			flowId = sourceFlow.Id
+		}
+	}
+	gateway := instance.findComplexGatewayActivity((*element).GetId())
+	if gateway != nil && gateway.State() == Completing && slices.Contains(gateway.inboundFlowIdsCompleted, flowId) {
+		gateway.SetState(Completed)
+		gateway = nil
+	}
+	if gateway == nil {
+		gateway = &gatewayActivity{
+			key:     state.generateKey(),
+			state:   Active,
+			element: element,
+		}
+		instance.appendActivity(gateway)
+	}
+	gateway.SetInboundFlowCompleted(flowId)
+	if gateway.State() == Completing {
+		if gateway.AreInboundFlowsCompleted() {
+			gateway.SetState(Completed)
+		}
+		return false, gateway, nil
+	}
+	continueFlow, err = isComplexGatewayActivated(instance, gateway)
+	if err != nil || !continueFlow {
+		return false, gateway, err
+	}
+	if gateway.AreInboundFlowsCompleted() {
+		gateway.SetState(Completed)
+	} else {
+		gateway.SetState(Completing)
+	}
+	return true, gateway, nil
+}
+
+// isComplexGatewayActivated evaluates the activation condition, with the number of arrived tokens
+// as additional variable
+func isComplexGatewayActivated(instance *processInstanceInfo, gateway *gatewayActivity) (bool, error) {
+	complexGateway := (*gateway.Element()).(BPMN20.TComplexGateway)
+	if !complexGateway.HasActivationCondition() {
+		return gateway.AreInboundFlowsCompleted(), nil
+	}
+	variables := map[string]interface{}{}
+	for k, v := range instance.VariableHolder.Variables() {
+		variables[k] = v
+	}
+	variables[activationCountVariable] = len(gateway.inboundFlowIdsCompleted)
+	out, err := instance.ProcessInfo.graph.expressions.evaluate(complexGateway.GetActivationConditionLanguage(), complexGateway.GetActivationCondition(), variables)
+	if err != nil {
+		return false, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating activation condition of complex gateway id='%s' name='%s'", complexGateway.Id, complexGateway.Name),
+			Err: err,
+		}
+	}
+	return out == true, nil
+}
+
+// findComplexGatewayActivity returns the current activation of the complex gateway, which either
+// waits for its activation, or for its reset; returns nil, when there's none
+func (pii *processInstanceInfo) findComplexGatewayActivity(elementId string) *gatewayActivity {
+	for _, a := range pii.activities {
+		if gateway, ok := a.(*gatewayActivity); ok && (*gateway.Element()).GetId() == elementId && (gateway.State() == Active || gateway.State() == Completing) {
+			return gateway
+		}
+	}
+	return nil
+}
+
+// resetComplexGateways resets the activated complex gateways of the scope, at which no other token can arrive anymore
+func (state *BpmnEngineState) resetComplexGateways(process BPMN20.ProcessElement, instance *processInstanceInfo) {
+	graph := instance.ProcessInfo.graph
+	for _, a := range instance.activities {
+		gateway, ok := a.(*gatewayActivity)
+		if !ok || (*gateway.Element()).GetType() != BPMN20.ComplexGateway || gateway.State() != Completing || graph.scopes[(*gateway.Element()).GetId()].GetId() != process.GetId() {
+			continue
+		}
+		if !state.canTokenArriveAt(instance, gateway) {
+			gateway.SetState(Completed)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package bpmn_engine
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+// reviewHandlers count the decisions as rounds, and hold back the review-3 job, while it's not released
+type reviewHandlers struct {
+	cp              CallPath
+	rounds          int
+	review3Released bool
+}
+
+func (rh *reviewHandlers) review(job ActivatedJob) {
+	if job.ElementId() == "review-3" && !rh.review3Released {
+		return
+	}
+	rh.cp.TaskHandler(job)
+}
+
+func (rh *reviewHandlers) decide(job ActivatedJob) {
+	rh.rounds++
+	job.SetVariable("round", rh.rounds)
+	rh.cp.TaskHandler(job)
+}
+
+func (rh *reviewHandlers) register(bpmnEngine *BpmnEngineState, taskTypes ...string) {
+	for _, taskType := range taskTypes {
+		switch taskType {
+		case "review":
+			bpmnEngine.NewTaskHandler().Type(taskType).Handler(rh.review)
+		case "decide":
+			bpmnEngine.NewTaskHandler().Type(taskType).Handler(rh.decide)
+		default:
+			bpmnEngine.NewTaskHandler().Type(taskType).Handler(rh.cp.TaskHandler)
+		}
+	}
+}
+
+func Test_complex_gateway_routes_by_the_conditions_of_its_outgoing_flows(t *testing.T) {
+	tests := []struct {
+		amount   int
+		callPath string
+	}{
+		{50, "review-1,review-2,review-3,decide,auto-approve"},
+		{500, "review-1,review-2,review-3,decide,escalate"},
+		{5000, "review-1,review-2,review-3,decide,escalate,audit"},
+	}
+	for _, test := range tests {
+		t.Run(test.callPath, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/complex-gateway.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			rh := &reviewHandlers{review3Released: true}
+			rh.register(&bpmnEngine, "review", "decide", "escalate", "audit", "auto-approve")
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"amount": test.amount, "rounds": 1})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, rh.cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_complex_gateway_continues_once_its_activation_condition_is_true(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/complex-gateway.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		rh := &reviewHandlers{}
+		rh.register(&bpmnEngine, "review", "decide")
+
+		// given
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"amount": 50, "rounds": 1})
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+		then.AssertThat(t, rh.cp.CallPath, is.EqualTo("review-1,review-2,decide"))
+		then.AssertThat(t, instance.findComplexGatewayActivity("two-of-three").State(), is.EqualTo(Completing))
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		rh.review3Released = true
+		rh.register(&restored, "review", "decide")
+
+		// when
+		restoredInstance, err := restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, rh.cp.CallPath, is.EqualTo("review-1,review-2,decide,review-3"))
+		then.AssertThat(t, restoredInstance.findComplexGatewayActivity("two-of-three"), is.Nil())
+		then.AssertThat(t, restored.jobs.ofElement(instance.InstanceKey, "auto-approve"), has.Length(1))
+	})
+}
+
+func Test_complex_gateway_resets_for_every_loop_iteration(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/complex-gateway.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	rh := &reviewHandlers{review3Released: true}
+	rh.register(&bpmnEngine, "review", "decide", "escalate", "audit", "auto-approve")
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"amount": 50, "rounds": 2})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, rh.cp.CallPath, is.EqualTo("review-1,review-2,review-3,decide,review-1,review-2,review-3,decide,auto-approve"))
+}
@@ -0,0 +1,193 @@
+package bpmn_engine
+
+import (
+	"fmt"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// handleIntermediateConditionalCatchEvent evaluates the condition, when the event is entered,
+// and continues right away, when it's true; otherwise, the event waits, see triggerConditionalEvents
+func (state *BpmnEngineState) handleIntermediateConditionalCatchEvent(instance *processInstanceInfo, element *BPMN20.BaseElement) (continueFlow bool, activity activity, err error) {
+	ce := &conditionalEventActivity{
+		key:     state.generateKey(),
+		state:   Active,
+		element: element,
+	}
+	continueFlow, err = isConditionalEventTriggered(instance, ce)
+	if err != nil {
+		ce.SetState(Failed)
+		return false, ce, err
+	}
+	if continueFlow {
+		ce.SetState(Completed)
+	} else {
+		instance.appendActivity(ce)
+	}
+	return continueFlow, ce, nil
+}
+
+// armConditionalBoundaryEvents lets the conditional boundary events, which are attached to the waiting activity,
+// wait for their conditions; boundary events, which already wait, are kept
+func (state *BpmnEngineState) armConditionalBoundaryEvents(instance *processInstanceInfo, activityId string) {
+	for _, boundaryEvent := range instance.ProcessInfo.graph.boundaryEvents[activityId] {
+		if (*boundaryEvent).(BPMN20.TBoundaryEvent).ConditionalEventDefinition.Id == "" || instance.findActiveActivityByElementId((*boundaryEvent).GetId()) != nil {
+			continue
+		}
+		instance.appendActivity(&conditionalEventActivity{
+			key:     state.generateKey(),
+			state:   Active,
+			element: boundaryEvent,
+		})
+	}
+}
+
+// withdrawConditionalBoundaryEvents withdraws the waiting conditional boundary events, which are attached to the activity
+func withdrawConditionalBoundaryEvents(instance *processInstanceInfo, activityId string) {
+	for _, boundaryEvent := range instance.ProcessInfo.graph.boundaryEvents[activityId] {
+		if ce := instance.findActiveActivityByElementId((*boundaryEvent).GetId()); ce != nil {
+			ce.SetState(Withdrawn)
+		}
+	}
+}
+
+// triggerConditionalEvents evaluates the conditions of the waiting conditional events again, since the variables
+// might have changed. Returns the commands, which continue the first triggered intermediate catch event or boundary
+// event; event sub-processes are run right away. Boundary events of sub-processes and event sub-processes
+// are only triggered, while their active sub-process or parent scope is run by the given activity's run, or its parents.
+func (state *BpmnEngineState) triggerConditionalEvents(act activity, instance *processInstanceInfo) []command {
+	graph := instance.ProcessInfo.graph
+	for _, a := range instance.activities {
+		ce, ok := a.(*conditionalEventActivity)
+		if !ok || ce.State() != Active {
+			continue
+		}
+		element := ce.Element()
+		boundaryEvent, isBoundaryEvent := (*element).(BPMN20.TBoundaryEvent)
+		startEvent, isStartEvent := (*element).(BPMN20.TStartEvent)
+		var subProcess *subProcessInfo
+		if isBoundaryEvent {
+			if _, attachedToSubProcess := (*graph.element(boundaryEvent.AttachedToRef)).(BPMN20.TSubProcess); attachedToSubProcess {
+				if subProcess = runningSubProcess(act, boundaryEvent.AttachedToRef); subProcess == nil || subProcess.State() != Active {
+					continue
+				}
+			}
+		}
+		if isStartEvent && !isScopeRunBy(act, graph.scopes[graph.eventSubProcessOf(startEvent.Id).Id].GetId()) {
+			continue
+		}
+		triggered, err := isConditionalEventTriggered(instance, ce)
+		if err != nil {
+			ce.SetState(Failed)
+			return []command{errorCommand{
+				err:         err,
+				elementId:   (*element).GetId(),
+				elementName: (*element).GetName(),
+			}}
+		}
+		if !triggered {
+			continue
+		}
+		switch {
+		case isBoundaryEvent && subProcess != nil:
+			if boundaryEvent.Interrupting() {
+				withdrawConditionalBoundaryEvents(instance, boundaryEvent.AttachedToRef)
+				ce.SetState(Completed)
+			}
+			state.triggerBoundaryEvent(instance, subProcess, element)
+		case isBoundaryEvent:
+			return state.triggerConditionalBoundaryEvent(instance, boundaryEvent, ce)
+		case isStartEvent:
+			if startEvent.Interrupting() {
+				ce.SetState(Completed)
+			}
+			esp := graph.eventSubProcessOf(startEvent.Id)
+			if cmds := state.triggerEventSubProcess(act, instance, graph.element(esp.Id), startEvent.Interrupting()); len(cmds) > 0 {
+				return cmds
+			}
+		default:
+			startedAt := state.history.currentTime()
+			ce.SetState(Completed)
+			state.exportElementEvent(graph.scopes[(*element).GetId()], *instance, *element, exporter.ElementCompleted)
+			state.recordElementHistory(instance, element, act, ce, true, startedAt, nil)
+			return createNextCommands(instance, element, ce)
+		}
+	}
+	return nil
+}
+
+// triggerConditionalBoundaryEvent continues the flow at the boundary event of a waiting task;
+// an interrupting boundary event terminates the task's job and loop, or withdraws its message subscription, first
+func (state *BpmnEngineState) triggerConditionalBoundaryEvent(instance *processInstanceInfo, boundaryEvent BPMN20.TBoundaryEvent, ce *conditionalEventActivity) []command {
+	if boundaryEvent.Interrupting() {
+		for _, j := range state.jobs.ofElement(instance.InstanceKey, boundaryEvent.AttachedToRef) {
+			if j.JobState == Active {
+				j.JobState = Terminated
+			}
+		}
+		for _, ms := range state.messageSubscriptions.ofElement(instance.InstanceKey, boundaryEvent.AttachedToRef) {
+			if ms.MessageState == Active {
+				ms.MessageState = Withdrawn
+			}
+		}
+		if loop := instance.findActiveLoop(boundaryEvent.AttachedToRef); loop != nil {
+			loop.SetState(Terminated)
+		}
+		withdrawConditionalBoundaryEvents(instance, boundaryEvent.AttachedToRef)
+		ce.SetState(Completed)
+	}
+	return []command{activityCommand{
+		sourceId: boundaryEvent.AttachedToRef,
+		element:  ce.Element(),
+	}}
+}
+
+// isConditionalEventTriggered evaluates the condition, and returns true, when it became true,
+// i.e. it's true and was false on the last evaluation
+func isConditionalEventTriggered(instance *processInstanceInfo, ce *conditionalEventActivity) (bool, error) {
+	definition := conditionalEventDefinitionOf(*ce.Element())
+	out, err := instance.ProcessInfo.graph.expressions.evaluate(definition.GetConditionLanguage(), definition.GetCondition(), instance.VariableHolder.Variables())
+	if err != nil {
+		return false, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating condition of conditional event id='%s' name='%s'", (*ce.Element()).GetId(), (*ce.Element()).GetName()),
+			Err: err,
+		}
+	}
+	triggered := out == true && !ce.satisfied
+	ce.satisfied = out == true
+	return triggered, nil
+}
+
+// conditionalEventDefinitionOf returns the conditional event definition of start, intermediate catch and boundary events
+func conditionalEventDefinitionOf(element BPMN20.BaseElement) BPMN20.TConditionalEventDefinition {
+	switch e := element.(type) {
+	case BPMN20.TStartEvent:
+		return e.ConditionalEventDefinition
+	case BPMN20.TIntermediateCatchEvent:
+		return e.ConditionalEventDefinition
+	case BPMN20.TBoundaryEvent:
+		return e.ConditionalEventDefinition
+	}
+	return BPMN20.TConditionalEventDefinition{}
+}
+
+// isWaitingAtConditionalCatchEvent returns true, when a token waits at a conditional intermediate catch event
+func (pii *processInstanceInfo) isWaitingAtConditionalCatchEvent() bool {
+	for _, a := range pii.activities {
+		if ce, ok := a.(*conditionalEventActivity); ok && ce.State() == Active && (*ce.Element()).GetType() == BPMN20.IntermediateCatchEvent {
+			return true
+		}
+	}
+	return false
+}
+
+// isScopeRunBy returns true, when the (sub) process with the given ID is within the chain of the running (sub) processes
+func isScopeRunBy(act activity, scopeId string) bool {
+	for a := act; a != nil; a = parentActivityOf(a) {
+		if (*a.Element()).GetId() == scopeId {
+			return true
+		}
+	}
+	return false
+}
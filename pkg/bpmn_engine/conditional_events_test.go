@@ -0,0 +1,268 @@
+package bpmn_engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_conditional_catch_event_continues_right_away_when_its_condition_is_true(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true, "stockReserved": true})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("ship"))
+	then.AssertThat(t, instance.findActiveActivityByElementId("ready"), is.Nil())
+}
+
+func Test_conditional_catch_event_is_triggered_by_a_completed_job(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("reserve").Handler(func(job ActivatedJob) {
+		job.SetVariable("stockReserved", true)
+		cp.TaskHandler(job)
+	})
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("reserve,ship"))
+}
+
+func Test_conditional_catch_event_is_triggered_by_a_correlated_message(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"stockReserved": true})
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.findActiveActivityByElementId("ready"), is.Not(is.Nil()))
+
+	// when
+	err = bpmnEngine.PublishEventForInstance(instance.InstanceKey, "payment", map[string]interface{}{"paymentReceived": true})
+	then.AssertThat(t, err, is.Nil())
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("ship"))
+	then.AssertThat(t, instance.findActiveActivityByElementId("ready"), is.Nil())
+}
+
+func Test_conditional_catch_event_is_triggered_by_a_set_variable(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		cp := CallPath{}
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+		then.AssertThat(t, err, is.Nil())
+
+		// given
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true})
+		then.AssertThat(t, err, is.Nil())
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		restored.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+		restoredInstance := restored.FindProcessInstance(instance.InstanceKey)
+
+		// when
+		restoredInstance.SetVariable("stockReserved", true)
+		_, err = restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, cp.CallPath, is.EqualTo("ship"))
+		then.AssertThat(t, restoredInstance.GetState(), is.EqualTo(Active))
+	})
+}
+
+func Test_interrupting_conditional_boundary_event_terminates_the_task(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("notify").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true, "stockReserved": true})
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "ship")[0].JobState, is.EqualTo(Active))
+
+	// when
+	instance.SetVariable("cancelled", true)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+	then.AssertThat(t, err, is.Nil())
+	instance.SetVariable("expedite", true)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo(""))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "ship")[0].JobState, is.EqualTo(Terminated))
+	then.AssertThat(t, instance.findActiveActivityByElementId("cancelled"), is.Nil())
+	then.AssertThat(t, instance.findActiveActivityByElementId("expedite"), is.Nil())
+}
+
+func Test_non_interrupting_conditional_boundary_event_is_triggered_whenever_its_condition_becomes_true(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("notify").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true, "stockReserved": true})
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	for _, expedite := range []bool{true, true, false, true} {
+		instance.SetVariable("expedite", expedite)
+		_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+		then.AssertThat(t, err, is.Nil())
+	}
+
+	// then
+	then.AssertThat(t, cp.CallPath, is.EqualTo("notify,notify"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "ship")[0].JobState, is.EqualTo(Active))
+	then.AssertThat(t, instance.findActiveActivityByElementId("cancelled"), is.Not(is.Nil()))
+}
+
+func Test_conditional_boundary_events_are_withdrawn_when_the_task_completes(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("notify").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"paymentReceived": true, "stockReserved": true})
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	instance.SetVariable("expedite", true)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("ship"))
+	then.AssertThat(t, instance.findActiveActivityByElementId("cancelled"), is.Nil())
+	then.AssertThat(t, instance.findActiveActivityByElementId("expedite"), is.Nil())
+}
+
+func Test_conditional_boundary_event_of_a_sub_process_is_triggered_while_it_runs(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-event-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("pick").Handler(func(job ActivatedJob) {
+		job.SetVariable("delayed", true)
+		cp.TaskHandler(job)
+	})
+	bpmnEngine.NewTaskHandler().Type("apologise").Handler(cp.TaskHandler)
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("pick,apologise"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "pack")[0].JobState, is.EqualTo(Active))
+}
+
+func Test_non_interrupting_conditional_event_sub_process_runs_whenever_its_condition_becomes_true(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-event-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("send-reminder").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	for _, remind := range []bool{true, true, false, true} {
+		instance.SetVariable("remind", remind)
+		_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+		then.AssertThat(t, err, is.Nil())
+	}
+
+	// then
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("send-reminder,send-reminder"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "pick")[0].JobState, is.EqualTo(Active))
+}
+
+func Test_interrupting_conditional_event_sub_process_terminates_the_process_scope(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-event-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("handle-cancel").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("send-reminder").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	instance.SetVariable("cancelled", true)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+	then.AssertThat(t, err, is.Nil())
+	instance.SetVariable("remind", true)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("handle-cancel"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "pick")[0].JobState, is.EqualTo(Terminated))
+	then.AssertThat(t, instance.findActiveActivityByElementId("reminder-due"), is.Nil())
+}
+
+func Test_loading_fails_on_conditional_event_without_condition(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	data, err := os.ReadFile("../../test-cases/conditional-events.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData := strings.Replace(string(data), `<bpmn:condition xsi:type="bpmn:tFormalExpression">= expedite = true</bpmn:condition>`, "", 1)
+
+	// when
+	_, err = bpmnEngine.LoadFromBytes([]byte(xmlData))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("conditional event id=expedite has no condition"))
+	then.AssertThat(t, bpmnEngine.processes, has.Length(0))
+}
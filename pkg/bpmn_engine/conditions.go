@@ -3,8 +3,6 @@ package bpmn_engine
 import (
 	"fmt"
 	"strings"
-
-	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
 )
 
 // exclusivelyFilterByConditionExpression
@@ -19,14 +17,14 @@ import (
 // evaluates to true, a runtime exception occurs.
 // A converging Exclusive Gateway is used to merge alternative paths. Each incoming Sequence Flow token is routed
 // to the outgoing Sequence Flow without synchronization.
-func exclusivelyFilterByConditionExpression(flows []BPMN20.TSequenceFlow, variableContext map[string]interface{}) ([]BPMN20.TSequenceFlow, error) {
-	var ret []BPMN20.TSequenceFlow
+// The default flow's condition is ignored, and the other conditions are evaluated in order of their definition.
+func exclusivelyFilterByConditionExpression(flows []*graphFlow, defaultFlowId string, expressions compiledExpressions, variableContext map[string]interface{}) ([]*graphFlow, error) {
+	var ret []*graphFlow
 	flowIds := strings.Builder{}
 	for _, flow := range flows {
-		if flow.HasConditionExpression() {
+		if flow.condition != "" && flow.Id != defaultFlowId {
 			flowIds.WriteString(fmt.Sprintf("[id='%s',name='%s']", flow.Id, flow.Name))
-			expression := flow.GetConditionExpression()
-			out, err := evaluateExpression(expression, variableContext)
+			out, err := expressions.evaluate(flow.conditionLanguage, flow.condition, variableContext)
 			if err != nil {
 				return nil, &ExpressionEvaluationError{
 					Msg: fmt.Sprintf("Error evaluating expression in flow element id='%s' name='%s'", flow.Id, flow.Name),
@@ -40,7 +38,7 @@ func exclusivelyFilterByConditionExpression(flows []BPMN20.TSequenceFlow, variab
 		}
 	}
 	if len(ret) == 0 {
-		ret = append(ret, findDefaultFlow(flows)...)
+		ret = append(ret, findDefaultFlow(flows, defaultFlowId)...)
 	}
 	if len(ret) == 0 {
 		return nil, &ExpressionEvaluationError{
@@ -58,33 +56,61 @@ func exclusivelyFilterByConditionExpression(flows []BPMN20.TSequenceFlow, variab
 // condition Expression does not exclude the evaluation of other condition Expressions. All Sequence Flows with
 // a true evaluation will be traversed by a token. Since each path is considered to be independent, all combinations of the
 // paths MAY be taken, from zero to all.
-func inclusivelyFilterByConditionExpression(flows []BPMN20.TSequenceFlow, variableContext map[string]interface{}) ([]BPMN20.TSequenceFlow, error) {
-	var ret []BPMN20.TSequenceFlow
+// Flows without condition are always taken, the default flow only, when no other flow is; this applies to
+// complex gateways and to conditional sequence flows, which leave activities, as well.
+func inclusivelyFilterByConditionExpression(flows []*graphFlow, defaultFlowId string, expressions compiledExpressions, variableContext map[string]interface{}) ([]*graphFlow, error) {
+	var ret []*graphFlow
+	flowIds := strings.Builder{}
+	anyConditionTrue := false
 	for _, flow := range flows {
-		if flow.HasConditionExpression() {
-			expression := flow.GetConditionExpression()
-			out, err := evaluateExpression(expression, variableContext)
-			if err != nil {
-				return nil, &ExpressionEvaluationError{
-					Msg: fmt.Sprintf("Error evaluating expression in flow element id='%s' name='%s'", flow.Id, flow.Name),
-					Err: err,
-				}
-			}
-			if out == true {
-				ret = append(ret, flow)
+		if flow.Id == defaultFlowId {
+			continue
+		}
+		if flow.condition == "" {
+			ret = append(ret, flow)
+			continue
+		}
+		flowIds.WriteString(fmt.Sprintf("[id='%s',name='%s']", flow.Id, flow.Name))
+		out, err := expressions.evaluate(flow.conditionLanguage, flow.condition, variableContext)
+		if err != nil {
+			return nil, &ExpressionEvaluationError{
+				Msg: fmt.Sprintf("Error evaluating expression in flow element id='%s' name='%s'", flow.Id, flow.Name),
+				Err: err,
 			}
 		}
+		if out == true {
+			ret = append(ret, flow)
+			anyConditionTrue = true
+		}
+	}
+	if !anyConditionTrue {
+		ret = append(ret, findDefaultFlow(flows, defaultFlowId)...)
+	}
+	if len(ret) == 0 && len(flows) > 0 {
+		return nil, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("No default flow, nor matching expressions found, for flow elements: %s", flowIds.String()),
+			Err: nil,
+		}
 	}
-	ret = append(ret, findDefaultFlow(flows)...)
 	return ret, nil
 }
 
-func findDefaultFlow(flows []BPMN20.TSequenceFlow) (ret []BPMN20.TSequenceFlow) {
+func findDefaultFlow(flows []*graphFlow, defaultFlowId string) (ret []*graphFlow) {
 	for _, flow := range flows {
-		if !flow.HasConditionExpression() {
+		if flow.Id == defaultFlowId {
 			ret = append(ret, flow)
 			break
 		}
 	}
 	return ret
 }
+
+// hasConditionalFlows returns true, when any of the flows has a condition
+func hasConditionalFlows(flows []*graphFlow) bool {
+	for _, flow := range flows {
+		if flow.condition != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 package bpmn_engine
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/corbym/gocrest/has"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
 )
@@ -211,7 +215,7 @@ func Test_inclusive_gateway_with_expressions_selects_default(t *testing.T) {
 	then.AssertThat(t, cp.CallPath, is.EqualTo("task-b"))
 }
 
-func Test_inclusive_gateway_executes_all_paths(t *testing.T) {
+func Test_inclusive_gateway_executes_all_matching_paths_but_not_the_default(t *testing.T) {
 	// setup
 	bpmnEngine := New()
 	cp := CallPath{}
@@ -229,6 +233,137 @@ func Test_inclusive_gateway_executes_all_paths(t *testing.T) {
 	_, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, variables)
 	then.AssertThat(t, err, is.Nil())
 
+	// then
+	then.AssertThat(t, cp.CallPath, is.EqualTo("task-a,task-b"))
+}
+
+func Test_inclusive_gateway_always_takes_flows_without_condition(t *testing.T) {
+	// setup
+	xmlData, err := os.ReadFile("../../test-cases/inclusive-gateway-multiple-tasks.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), ` default="Flow_0hei4kw"`, "", 1))
+	xmlData = []byte(strings.Replace(string(xmlData), `<bpmn:conditionExpression xsi:type="bpmn:tFormalExpression">=price = 0</bpmn:conditionExpression>`, "", 1))
+	bpmnEngine := New()
+	cp := CallPath{}
+
+	// given
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Id("task-default").Handler(cp.TaskHandler)
+	variables := map[string]interface{}{
+		"price": 1,
+	}
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, variables)
+	then.AssertThat(t, err, is.Nil())
+
 	// then
 	then.AssertThat(t, cp.CallPath, is.EqualTo("task-a,task-b,task-default"))
 }
+
+func Test_exclusive_gateway_ignores_the_condition_of_its_default_flow(t *testing.T) {
+	tests := []struct {
+		a        bool
+		b        bool
+		callPath string
+	}{
+		{true, false, "task-a"},
+		{false, true, "task-b"},
+		{true, true, "task-a"},
+		{false, false, "fallback"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("a=%v,b=%v", test.a, test.b), func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-default-attribute.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"fallback", "task-a", "task-b"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"a": test.a, "b": test.b})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_conditional_sequence_flows_leave_tasks_without_gateway(t *testing.T) {
+	tests := []struct {
+		amount   int
+		express  bool
+		callPath string
+	}{
+		{50, false, "check,archive,ship-standard"},
+		{50, true, "check,ship-express,archive"},
+		{500, true, "check,review,ship-express,archive"},
+	}
+	for _, test := range tests {
+		t.Run(test.callPath, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/conditional-sequence-flows.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"check", "review", "ship-express", "ship-standard", "archive"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"amount": test.amount, "express": test.express})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_loading_fails_on_invalid_default_flows(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		old         string
+		new         string
+		errorPrefix string
+	}{
+		{
+			"multiple unconditioned flows",
+			"exclusive-gateway-multiple-tasks.bpmn",
+			`<bpmn:conditionExpression xsi:type="bpmn:tFormalExpression">=price = 0</bpmn:conditionExpression>`,
+			"",
+			"gateway id=Gateway_01wr5g0 has more than one outgoing sequence flow without condition",
+		},
+		{
+			"unknown default flow",
+			"exclusive-gateway-default-attribute.bpmn",
+			`default="to-fallback"`,
+			`default="not-existing"`,
+			"default flow id=not-existing of element id=route is not one of its outgoing sequence flows",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			xmlData, err := os.ReadFile("../../test-cases/" + test.file)
+			then.AssertThat(t, err, is.Nil())
+			xmlData = []byte(strings.Replace(string(xmlData), test.old, test.new, 1))
+			bpmnEngine := New()
+
+			// when
+			_, err = bpmnEngine.LoadFromBytes(xmlData)
+
+			// then
+			then.AssertThat(t, err, is.Not(is.Nil()))
+			then.AssertThat(t, err.Error(), has.Prefix(test.errorPrefix))
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pbinitiative/feel"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/DMN13"
+)
+
+// decisionTable is a DMN decision table, with all its FEEL expressions and unary tests parsed once, when it's loaded.
+// It's evaluated with the engine's custom functions and context values (see WithExpressionFunction),
+// but always as FEEL, regardless of the registered expression evaluators, as by the DMN spec.
+type decisionTable struct {
+	hitPolicy   DMN13.HitPolicy
+	aggregation DMN13.BuiltinAggregator
+	inputs      []feel.Node
+	outputNames []string // empty for a single output, which isn't a context then
+	rules       []decisionRule
+	scope       map[string]interface{}
+}
+
+type decisionRule struct {
+	id            string
+	inputEntries  []unaryTests
+	outputEntries []feel.Node // nil for empty entries, which result in null
+}
+
+// unaryTests are the tests of an input entry, e.g. `"gold", "silver"`, `[1..10]` or `not(> 5)`;
+// an empty or '-' input entry has no tests, and matches any input
+type unaryTests struct {
+	tests   []feel.Node
+	negated bool
+}
+
+// unaryTestsInput is the name of the input value in unary tests; the FEEL parser doesn't support '?' in expressions,
+// like `? > limit`, so it's replaced by this name before parsing
+const unaryTestsInput = "$input"
+
+var supportedHitPolicies = []DMN13.HitPolicy{DMN13.Unique, DMN13.First, DMN13.Any, DMN13.Collect, DMN13.RuleOrder}
+
+func newDecisionTable(decision DMN13.TDecision, scope map[string]interface{}) (*decisionTable, error) {
+	table := decision.DecisionTable
+	if table == nil {
+		return nil, newEngineErrorf("decision id=%s has no decision table, which is the only supported decision logic", decision.Id)
+	}
+	dt := &decisionTable{hitPolicy: table.GetHitPolicy(), aggregation: table.Aggregation, scope: scope}
+	if !slices.Contains(supportedHitPolicies, dt.hitPolicy) {
+		return nil, newEngineErrorf("hit policy %s of decision id=%s isn't supported", dt.hitPolicy, decision.Id)
+	}
+	if err := dt.checkAggregation(decision.Id, len(table.Outputs)); err != nil {
+		return nil, err
+	}
+	if len(table.Outputs) == 0 {
+		return nil, newEngineErrorf("decision table of decision id=%s has no output", decision.Id)
+	}
+	if len(table.Outputs) > 1 {
+		for _, output := range table.Outputs {
+			if output.Name == "" {
+				return nil, newEngineErrorf("output id=%s of decision id=%s needs a name, because there are multiple outputs", output.Id, decision.Id)
+			}
+			dt.outputNames = append(dt.outputNames, output.Name)
+		}
+	}
+	for _, input := range table.Inputs {
+		node, err := parseDecisionExpression(input.Id, "inputExpression", input.InputExpression.Text)
+		if err != nil {
+			return nil, err
+		}
+		dt.inputs = append(dt.inputs, node)
+	}
+	for _, rule := range table.Rules {
+		if len(rule.InputEntries) != len(table.Inputs) || len(rule.OutputEntries) != len(table.Outputs) {
+			return nil, newEngineErrorf("rule id=%s of decision id=%s must have %d input and %d output entries",
+				rule.Id, decision.Id, len(table.Inputs), len(table.Outputs))
+		}
+		dr := decisionRule{id: rule.Id}
+		for _, entry := range rule.InputEntries {
+			tests, err := parseUnaryTests(entry.Id, entry.Text)
+			if err != nil {
+				return nil, err
+			}
+			dr.inputEntries = append(dr.inputEntries, tests)
+		}
+		for _, entry := range rule.OutputEntries {
+			var node feel.Node
+			if strings.TrimSpace(entry.Text) != "" {
+				var err error
+				if node, err = parseDecisionExpression(entry.Id, "outputEntry", entry.Text); err != nil {
+					return nil, err
+				}
+			}
+			dr.outputEntries = append(dr.outputEntries, node)
+		}
+		dt.rules = append(dt.rules, dr)
+	}
+	return dt, nil
+}
+
+func (dt *decisionTable) checkAggregation(decisionId string, outputs int) error {
+	switch dt.aggregation {
+	case "", DMN13.Count:
+	case DMN13.Sum, DMN13.Min, DMN13.Max:
+		if outputs != 1 {
+			return newEngineErrorf("aggregation %s of decision id=%s needs exactly one output", dt.aggregation, decisionId)
+		}
+	default:
+		return newEngineErrorf("aggregation %s of decision id=%s isn't supported", dt.aggregation, decisionId)
+	}
+	if dt.aggregation != "" && dt.hitPolicy != DMN13.Collect {
+		return newEngineErrorf("aggregation %s of decision id=%s is only allowed with hit policy COLLECT", dt.aggregation, decisionId)
+	}
+	return nil
+}
+
+func parseDecisionExpression(elementId string, attribute string, expression string) (feel.Node, error) {
+	node, err := feel.ParseString(strings.TrimSpace(expression))
+	if err != nil {
+		return nil, &ExpressionSyntaxError{ElementId: elementId, Attribute: attribute, Language: ExpressionLanguageFEEL, Expression: expression, Err: err}
+	}
+	return node, nil
+}
+
+func parseUnaryTests(elementId string, text string) (unaryTests, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text == "-" {
+		return unaryTests{}, nil
+	}
+	ut := unaryTests{}
+	if inner, found := negatedUnaryTests(text); found {
+		ut.negated = true
+		text = inner
+	}
+	node, err := parseDecisionExpression(elementId, "inputEntry", replaceUnaryTestsInput(text))
+	if err != nil {
+		return unaryTests{}, err
+	}
+	if multiTests, ok := node.(*feel.MultiTests); ok {
+		ut.tests = multiTests.Elements
+	} else {
+		ut.tests = []feel.Node{node}
+	}
+	for _, test := range ut.tests {
+		// comparisons like `> 5` are parsed with an implicit '?' as left operand
+		if binop, ok := test.(*feel.Binop); ok {
+			if v, ok := binop.Left.(*feel.Var); ok && v.Name == "?" {
+				binop.Left = &feel.Var{Name: unaryTestsInput}
+			}
+		}
+	}
+	return ut, nil
+}
+
+// negatedUnaryTests returns the tests within `not(...)`, when the whole text is negated
+func negatedUnaryTests(text string) (string, bool) {
+	if !strings.HasPrefix(text, "not(") || !strings.HasSuffix(text, ")") {
+		return "", false
+	}
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(text)-1 {
+				return "", false // e.g. `not(a) or not(b)`
+			}
+		}
+	}
+	return text[len("not(") : len(text)-1], true
+}
+
+// replaceUnaryTestsInput replaces '?' outside of string literals
+func replaceUnaryTestsInput(text string) string {
+	var sb strings.Builder
+	inString := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString && c == '\\' && i+1 < len(text):
+			sb.WriteByte(c)
+			i++
+			c = text[i]
+		case c == '"':
+			inString = !inString
+		case c == '?' && !inString:
+			sb.WriteString(unaryTestsInput)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// evaluate returns the result of the decision, as Go value (see fromFeelValue):
+// the output of a single matching rule, a list of outputs for hit policies COLLECT and RULE ORDER,
+// or the aggregated value. Outputs of tables with multiple outputs are contexts, by output name.
+func (dt *decisionTable) evaluate(variables map[string]interface{}) (result interface{}, err error) {
+	defer recoverFeelPanic(&err)
+	intp := feel.NewIntepreter()
+	if len(dt.scope) > 0 {
+		intp.Push(dt.scope)
+	}
+	values := &feelValues{}
+	intp.Push(values.toFeelScope(variables))
+	inputValues := make([]interface{}, len(dt.inputs))
+	for i, input := range dt.inputs {
+		value, err := input.Eval(intp)
+		if err != nil {
+			return nil, fmt.Errorf("can't evaluate input %d: %w", i+1, err)
+		}
+		inputValues[i] = value
+	}
+	var outputs []interface{}
+	var ruleIds []string
+	for _, rule := range dt.rules {
+		matches, err := rule.matches(intp, inputValues)
+		if err != nil {
+			return nil, fmt.Errorf("can't evaluate rule id=%s: %w", rule.id, err)
+		}
+		if !matches {
+			continue
+		}
+		output, err := dt.output(intp, rule)
+		if err != nil {
+			return nil, fmt.Errorf("can't evaluate output of rule id=%s: %w", rule.id, err)
+		}
+		outputs = append(outputs, output)
+		ruleIds = append(ruleIds, rule.id)
+		if dt.hitPolicy == DMN13.First {
+			break
+		}
+	}
+	return dt.hitResult(values, outputs, ruleIds)
+}
+
+func (rule decisionRule) matches(intp *feel.Interpreter, inputValues []interface{}) (bool, error) {
+	for i, entry := range rule.inputEntries {
+		intp.Push(map[string]interface{}{unaryTestsInput: inputValues[i]})
+		matches, err := entry.matches(intp, inputValues[i])
+		intp.Pop()
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (ut unaryTests) matches(intp *feel.Interpreter, input interface{}) (bool, error) {
+	if len(ut.tests) == 0 {
+		return true, nil
+	}
+	for _, test := range ut.tests {
+		matches, err := unaryTestMatches(intp, test, input)
+		if err != nil {
+			return false, err
+		}
+		if matches {
+			return !ut.negated, nil
+		}
+	}
+	return ut.negated, nil
+}
+
+// unaryTestMatches compares the input with the test's value, e.g. `"gold"`,
+// checks if it's contained, e.g. in `[1..10]` or `[1, 2, 3]`,
+// or uses the result of comparisons and boolean expressions, e.g. `> 5` or `? > limit`
+func unaryTestMatches(intp *feel.Interpreter, test feel.Node, input interface{}) (bool, error) {
+	inputVar := &feel.Var{Name: unaryTestsInput}
+	if binop, ok := test.(*feel.Binop); ok && isUnaryTestsInput(binop.Left) && binop.Op != "=" && binop.Op != "!=" {
+		if isFeelNull(input) {
+			return false, nil // comparing null is null, and not true as by the FEEL library
+		}
+	}
+	value, err := test.Eval(intp)
+	if err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case bool:
+		if _, inputIsBool := input.(bool); !inputIsBool || !isUnaryTestsValue(test) {
+			return v, nil
+		}
+	case *feel.RangeValue:
+		return v.Contains(input), nil
+	case []interface{}:
+		if _, inputIsList := input.([]interface{}); !inputIsList {
+			result, err := (&feel.Binop{Op: "in", Left: inputVar, Right: test}).Eval(intp)
+			return result == true, err
+		}
+	}
+	result, err := (&feel.Binop{Op: "=", Left: inputVar, Right: test}).Eval(intp)
+	return result == true, err
+}
+
+// isUnaryTestsValue returns true, when the test is a value like `true` or `approved`, which is compared with the input,
+// and not a comparison or boolean expression like `? = false` or `limit > 5`, whose result is the test's result
+func isUnaryTestsValue(test feel.Node) bool {
+	switch n := test.(type) {
+	case *feel.BoolNode, *feel.DotOp:
+		return true
+	case *feel.Var:
+		return n.Name != unaryTestsInput
+	}
+	return false
+}
+
+func isUnaryTestsInput(node feel.Node) bool {
+	v, ok := node.(*feel.Var)
+	return ok && v.Name == unaryTestsInput
+}
+
+func isFeelNull(value interface{}) bool {
+	switch value.(type) {
+	case nil, feel.NullValue, *feel.NullValue:
+		return true
+	}
+	return false
+}
+
+// output returns the rule's output, as FEEL value
+func (dt *decisionTable) output(intp *feel.Interpreter, rule decisionRule) (interface{}, error) {
+	values := make([]interface{}, len(rule.outputEntries))
+	for i, entry := range rule.outputEntries {
+		if entry == nil {
+			continue
+		}
+		value, err := entry.Eval(intp)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	if len(dt.outputNames) == 0 {
+		return values[0], nil
+	}
+	output := map[string]interface{}{}
+	for i, name := range dt.outputNames {
+		output[name] = values[i]
+	}
+	return output, nil
+}
+
+func (dt *decisionTable) hitResult(values *feelValues, outputs []interface{}, ruleIds []string) (interface{}, error) {
+	switch dt.hitPolicy {
+	case DMN13.Unique:
+		if len(outputs) > 1 {
+			return nil, fmt.Errorf("hit policy UNIQUE, but multiple rules matched: %s", strings.Join(ruleIds, ", "))
+		}
+	case DMN13.Any:
+		for i := 1; i < len(outputs); i++ {
+			if !reflect.DeepEqual(values.fromFeelValue(outputs[0]), values.fromFeelValue(outputs[i])) {
+				return nil, fmt.Errorf("hit policy ANY, but the outputs of the matching rules differ: %s", strings.Join(ruleIds, ", "))
+			}
+		}
+	case DMN13.Collect, DMN13.RuleOrder:
+		return dt.aggregate(values, outputs)
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	return values.fromFeelValue(outputs[0]), nil
+}
+
+var aggregationFunctions = map[DMN13.BuiltinAggregator]string{
+	DMN13.Sum: "sum",
+	DMN13.Min: "min",
+	DMN13.Max: "max",
+}
+
+// aggregate uses the FEEL functions sum, min and max, e.g. the sum of no outputs is 0, but the min is null
+func (dt *decisionTable) aggregate(values *feelValues, outputs []interface{}) (interface{}, error) {
+	list := []interface{}{}
+	for _, output := range outputs {
+		if dt.aggregation != "" && isFeelNull(output) {
+			continue // aggregations ignore null outputs
+		}
+		list = append(list, output)
+	}
+	switch dt.aggregation {
+	case "":
+		return values.fromFeelValue(list), nil
+	case DMN13.Count:
+		return len(list), nil
+	}
+	result, err := feel.EvalStringWithScope(aggregationFunctions[dt.aggregation]+"(outputs)", map[string]interface{}{"outputs": list})
+	if err != nil {
+		return nil, fmt.Errorf("can't aggregate outputs with %s: %w", dt.aggregation, err)
+	}
+	return fromFeelValue(result), nil
+}
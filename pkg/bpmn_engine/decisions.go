@@ -0,0 +1,127 @@
+package bpmn_engine
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/DMN13"
+)
+
+// DecisionInfo describes a DMN decision, which can be called by business rule tasks
+type DecisionInfo struct {
+	DecisionId  string // The ID as defined in the DMN file
+	Name        string // The name as defined in the DMN file
+	Version     int32  // A version of the decision, default=1, incremented, when another decision with the same ID is loaded
+	DecisionKey int64  // The engines key for this given decision with version
+	table       *decisionTable
+}
+
+// decisionResource is a loaded DMN file, which may contain multiple decisions
+type decisionResource struct {
+	decisions    []*DecisionInfo // in order of the definitions
+	dmnData      string          // the raw source data, compressed and encoded via ascii85
+	resourceName string          // some name for the resource
+	checksum     [16]byte        // internal checksum to identify different versions
+}
+
+// LoadDecisionFromFile loads a given DMN file by filename into the engine
+// and returns DecisionInfo details for all its decisions
+func (state *BpmnEngineState) LoadDecisionFromFile(filename string) ([]*DecisionInfo, error) {
+	xmlData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return state.loadDecisions(xmlData, filename)
+}
+
+// LoadDecisionFromBytes loads a given DMN file by xmlData byte array into the engine
+// and returns DecisionInfo details for all its decisions.
+// Decision tables with the hit policies UNIQUE, FIRST, ANY, COLLECT (also with aggregations) and RULE ORDER are supported.
+// Returns an ExpressionSyntaxError, when any expression or unary test can't be parsed.
+func (state *BpmnEngineState) LoadDecisionFromBytes(xmlData []byte) ([]*DecisionInfo, error) {
+	return state.loadDecisions(xmlData, "")
+}
+
+func (state *BpmnEngineState) loadDecisions(xmlData []byte, resourceName string) ([]*DecisionInfo, error) {
+	md5sum := md5.Sum(xmlData)
+	for _, resource := range state.decisionResources {
+		if areEqual(resource.checksum, md5sum) {
+			return resource.decisions, nil
+		}
+	}
+	var definitions DMN13.TDefinitions
+	if err := xml.Unmarshal(xmlData, &definitions); err != nil {
+		return nil, err
+	}
+	resource := &decisionResource{
+		dmnData:      compressAndEncode(xmlData),
+		resourceName: resourceName,
+		checksum:     md5sum,
+	}
+	for _, decision := range definitions.Decisions {
+		table, err := newDecisionTable(decision, state.expressionScope)
+		if err != nil {
+			return nil, err
+		}
+		var version int32 = 1
+		if latest := state.findLatestDecision(decision.Id); latest != nil {
+			version = latest.Version + 1
+		}
+		resource.decisions = append(resource.decisions, &DecisionInfo{
+			DecisionId:  decision.Id,
+			Name:        decision.Name,
+			Version:     version,
+			DecisionKey: state.generateKey(),
+			table:       table,
+		})
+	}
+	state.decisionResources = append(state.decisionResources, resource)
+	return resource.decisions, nil
+}
+
+// FindDecisionsById returns all registered decisions with given ID
+// result array is ordered by version number, from 1 (first) and largest version (last)
+func (state *BpmnEngineState) FindDecisionsById(id string) (infos []*DecisionInfo) {
+	for _, resource := range state.decisionResources {
+		for _, decision := range resource.decisions {
+			if decision.DecisionId == id {
+				infos = append(infos, decision)
+			}
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Version < infos[j].Version
+	})
+	return infos
+}
+
+func (state *BpmnEngineState) findLatestDecision(id string) *DecisionInfo {
+	decisions := state.FindDecisionsById(id)
+	if len(decisions) == 0 {
+		return nil
+	}
+	return decisions[len(decisions)-1]
+}
+
+// EvaluateDecision evaluates the latest version of the decision with given ID, with the given variables,
+// like a business rule task does. The result is the output of the matching rule, a context (map) by output name,
+// when the decision table has multiple outputs, or a list of those for the hit policies COLLECT and RULE ORDER.
+// Returns a BpmnEngineError, when no decision with given ID was found,
+// or an ExpressionEvaluationError, when the evaluation failed, e.g. multiple rules matched with hit policy UNIQUE.
+func (state *BpmnEngineState) EvaluateDecision(decisionId string, variables map[string]interface{}) (interface{}, error) {
+	decision := state.findLatestDecision(decisionId)
+	if decision == nil {
+		return nil, newEngineErrorf("no decision found with id=%s", decisionId)
+	}
+	result, err := decision.table.evaluate(variables)
+	if err != nil {
+		return nil, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating decision id=%s version=%d", decision.DecisionId, decision.Version),
+			Err: err,
+		}
+	}
+	return result, nil
+}
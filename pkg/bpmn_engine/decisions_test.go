@@ -0,0 +1,319 @@
+package bpmn_engine
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/pbinitiative/feel"
+)
+
+func Test_business_rule_task_stores_decision_result_in_result_variable(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	_, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-dish.dmn")
+	then.AssertThat(t, err, is.Nil())
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/business-rule-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	var dish interface{}
+	bpmnEngine.NewTaskHandler().Type("cook").Handler(func(job ActivatedJob) {
+		dish = job.Variable("dish")
+		job.Complete()
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+		"season":     "Spring",
+		"guestCount": 6,
+	})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, dish, is.EqualTo("Steak"))
+}
+
+func Test_business_rule_task_fails_when_decision_is_not_loaded(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/business-rule-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("no decision found with id=dish"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+}
+
+func Test_business_rule_task_fails_when_decision_evaluation_fails(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	xmlData, err := os.ReadFile("../../test-cases/business-rule-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), `decisionId="dish"`, `decisionId="unique"`, 1))
+	_, err = bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-hit-policies.dmn")
+	then.AssertThat(t, err, is.Nil())
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"amount": 120})
+
+	// then
+	var evaluationError *ExpressionEvaluationError
+	then.AssertThat(t, errors.As(err, &evaluationError), is.True())
+	then.AssertThat(t, err.Error(), is.ValueContaining("unique-gold"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+}
+
+func Test_business_rule_task_fails_when_mapping_fails(t *testing.T) {
+	tests := []struct {
+		mapping string
+		message string
+	}{
+		{`<zeebe:input source="=1 - rebate" target="factor" />`, "input mapping"},
+		{`<zeebe:output source="=1 - rebate" target="price" />`, "output mapping"},
+	}
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			_, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-dish.dmn")
+			then.AssertThat(t, err, is.Nil())
+			xmlData, err := os.ReadFile("../../test-cases/business-rule-task.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			xmlData = []byte(strings.Replace(string(xmlData), `resultVariable="dish" />`, `resultVariable="dish" /><zeebe:ioMapping>`+test.mapping+`</zeebe:ioMapping>`, 1))
+			process, err := bpmnEngine.LoadFromBytes(xmlData)
+			then.AssertThat(t, err, is.Nil())
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+				"season":     "Spring",
+				"guestCount": 6,
+				"rebate":     "none",
+			})
+
+			// then
+			var evaluationError *ExpressionEvaluationError
+			then.AssertThat(t, errors.As(err, &evaluationError), is.True())
+			then.AssertThat(t, evaluationError.Msg, is.EqualTo("Error evaluating "+test.message+" in business rule task id='determine-dish' name='Determine dish'"))
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+		})
+	}
+}
+
+func Test_decision_hit_policies(t *testing.T) {
+	tests := []struct {
+		decisionId string
+		amount     interface{}
+		expected   interface{}
+	}{
+		{"unique", 70, "silver"},
+		{"unique", 10, nil},
+		{"first", 120, "gold"},
+		{"first", 70, "silver"},
+		{"any", 200, "gold"},
+		{"any", 60, "silver"},
+		{"rule-order", 200, []interface{}{20, 5}},
+		{"rule-order", 20, []interface{}{5}},
+		{"collect-sum", 200, 25},
+		{"collect-sum", -1, 5},
+		{"collect-count", 200, 2},
+		{"collect-min", 200, 5},
+		{"collect-max", 200, 20},
+		{"collect-max", -1, nil},
+		{"multiple-outputs", 100, map[string]interface{}{"level": "gold", "discount": 0.15}},
+		{"multiple-outputs", 1, map[string]interface{}{"level": "none", "discount": nil}},
+	}
+	// setup
+	bpmnEngine := New()
+	_, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-hit-policies.dmn")
+	then.AssertThat(t, err, is.Nil())
+	for _, test := range tests {
+		t.Run(test.decisionId, func(t *testing.T) {
+			// when
+			result, err := bpmnEngine.EvaluateDecision(test.decisionId, map[string]interface{}{"amount": test.amount})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, result, is.EqualTo(test.expected))
+		})
+	}
+}
+
+func Test_decision_hit_policy_violations_fail_the_evaluation(t *testing.T) {
+	tests := []struct {
+		decisionId string
+		expected   string
+	}{
+		{"unique", "unique-gold"},
+		{"any", "any-silver"},
+	}
+	// setup
+	bpmnEngine := New()
+	_, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-hit-policies.dmn")
+	then.AssertThat(t, err, is.Nil())
+	for _, test := range tests {
+		t.Run(test.decisionId, func(t *testing.T) {
+			// when
+			_, err := bpmnEngine.EvaluateDecision(test.decisionId, map[string]interface{}{"amount": 120})
+
+			// then
+			var evaluationError *ExpressionEvaluationError
+			then.AssertThat(t, errors.As(err, &evaluationError), is.True())
+			then.AssertThat(t, err.Error(), is.ValueContaining(test.expected))
+		})
+	}
+}
+
+func Test_decision_collect_evaluates_every_matching_rule(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	_, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-dish.dmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	result, err := bpmnEngine.EvaluateDecision("beverages", map[string]interface{}{
+		"dish":               "Spareribs",
+		"guestsWithChildren": true,
+	})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result, is.EqualTo([]interface{}{"Aecht Schlenkerla Rauchbier", "Water", "Apple Juice"}))
+}
+
+func Test_unary_tests(t *testing.T) {
+	tests := []struct {
+		unaryTests string
+		input      interface{}
+		expected   bool
+	}{
+		{`-`, "anything", true},
+		{``, nil, true},
+		{`"gold"`, "gold", true},
+		{`"gold"`, "silver", false},
+		{`"gold", "silver"`, "silver", true},
+		{`not("gold", "silver")`, "silver", false},
+		{`not("gold", "silver")`, "bronze", true},
+		{`5`, 5, true},
+		{`> 5`, 6, true},
+		{`> 5`, 5, false},
+		{`> 5`, nil, false},
+		{`<= 5, > 10`, 11, true},
+		{`[1..10]`, 10, true},
+		{`[1..10)`, 10, false},
+		{`(1..10]`, 1, false},
+		{`? > 3 and ? < 7`, 4, true},
+		{`? > 3 and ? < 7`, 7, false},
+		{`true`, true, true},
+		{`false`, true, false},
+		{`false`, false, true},
+		{`? = true`, true, true},
+		{`? = true`, false, false},
+		{`? = false`, false, true},
+		{`? = false`, true, false},
+		{`? != false`, false, false},
+		{`null`, nil, true},
+		{`"what?"`, "what?", true},
+	}
+	for _, test := range tests {
+		t.Run(test.unaryTests, func(t *testing.T) {
+			// setup
+			input := toFeelValue(test.input)
+			intp := feel.NewIntepreter()
+			intp.Push(map[string]interface{}{unaryTestsInput: input})
+
+			// given
+			ut, err := parseUnaryTests("rule", test.unaryTests)
+			then.AssertThat(t, err, is.Nil())
+
+			// when
+			matches, err := ut.matches(intp, input)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, matches, is.EqualTo(test.expected))
+		})
+	}
+}
+
+func Test_loading_decision_with_invalid_unary_test_fails(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	xmlData, err := os.ReadFile("../../test-cases/decision-dish.dmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), "<text>[5..8]</text>", "<text>[5..</text>", 1))
+
+	// when
+	_, err = bpmnEngine.LoadDecisionFromBytes(xmlData)
+
+	// then
+	var syntaxError *ExpressionSyntaxError
+	then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+	then.AssertThat(t, syntaxError.ElementId, is.EqualTo("UnaryTests_8"))
+	then.AssertThat(t, syntaxError.Attribute, is.EqualTo("inputEntry"))
+	then.AssertThat(t, bpmnEngine.FindDecisionsById("dish"), has.Length(0))
+}
+
+func Test_loading_a_changed_decision_creates_a_new_version(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	xmlData, err := os.ReadFile("../../test-cases/decision-dish.dmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	first, err := bpmnEngine.LoadDecisionFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	same, err := bpmnEngine.LoadDecisionFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	changed, err := bpmnEngine.LoadDecisionFromBytes([]byte(strings.Replace(string(xmlData), `"Steak"`, `"Tofu"`, 1)))
+	then.AssertThat(t, err, is.Nil())
+
+	// then
+	then.AssertThat(t, first[0].Version, is.EqualTo(int32(1)))
+	then.AssertThat(t, same[0].DecisionKey, is.EqualTo(first[0].DecisionKey))
+	then.AssertThat(t, changed[0].Version, is.EqualTo(int32(2)))
+	result, err := bpmnEngine.EvaluateDecision("dish", map[string]interface{}{"season": "Spring", "guestCount": 6})
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result, is.EqualTo("Tofu"))
+}
+
+func Test_decisions_are_restored_when_unmarshalling(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		decisions, err := bpmnEngine.LoadDecisionFromFile("../../test-cases/decision-dish.dmn")
+		then.AssertThat(t, err, is.Nil())
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/business-rule-task.bpmn")
+		then.AssertThat(t, err, is.Nil())
+
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+
+		// then
+		restoredDecisions := restored.FindDecisionsById("dish")
+		then.AssertThat(t, restoredDecisions, has.Length(1))
+		then.AssertThat(t, restoredDecisions[0].DecisionKey, is.EqualTo(decisions[0].DecisionKey))
+		var dish interface{}
+		restored.NewTaskHandler().Type("cook").Handler(func(job ActivatedJob) {
+			dish = job.Variable("dish")
+			job.Complete()
+		})
+		instance, err := restored.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+			"season":     "Winter",
+			"guestCount": 2,
+		})
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, dish, is.EqualTo("Roastbeef"))
+	})
+}
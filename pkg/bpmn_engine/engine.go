@@ -24,26 +24,35 @@ type BpmnEngine interface {
 	FindProcessesById(id string) []*ProcessInfo
 }
 
+// EngineOption configures the engine, when it's created via New or NewWithName
+type EngineOption func(state *BpmnEngineState)
+
 // New creates a new instance of the BPMN Engine;
-func New() BpmnEngineState {
-	return NewWithName(fmt.Sprintf("Bpmn-Engine-%d", getGlobalSnowflakeIdGenerator().Generate().Int64()))
+func New(options ...EngineOption) BpmnEngineState {
+	return NewWithName(fmt.Sprintf("Bpmn-Engine-%d", getGlobalSnowflakeIdGenerator().Generate().Int64()), options...)
 }
 
 // NewWithName creates an engine with an arbitrary name of the engine;
 // useful in case you have multiple ones, in order to distinguish them;
 // also stored in when marshalling a process instance state, in case you want to store some special identifier
-func NewWithName(name string) BpmnEngineState {
+func NewWithName(name string, options ...EngineOption) BpmnEngineState {
 	snowflakeIdGenerator := getGlobalSnowflakeIdGenerator()
-	return BpmnEngineState{
+	expressionScope := map[string]interface{}{}
+	state := BpmnEngineState{
 		name:                 name,
 		processes:            []*ProcessInfo{},
 		processInstances:     []*processInstanceInfo{},
 		taskHandlers:         []*taskHandler{},
-		jobs:                 []*job{},
-		messageSubscriptions: []*MessageSubscription{},
+		processInstanceIndex: map[int64]*processInstanceInfo{},
 		snowflake:            snowflakeIdGenerator,
 		exporters:            []exporter.EventExporter{},
+		expressionScope:      expressionScope,
+		expressionEvaluators: map[string]ExpressionEvaluator{ExpressionLanguageFEEL: feelEvaluator{scope: expressionScope}},
+	}
+	for _, option := range options {
+		option(&state)
 	}
+	return state
 }
 
 // CreateInstanceById creates a new instance for a process with given process ID and uses latest version (if available)
@@ -76,8 +85,9 @@ func (state *BpmnEngineState) CreateInstance(processKey int64, variableContext m
 				CreatedAt:      time.Now(),
 				ActivityState:  Ready,
 			}
-			state.processInstances = append(state.processInstances, &processInstanceInfo)
+			state.addProcessInstance(&processInstanceInfo)
 			state.exportProcessInstanceEvent(*process, processInstanceInfo)
+			state.history.recordInstanceCreated(&processInstanceInfo)
 			return &processInstanceInfo, nil
 		}
 	}
@@ -114,27 +124,33 @@ func (state *BpmnEngineState) CreateAndRunInstance(processKey int64, variableCon
 // returns nil, nil when no process instance was found;
 // might return BpmnEngineError or ExpressionEvaluationError.
 func (state *BpmnEngineState) RunOrContinueInstance(processInstanceKey int64) (*processInstanceInfo, error) {
-	for _, pi := range state.processInstances {
-		if processInstanceKey == pi.InstanceKey {
-			return pi, state.run(pi.ProcessInfo.definitions.Process, pi, pi)
-		}
+	if pi := state.FindProcessInstance(processInstanceKey); pi != nil {
+		return pi, state.run(pi.ProcessInfo.definitions.Process, pi, pi)
 	}
 	return nil, nil
 }
 
 func (state *BpmnEngineState) run(process BPMN20.ProcessElement, instance *processInstanceInfo, currentActivity activity) (err error) {
 	var commandQueue []command
+	graph := instance.ProcessInfo.graph
+	if currentActivity == activity(instance) {
+		instance.running = true
+		defer func() { instance.running = false }()
+	}
 
 	switch currentActivity.State() {
 	case Ready:
 		// use start events to start the instance
-		for _, startEvent := range process.GetStartEvents() {
-			var be BPMN20.BaseElement = startEvent
+		for _, startEvent := range graph.startEvents[process.GetId()] {
 			commandQueue = append(commandQueue, activityCommand{
-				element: &be,
+				element: startEvent,
 			})
 		}
 		currentActivity.SetState(Active)
+		if err := state.armEventSubProcesses(instance, process); err != nil {
+			instance.ActivityState = Failed
+			return err
+		}
 		// TODO: check? export process EVENT
 	case Active:
 		jobs := state.findActiveJobsForContinuation(instance)
@@ -157,29 +173,59 @@ func (state *BpmnEngineState) run(process BPMN20.ProcessElement, instance *proce
 				originActivity: timer.originActivity,
 			})
 		}
+		if len(commandQueue) == 0 {
+			// only the variables might have changed, e.g. by SetVariable
+			commandQueue = state.triggerConditionalEvents(currentActivity, instance)
+		}
+	}
+
+	err = state.runCommands(process, instance, currentActivity, commandQueue)
+
+	// sub-processes are run nested, so only the outermost run finishes the instance
+	if currentActivity == activity(instance) {
+		state.finishRun(instance)
 	}
 
+	return err
+}
+
+// runCommands runs the commands, and the ones which follow, until all tokens of the (sub) process wait or ended
+func (state *BpmnEngineState) runCommands(process BPMN20.ProcessElement, instance *processInstanceInfo, currentActivity activity, commandQueue []command) (err error) {
+	graph := instance.ProcessInfo.graph
+
 	// *** MAIN LOOP ***
+	interruptionsAtStart := len(instance.interruptedScopes)
 	for len(commandQueue) > 0 {
 		cmd := commandQueue[0]
 		commandQueue = commandQueue[1:]
+		if instance.isInterrupted(commandElementId(cmd), interruptionsAtStart) {
+			continue
+		}
 
 		switch cmd.Type() {
 		case flowTransitionType:
 			sourceActivity := cmd.(flowTransitionCommand).sourceActivity
 			flowId := cmd.(flowTransitionCommand).sequenceFlowId
-			nextFlows := BPMN20.FindSequenceFlows(process, []string{flowId})
+			flow, found := graph.flows[flowId]
+			if !found {
+				instance.ActivityState = Failed
+				return newEngineErrorf("missing sequence flow id=%s from element id=%s", flowId, (*sourceActivity.Element()).GetId())
+			}
+			nextFlows := []*graphFlow{flow}
 			if BPMN20.ExclusiveGateway == (*sourceActivity.Element()).GetType() {
-				nextFlows, err = exclusivelyFilterByConditionExpression(nextFlows, instance.VariableHolder.Variables())
+				nextFlows, err = exclusivelyFilterByConditionExpression(nextFlows, graph.defaultFlows[cmd.(flowTransitionCommand).sourceId], graph.expressions, instance.VariableHolder.Variables())
 				if err != nil {
 					instance.ActivityState = Failed
 					return err
 				}
 			}
 			for _, flow := range nextFlows {
-				state.exportSequenceFlowEvent(*instance.ProcessInfo, *instance, flow)
-				baseElements := BPMN20.FindBaseElementsById(process, flow.TargetRef)
-				targetBaseElement := baseElements[0]
+				state.exportSequenceFlowEvent(*instance.ProcessInfo, *instance, flow.TSequenceFlow)
+				targetBaseElement := graph.element(flow.TargetRef)
+				if targetBaseElement == nil {
+					instance.ActivityState = Failed
+					return newEngineErrorf("missing target element id=%s of sequence flow id=%s", flow.TargetRef, flow.Id)
+				}
 				aCmd := activityCommand{
 					sourceId:       flowId,
 					originActivity: sourceActivity,
@@ -205,28 +251,65 @@ func (state *BpmnEngineState) run(process BPMN20.ProcessElement, instance *proce
 			break
 		case checkExclusiveGatewayDoneType:
 			activity := cmd.(checkExclusiveGatewayDoneCommand).gatewayActivity
-			state.checkExclusiveGatewayDone(activity)
+			state.checkExclusiveGatewayDone(instance, activity)
 		default:
 			panic("[invariant check] command type check not fully implemented")
 		}
+		if len(commandQueue) == 0 && err == nil {
+			// all tokens of the run wait now, so conditional events see the latest variables,
+			// and converging inclusive gateways know, which tokens can still arrive
+			commandQueue = state.triggerConditionalEvents(currentActivity, instance)
+			if len(commandQueue) == 0 {
+				commandQueue = state.joinInclusiveGateways(process, instance)
+			}
+			state.resetComplexGateways(process, instance)
+		}
 	}
+	return err
+}
 
+// finishRun exports the end of the process instance, when it's completed or failed,
+// and applies the retention policy, when it has just completed
+func (state *BpmnEngineState) finishRun(instance *processInstanceInfo) {
+	instance.interruptedScopes = nil
 	if instance.ActivityState == Completed || instance.ActivityState == Failed {
 		// TODO need to send failed State
 		state.exportEndProcessEvent(*instance.ProcessInfo, *instance)
 	}
-
-	return err
+	if instance.ActivityState == Completed && instance.CompletedAt.IsZero() {
+		instance.CompletedAt = time.Now()
+		state.history.recordInstanceCompleted(instance)
+		state.addCompletedInstance(instance)
+		state.applyInstanceRetention()
+	}
 }
 
 func (state *BpmnEngineState) handleElement(process BPMN20.ProcessElement, act activity, instance *processInstanceInfo, element *BPMN20.BaseElement, originActivity activity) []command {
 	state.exportElementEvent(process, *instance, *element, exporter.ElementActivated) // FIXME: don't create event on continuation ?!?!
+	startedAt := state.history.currentTime()
 	createFlowTransitions := true
 	var activity activity
 	var nextCommands []command
 	var err error
+	if _, isHandler := instance.ProcessInfo.graph.compensatedActivities[(*element).GetId()]; isHandler {
+		// compensation handlers have no flows; they're continued here, when their job wasn't completed right away
+		activity, nextCommands = state.continueCompensationHandler(instance, element, act)
+		state.recordElementHistory(instance, element, act, activity, false, startedAt, nil)
+		return nextCommands
+	}
+	loop, runLoop, err := state.enterLoop(instance, element)
+	if err != nil || !runLoop {
+		// the loop's test before the first iteration failed, so the activity completes without any iteration
+		return state.skipLoop(instance, element, act, loop, startedAt, err)
+	}
 	switch (*element).GetType() {
 	case BPMN20.StartEvent:
+		if esp := instance.ProcessInfo.graph.eventSubProcessOf((*element).GetId()); esp != nil && (*act.Element()).GetId() != esp.Id {
+			// the start event of an armed event sub-process is checked, whether it's triggered
+			activity, nextCommands = state.handleEventSubProcessStartEvent(act, instance, esp, element)
+			createFlowTransitions = false
+			break
+		}
 		createFlowTransitions = true
 		activity = &elementActivity{
 			key:     state.generateKey(),
@@ -234,17 +317,122 @@ func (state *BpmnEngineState) handleElement(process BPMN20.ProcessElement, act a
 			element: element,
 		}
 	case BPMN20.EndEvent:
-		createFlowTransitions = state.handleEndEvent(process, act, instance)
+		if endEvent := (*element).(BPMN20.TEndEvent); endEvent.MessageEventDefinition.Id != "" {
+			if err = state.sendMessages(instance, *element); err != nil {
+				activity = act
+				nextCommands = append(nextCommands, errorCommand{
+					err:         err,
+					elementId:   (*element).GetId(),
+					elementName: (*element).GetName(),
+				})
+				createFlowTransitions = false
+				break
+			}
+		}
+		if endEvent := (*element).(BPMN20.TEndEvent); endEvent.ErrorEventDefinition.Id != "" {
+			activity = &elementActivity{
+				key:     state.generateKey(),
+				state:   Completed,
+				element: element,
+			}
+			nextCommands = state.throwError(act, instance, endEvent)
+			createFlowTransitions = false
+			break
+		}
+		if endEvent := (*element).(BPMN20.TEndEvent); endEvent.EscalationEventDefinition.Id != "" {
+			nextCommands = state.throwEscalation(act, instance, element, endEvent.EscalationEventDefinition.EscalationRef)
+			if instance.isInterrupted(endEvent.Id, 0) {
+				activity = act
+				createFlowTransitions = false
+				break
+			}
+		}
+		if endEvent := (*element).(BPMN20.TEndEvent); endEvent.CancelEventDefinition.Id != "" {
+			activity = act
+			nextCommands = state.cancelTransaction(act, instance, element)
+			createFlowTransitions = false
+			break
+		}
+		if endEvent := (*element).(BPMN20.TEndEvent); endEvent.CompensateEventDefinition.Id != "" {
+			if !state.throwCompensation(instance, element, endEvent.CompensateEventDefinition.ActivityRef) {
+				activity = act
+				createFlowTransitions = false
+				break
+			}
+		}
+		createFlowTransitions = state.handleEndEvent(process, act, instance, element)
 		activity = act
 		state.exportElementEvent(process, *instance, *element, exporter.ElementCompleted) // special case here, to end the instance
-	case BPMN20.ServiceTask:
+	case BPMN20.ServiceTask, BPMN20.SendTask:
 		taskElement := (*element).(BPMN20.TaskElement)
-		_, activity = state.handleServiceTask(process, instance, &taskElement)
+		_, activity = state.handleServiceTask(process, instance, &taskElement, loopVariables(act, instance, loop))
 		createFlowTransitions = activity.State() == Completed
+		if createFlowTransitions && (*element).GetType() == BPMN20.SendTask {
+			// the job sends the message to black box pools, the engine to the other processes of the collaboration
+			if err = state.sendMessages(instance, *element); err != nil {
+				nextCommands = append(nextCommands, errorCommand{
+					err:         err,
+					elementId:   (*element).GetId(),
+					elementName: (*element).GetName(),
+				})
+			}
+		}
 	case BPMN20.UserTask:
 		taskElement := (*element).(BPMN20.TaskElement)
-		activity = state.handleUserTask(process, instance, &taskElement)
+		activity = state.handleUserTask(process, instance, &taskElement, loopVariables(act, instance, loop))
+		createFlowTransitions = activity.State() == Completed
+	case BPMN20.BusinessRuleTask:
+		businessRuleTask := (*element).(BPMN20.TBusinessRuleTask)
+		if businessRuleTask.CalledDecision.DecisionId == "" {
+			taskElement := (*element).(BPMN20.TaskElement)
+			_, activity = state.handleServiceTask(process, instance, &taskElement, loopVariables(act, instance, loop))
+		} else {
+			activity, err = state.handleBusinessRuleTask(instance, element, businessRuleTask)
+			if err != nil {
+				nextCommands = append(nextCommands, errorCommand{
+					err:         err,
+					elementId:   (*element).GetId(),
+					elementName: (*element).GetName(),
+				})
+			}
+		}
 		createFlowTransitions = activity.State() == Completed
+	case BPMN20.ScriptTask:
+		scriptTask := (*element).(BPMN20.TScriptTask)
+		if scriptTask.Script.Expression == "" {
+			taskElement := (*element).(BPMN20.TaskElement)
+			_, activity = state.handleServiceTask(process, instance, &taskElement, loopVariables(act, instance, loop))
+		} else {
+			activity, err = state.handleScriptTask(instance, element, scriptTask)
+			if err != nil {
+				nextCommands = append(nextCommands, errorCommand{
+					err:         err,
+					elementId:   (*element).GetId(),
+					elementName: (*element).GetName(),
+				})
+			}
+		}
+		createFlowTransitions = activity.State() == Completed
+	case BPMN20.ReceiveTask:
+		receiveTask := (*element).(BPMN20.TReceiveTask)
+		createFlowTransitions, activity, err = state.handleReceiveTask(instance, receiveTask, originActivity)
+		if err != nil {
+			nextCommands = append(nextCommands, errorCommand{
+				err:         err,
+				elementId:   (*element).GetId(),
+				elementName: (*element).GetName(),
+			})
+		} else {
+			nextCommands = append(nextCommands, createCheckExclusiveGatewayDoneCommand(originActivity)...)
+		}
+	case BPMN20.ManualTask, BPMN20.Task, BPMN20.BoundaryEvent:
+		// there's nothing to do for the engine, so these elements just pass through
+		activity = &elementActivity{
+			key:     state.generateKey(),
+			state:   Completed,
+			element: element,
+		}
+		createFlowTransitions = true
 	case BPMN20.IntermediateCatchEvent:
 		ice := (*element).(BPMN20.TIntermediateCatchEvent)
 		createFlowTransitions, activity, err = state.handleIntermediateCatchEvent(process, instance, ice, originActivity)
@@ -258,16 +446,61 @@ func (state *BpmnEngineState) handleElement(process BPMN20.ProcessElement, act a
 			nextCommands = append(nextCommands, createCheckExclusiveGatewayDoneCommand(originActivity)...)
 		}
 	case BPMN20.IntermediateThrowEvent:
+		if ite := (*element).(BPMN20.TIntermediateThrowEvent); ite.MessageEventDefinition.Id != "" {
+			activity = &elementActivity{
+				key:     state.generateKey(),
+				state:   Completed,
+				element: element,
+			}
+			if err = state.sendMessages(instance, *element); err != nil {
+				nextCommands = append(nextCommands, errorCommand{
+					err:         err,
+					elementId:   (*element).GetId(),
+					elementName: (*element).GetName(),
+				})
+			}
+			createFlowTransitions = true
+			break
+		}
+		if ite := (*element).(BPMN20.TIntermediateThrowEvent); ite.EscalationEventDefinition.Id != "" {
+			activity = &elementActivity{
+				key:     state.generateKey(),
+				state:   Completed,
+				element: element,
+			}
+			nextCommands = state.throwEscalation(act, instance, element, ite.EscalationEventDefinition.EscalationRef)
+			createFlowTransitions = true
+			break
+		}
+		if ite := (*element).(BPMN20.TIntermediateThrowEvent); ite.CompensateEventDefinition.Id != "" {
+			createFlowTransitions = state.throwCompensation(instance, element, ite.CompensateEventDefinition.ActivityRef)
+			activity = &elementActivity{
+				key:     state.generateKey(),
+				state:   Active,
+				element: element,
+			}
+			if createFlowTransitions {
+				activity.SetState(Completed)
+			}
+			break
+		}
 		activity = &elementActivity{
 			key:     state.generateKey(),
 			state:   Active, // FIXME: should be Completed?
 			element: element,
 		}
-		cmds := state.handleIntermediateThrowEvent(process, instance, (*element).(BPMN20.TIntermediateThrowEvent), activity)
+		cmds := state.handleIntermediateThrowEvent(instance, (*element).(BPMN20.TIntermediateThrowEvent), activity)
 		nextCommands = append(nextCommands, cmds...)
 		createFlowTransitions = false
 	case BPMN20.ParallelGateway:
-		createFlowTransitions, activity = state.handleParallelGateway(process, instance, (*element).(BPMN20.TParallelGateway), originActivity)
+		createFlowTransitions, activity, err = state.handleParallelGateway(process, instance, (*element).(BPMN20.TParallelGateway), originActivity)
+		if err != nil {
+			nextCommands = append(nextCommands, errorCommand{
+				err:         err,
+				elementId:   (*element).GetId(),
+				elementName: (*element).GetName(),
+			})
+		}
 	case BPMN20.ExclusiveGateway:
 		activity = &elementActivity{
 			key:     state.generateKey(),
@@ -284,15 +517,20 @@ func (state *BpmnEngineState) handleElement(process BPMN20.ProcessElement, act a
 		instance.appendActivity(activity)
 		createFlowTransitions = true
 	case BPMN20.InclusiveGateway:
-		activity = &elementActivity{
-			key:     state.generateKey(),
-			state:   Active,
-			element: element,
+		createFlowTransitions, activity = state.handleInclusiveGateway(instance, element, originActivity)
+	case BPMN20.ComplexGateway:
+		createFlowTransitions, activity, err = state.handleComplexGateway(instance, element, originActivity)
+		if err != nil {
+			nextCommands = append(nextCommands, errorCommand{
+				err:         err,
+				elementId:   (*element).GetId(),
+				elementName: (*element).GetName(),
+			})
 		}
-		createFlowTransitions = true
-	case BPMN20.SubProcess:
+	case BPMN20.SubProcess, BPMN20.Transaction:
 		subProcessElement := (*element).(BPMN20.TSubProcess)
-		activity, err = state.handleSubProcess(instance, &subProcessElement)
+		activity, err = state.handleSubProcess(act, instance, &subProcessElement)
+		nextCommands = append(nextCommands, activity.(*subProcessInfo).boundaryEventCommands...)
 		if err != nil {
 			nextCommands = append(nextCommands, errorCommand{
 				err:         err,
@@ -304,12 +542,61 @@ func (state *BpmnEngineState) handleElement(process BPMN20.ProcessElement, act a
 	default:
 		panic(fmt.Sprintf("[invariant check] unsupported element: id=%s, type=%s", (*element).GetId(), (*element).GetType()))
 	}
+	if loop != nil && createFlowTransitions && err == nil {
+		var loopCommands []command
+		if loopCommands, err = continueLoop(instance, loop, activity); err != nil {
+			nextCommands = append(nextCommands, errorCommand{
+				err:         err,
+				elementId:   (*element).GetId(),
+				elementName: (*element).GetName(),
+			})
+		}
+		nextCommands = append(nextCommands, loopCommands...)
+		createFlowTransitions = loop.State() == Completed
+	} else if loop != nil && activity != nil && activity.State() != Active {
+		// the iteration failed or was interrupted, so the activity doesn't loop anymore
+		loop.SetState(activity.State())
+	}
 	if createFlowTransitions && err == nil {
-		nextCommands = append(nextCommands, createNextCommands(process, instance, element, activity)...)
+		withdrawConditionalBoundaryEvents(instance, (*element).GetId())
+		instance.recordCompensableActivity(element, activity)
+		nextCommands = append(nextCommands, createNextCommands(instance, element, activity)...)
+	} else if err == nil && activity != nil && activity.State() == Active {
+		state.armConditionalBoundaryEvents(instance, (*element).GetId())
 	}
+	state.recordElementHistory(instance, element, act, activity, createFlowTransitions, startedAt, err)
 	return nextCommands
 }
 
+// recordElementHistory records the element instance, when the history is enabled;
+// an element instance ended, when the flow continues, or it was completed or failed
+func (state *BpmnEngineState) recordElementHistory(instance *processInstanceInfo, element *BPMN20.BaseElement, act activity, activity activity, continueFlow bool, startedAt time.Time, err error) {
+	if state.history == nil {
+		return
+	}
+	var key int64
+	activityState := Completed
+	if activity == nil || (*element).GetType() == BPMN20.EndEvent {
+		// there's no activity for this element, e.g. end events complete the process instance
+		key = state.generateKey()
+	} else {
+		key = activity.Key()
+		activityState = activity.State()
+	}
+	var errorMessage string
+	if j, ok := activity.(*job); ok {
+		errorMessage = j.failReason
+	}
+	if err != nil {
+		activityState = Failed
+		errorMessage = err.Error()
+	} else if continueFlow {
+		activityState = Completed
+	}
+	ended := continueFlow || activityState == Completed || activityState == Failed
+	state.history.recordElementInstance(instance, key, *element, activityState, ended, startedAt, errorMessage)
+}
+
 func createCheckExclusiveGatewayDoneCommand(originActivity activity) (cmds []command) {
 	if (*originActivity.Element()).GetType() == BPMN20.EventBasedGateway {
 		evtBasedGatewayActivity := originActivity.(*eventBasedGatewayActivity)
@@ -320,32 +607,32 @@ func createCheckExclusiveGatewayDoneCommand(originActivity activity) (cmds []com
 	return cmds
 }
 
-func createNextCommands(process BPMN20.ProcessElement, instance *processInstanceInfo, element *BPMN20.BaseElement, activity activity) (cmds []command) {
-	nextFlows := BPMN20.FindSequenceFlows(process, (*element).GetOutgoingAssociation())
+func createNextCommands(instance *processInstanceInfo, element *BPMN20.BaseElement, activity activity) (cmds []command) {
+	graph := instance.ProcessInfo.graph
+	nextFlows := graph.outgoing[(*element).GetId()]
+	defaultFlowId := graph.defaultFlows[(*element).GetId()]
 	var err error
 	switch (*element).GetType() {
 	case BPMN20.ExclusiveGateway:
-		nextFlows, err = exclusivelyFilterByConditionExpression(nextFlows, instance.VariableHolder.Variables())
-		if err != nil {
-			instance.ActivityState = Failed
-			cmds = append(cmds, errorCommand{
+		nextFlows, err = exclusivelyFilterByConditionExpression(nextFlows, defaultFlowId, graph.expressions, instance.VariableHolder.Variables())
+	case BPMN20.InclusiveGateway, BPMN20.ComplexGateway:
+		nextFlows, err = inclusivelyFilterByConditionExpression(nextFlows, defaultFlowId, graph.expressions, instance.VariableHolder.Variables())
+	case BPMN20.ParallelGateway, BPMN20.EventBasedGateway:
+		// conditions aren't evaluated
+	default:
+		// conditional sequence flows leave activities, without gateway
+		if hasConditionalFlows(nextFlows) {
+			nextFlows, err = inclusivelyFilterByConditionExpression(nextFlows, defaultFlowId, graph.expressions, instance.VariableHolder.Variables())
+		}
+	}
+	if err != nil {
+		instance.ActivityState = Failed
+		return []command{
+			errorCommand{
 				err:         err,
 				elementId:   (*element).GetId(),
 				elementName: (*element).GetName(),
-			})
-			return cmds
-		}
-	case BPMN20.InclusiveGateway:
-		nextFlows, err = inclusivelyFilterByConditionExpression(nextFlows, instance.VariableHolder.Variables())
-		if err != nil {
-			instance.ActivityState = Failed
-			return []command{
-				errorCommand{
-					elementId:   (*element).GetId(),
-					elementName: (*element).GetName(),
-					err:         err,
-				},
-			}
+			},
 		}
 	}
 	for _, flow := range nextFlows {
@@ -364,6 +651,9 @@ func (state *BpmnEngineState) handleIntermediateCatchEvent(process BPMN20.Proces
 		continueFlow, activity, err = state.handleIntermediateMessageCatchEvent(process, instance, ice, originActivity)
 	} else if ice.TimerEventDefinition.Id != "" {
 		continueFlow, activity, err = state.handleIntermediateTimerCatchEvent(instance, ice, originActivity)
+	} else if ice.ConditionalEventDefinition.Id != "" {
+		var be BPMN20.BaseElement = ice
+		continueFlow, activity, err = state.handleIntermediateConditionalCatchEvent(instance, &be)
 	} else if ice.LinkEventDefinition.Id != "" {
 		var be BPMN20.BaseElement = ice
 		activity = &elementActivity{
@@ -374,7 +664,7 @@ func (state *BpmnEngineState) handleIntermediateCatchEvent(process BPMN20.Proces
 		throwLinkName := (*originActivity.Element()).(BPMN20.TIntermediateThrowEvent).LinkEventDefinition.Name
 		catchLinkName := ice.LinkEventDefinition.Name
 		elementVarHolder := NewVarHolder(&instance.VariableHolder, nil)
-		if err := propagateProcessInstanceVariables(&elementVarHolder, ice.Output); err != nil {
+		if err := propagateProcessInstanceVariables(instance.ProcessInfo.graph.expressions, &elementVarHolder, ice.Output); err != nil {
 			msg := fmt.Sprintf("Can't evaluate expression in element id=%s name=%s", ice.Id, ice.Name)
 			err = &ExpressionEvaluationError{Msg: msg, Err: err}
 		} else {
@@ -384,30 +674,40 @@ func (state *BpmnEngineState) handleIntermediateCatchEvent(process BPMN20.Proces
 	return continueFlow, activity, err
 }
 
-func (state *BpmnEngineState) handleEndEvent(process BPMN20.ProcessElement, act activity, instance *processInstanceInfo) bool {
+func (state *BpmnEngineState) handleEndEvent(process BPMN20.ProcessElement, act activity, instance *processInstanceInfo, element *BPMN20.BaseElement) bool {
+	graph := instance.ProcessInfo.graph
+	if esp := graph.eventSubProcessOf((*element).GetId()); esp != nil && (*act.Element()).GetId() != esp.Id {
+		// the event sub-process was continued by the run of its parent scope,
+		// which only ends, when the event sub-process was interrupting it
+		if !graph.isInterrupting(esp) || graph.scopes[esp.Id].GetId() != (*act.Element()).GetId() {
+			return false
+		}
+	}
 	activeMessageSubscriptions := false
-	for _, ms := range state.messageSubscriptions {
-		if ms.ProcessInstanceKey == instance.InstanceKey {
-			activeMessageSubscriptions = activeMessageSubscriptions || ms.State() == Active || ms.State() == Ready
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if (*ms.Element()).GetType() == BPMN20.StartEvent {
+			// armed event sub-processes don't keep their scope active
+			continue
 		}
+		activeMessageSubscriptions = activeMessageSubscriptions || ms.State() == Active || ms.State() == Ready
 		if activeMessageSubscriptions {
 			break
 		}
 	}
-	if !activeMessageSubscriptions {
+	if !activeMessageSubscriptions && !instance.isWaitingAtConditionalCatchEvent() {
 		act.SetState(Completed)
 	}
-	switch process.(type) {
-	case *BPMN20.TProcess:
-		return false
-	case *BPMN20.TSubProcess:
+	_, continueFlow := process.(*BPMN20.TSubProcess)
+	if continueFlow {
 		act.SetState(Completed)
-		return true
 	}
-	return false
+	if act.State() == Completed {
+		state.disarmEventSubProcesses(instance, (*act.Element()).GetId())
+	}
+	return continueFlow
 }
 
-func (state *BpmnEngineState) handleParallelGateway(process BPMN20.ProcessElement, instance *processInstanceInfo, element BPMN20.TParallelGateway, originActivity activity) (continueFlow bool, resultActivity activity) {
+func (state *BpmnEngineState) handleParallelGateway(process BPMN20.ProcessElement, instance *processInstanceInfo, element BPMN20.TParallelGateway, originActivity activity) (continueFlow bool, resultActivity activity, err error) {
 	resultActivity = instance.findActiveActivityByElementId(element.Id)
 	if resultActivity == nil {
 		var be BPMN20.BaseElement = element
@@ -419,18 +719,21 @@ func (state *BpmnEngineState) handleParallelGateway(process BPMN20.ProcessElemen
 		}
 		instance.appendActivity(resultActivity)
 	}
-	sourceFlow := BPMN20.FindFirstSequenceFlow(process, (*originActivity.Element()).GetId(), element.GetId())
+	sourceFlow := instance.ProcessInfo.graph.incomingFlowFrom((*originActivity.Element()).GetId(), element.GetId())
+	if sourceFlow == nil {
+		return false, resultActivity, newEngineErrorf("no sequence flow from element id=%s to parallel gateway id=%s", (*originActivity.Element()).GetId(), element.GetId())
+	}
 	resultActivity.(*gatewayActivity).SetInboundFlowCompleted(sourceFlow.Id)
 	continueFlow = resultActivity.(*gatewayActivity).parallel && resultActivity.(*gatewayActivity).AreInboundFlowsCompleted()
 	if continueFlow {
 		resultActivity.(*gatewayActivity).SetState(Completed)
 	}
-	return continueFlow, resultActivity
+	return continueFlow, resultActivity, nil
 }
 
-func (state *BpmnEngineState) handleSubProcess(instance *processInstanceInfo, subProcessElement *BPMN20.TSubProcess) (subProcessActivity activity, err error) {
+func (state *BpmnEngineState) handleSubProcess(act activity, instance *processInstanceInfo, subProcessElement *BPMN20.TSubProcess) (activity, error) {
 	var be BPMN20.BaseElement = subProcessElement
-	subProcessActivity = &subProcessInfo{
+	subProcessActivity := &subProcessInfo{
 		ElementId:       subProcessElement.GetId(),
 		ProcessInstance: instance,
 		ProcessId:       state.generateKey(),
@@ -438,14 +741,25 @@ func (state *BpmnEngineState) handleSubProcess(instance *processInstanceInfo, su
 		processState:    Ready,
 		variableHolder:  NewVarHolder(&instance.VariableHolder, nil),
 		baseElement:     &be,
+		parentActivity:  act,
+	}
+	// the conditional boundary events are triggered by the run of the sub-process
+	state.armConditionalBoundaryEvents(instance, subProcessElement.Id)
+	err := state.run(subProcessElement, instance, subProcessActivity)
+	if subProcessActivity.State() == Active {
+		if instance.waitingSubProcesses == nil {
+			instance.waitingSubProcesses = map[string]*subProcessInfo{}
+		}
+		instance.waitingSubProcesses[subProcessElement.Id] = subProcessActivity
+	} else {
+		delete(instance.waitingSubProcesses, subProcessElement.Id)
 	}
-	err = state.run(subProcessElement, instance, subProcessActivity)
 	return subProcessActivity, err
 }
 
 func (state *BpmnEngineState) findActiveJobsForContinuation(instance *processInstanceInfo) (ret []*job) {
-	for _, job := range state.jobs {
-		if job.ProcessInstanceKey == instance.InstanceKey && job.JobState == Active {
+	for _, job := range state.jobs.ofInstance(instance.InstanceKey) {
+		if job.JobState == Active {
 			ret = append(ret, job)
 		}
 	}
@@ -456,8 +770,8 @@ func (state *BpmnEngineState) findActiveJobsForContinuation(instance *processIns
 // if ids are provided, the result gets filtered;
 // if no ids are provided, all active subscriptions are returned
 func (state *BpmnEngineState) findActiveSubscriptions(instance *processInstanceInfo) (result []*MessageSubscription) {
-	for _, ms := range state.messageSubscriptions {
-		if ms.ProcessInstanceKey == instance.InstanceKey && ms.MessageState == Active {
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if ms.MessageState == Active {
 			result = append(result, ms)
 		}
 	}
@@ -466,8 +780,8 @@ func (state *BpmnEngineState) findActiveSubscriptions(instance *processInstanceI
 
 // findCreatedTimers the list of all scheduled/creates timers in the engine, not yet completed
 func (state *BpmnEngineState) findCreatedTimers(instance *processInstanceInfo) (result []*Timer) {
-	for _, t := range state.timers {
-		if instance.InstanceKey == t.ProcessInstanceKey && t.TimerState == TimerCreated {
+	for _, t := range state.timers.ofInstance(instance.InstanceKey) {
+		if t.TimerState == TimerCreated {
 			result = append(result, t)
 		}
 	}
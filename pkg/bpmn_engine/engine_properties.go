@@ -11,13 +11,21 @@ import (
 type BpmnEngineState struct {
 	name                 string
 	processes            []*ProcessInfo
+	decisionResources    []*decisionResource
 	processInstances     []*processInstanceInfo
-	messageSubscriptions []*MessageSubscription
-	jobs                 []*job
-	timers               []*Timer
+	processInstanceIndex map[int64]*processInstanceInfo
+	removedInstances     int                    // removed instances, which are still in processInstances, see instances
+	completedInstances   []*processInstanceInfo // in the order of their completion, see addCompletedInstance
+	messageSubscriptions instanceRecords[*MessageSubscription]
+	jobs                 instanceRecords[*job]
+	timers               instanceRecords[*Timer]
 	taskHandlers         []*taskHandler
 	exporters            []exporter.EventExporter
 	snowflake            *snowflake.Node
+	history              *History
+	instanceRetention    InstanceRetention
+	expressionScope      map[string]interface{}         // custom functions and context values for all FEEL expressions
+	expressionEvaluators map[string]ExpressionEvaluator // by expression language
 }
 
 type ProcessInfo struct {
@@ -25,27 +33,53 @@ type ProcessInfo struct {
 	Version          int32               // A version of the process, default=1, incremented, when another process with the same ID is loaded
 	ProcessKey       int64               // The engines key for this given process with version
 	definitions      BPMN20.TDefinitions // parsed file content
+	graph            *processGraph       // the indexed definitions, used for execution
 	bpmnData         string              // the raw source data, compressed and encoded via ascii85
 	bpmnResourceName string              // some name for the resource
 	bpmnChecksum     [16]byte            // internal checksum to identify different versions
 }
 
 // ProcessInstances returns the list of process instances
-// Hint: completed instances are prone to be removed from the list,
+// Hint: completed instances are prone to be removed from the list (see SetInstanceRetention and PurgeCompleted),
 // which means typically you only see currently active process instances
 func (state *BpmnEngineState) ProcessInstances() []*processInstanceInfo {
+	return state.instances()
+}
+
+// instances returns the process instances, after the removed ones are compacted
+func (state *BpmnEngineState) instances() []*processInstanceInfo {
+	if state.removedInstances > 0 {
+		var kept []*processInstanceInfo
+		for _, instance := range state.processInstances {
+			if state.processInstanceIndex[instance.InstanceKey] == instance {
+				kept = append(kept, instance)
+			}
+		}
+		state.processInstances = kept
+		state.removedInstances = 0
+	}
 	return state.processInstances
 }
 
 // FindProcessInstance searches for a given processInstanceKey
 // and returns the corresponding processInstanceInfo, or otherwise nil
 func (state *BpmnEngineState) FindProcessInstance(processInstanceKey int64) *processInstanceInfo {
-	for _, instance := range state.processInstances {
-		if instance.InstanceKey == processInstanceKey {
-			return instance
-		}
+	return state.processInstanceIndex[processInstanceKey]
+}
+
+func (state *BpmnEngineState) addProcessInstance(instance *processInstanceInfo) {
+	if state.processInstanceIndex == nil {
+		state.processInstanceIndex = map[int64]*processInstanceInfo{}
+	}
+	state.processInstances = append(state.processInstances, instance)
+	state.processInstanceIndex[instance.InstanceKey] = instance
+	if sender := state.processInstanceIndex[instance.MessageSenderKey]; sender != nil {
+		// restored instances only know their sender
+		sender.messageReceiverKeys = append(sender.messageReceiverKeys, instance.InstanceKey)
+	}
+	if instance.ActivityState == Completed {
+		state.addCompletedInstance(instance)
 	}
-	return nil
 }
 
 // Name returns the name of the engine, only useful in case you control multiple ones
@@ -67,18 +101,18 @@ func (state *BpmnEngineState) FindProcessesById(id string) (infos []*ProcessInfo
 	return infos
 }
 
-func (state *BpmnEngineState) checkExclusiveGatewayDone(activity eventBasedGatewayActivity) {
+func (state *BpmnEngineState) checkExclusiveGatewayDone(instance *processInstanceInfo, activity eventBasedGatewayActivity) {
 	if !activity.OutboundCompleted() {
 		return
 	}
 	// cancel other activities started by this one
-	for _, ms := range state.messageSubscriptions {
-		if ms.originActivity.Key() == activity.Key() && ms.State() == Active {
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if ms.originActivity != nil && ms.originActivity.Key() == activity.Key() && ms.State() == Active {
 			ms.MessageState = Withdrawn
 		}
 	}
-	for _, t := range state.timers {
-		if t.originActivity.Key() == activity.Key() && t.State() == Active {
+	for _, t := range state.timers.ofInstance(instance.InstanceKey) {
+		if t.originActivity != nil && t.originActivity.Key() == activity.Key() && t.State() == Active {
 			t.TimerState = TimerCancelled
 		}
 	}
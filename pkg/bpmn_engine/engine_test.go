@@ -1,6 +1,7 @@
 package bpmn_engine
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
 )
 
 type CallPath struct {
@@ -245,3 +247,41 @@ func Test_CreateInstanceById_return_error_when_no_ID_found(t *testing.T) {
 	then.AssertThat(t, err, is.Not(is.Nil()))
 	then.AssertThat(t, err.Error(), has.Prefix("no process with id=Simple_Task_Process was found (prior loaded into the engine)"))
 }
+
+func Test_unknown_sequence_flow_fails_the_instance(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// given
+	delete(process.graph.flows, "Flow_0xt1d7q")
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	var engineError *BpmnEngineError
+	then.AssertThat(t, errors.As(err, &engineError), is.True())
+	then.AssertThat(t, err.Error(), is.ValueContaining("missing sequence flow id=Flow_0xt1d7q from element id=StartEvent_1"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+}
+
+func Test_parallel_gateway_without_sequence_flow_from_origin_returns_error(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/parallel-gateway-flow.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	instance, err := bpmnEngine.CreateInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	gateway := (*process.graph.element("id-parallel-gateway-1")).(BPMN20.TParallelGateway)
+	origin := &elementActivity{key: 1, state: Completed, element: process.graph.element("id-b-1")}
+
+	// when
+	_, _, err = bpmnEngine.handleParallelGateway(process.definitions.Process, instance, gateway, origin)
+
+	// then
+	var engineError *BpmnEngineError
+	then.AssertThat(t, errors.As(err, &engineError), is.True())
+	then.AssertThat(t, err.Error(), is.EqualTo("no sequence flow from element id=id-b-1 to parallel gateway id=id-parallel-gateway-1"))
+}
@@ -1,6 +1,9 @@
 package bpmn_engine
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type BpmnEngineError struct {
 	Msg string
@@ -20,20 +23,58 @@ func newEngineErrorf(format string, a ...interface{}) error {
 type BpmnEngineUnmarshallingError struct {
 	Msg string
 	Err error
+	// Offset within the serialized data, where the corrupt data was detected.
+	// For malformed JSON this is the number of bytes read before the error (like json.SyntaxError),
+	// otherwise it's the start of the corrupt record (e.g. a process instance with an unknown process key).
+	Offset int64
 }
 
 func (e *BpmnEngineUnmarshallingError) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
 	if len(e.Msg) > 0 {
 		return e.Msg + ": " + e.Err.Error()
 	}
 	return e.Err.Error()
 }
 
+func (e *BpmnEngineUnmarshallingError) Unwrap() error {
+	return e.Err
+}
+
 type ExpressionEvaluationError struct {
 	Msg string
 	Err error
 }
 
 func (e *ExpressionEvaluationError) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
 	return e.Msg + "\nerror: " + e.Err.Error()
 }
+
+// ExpressionSyntaxError is returned, when loading a process with an expression, which can't be parsed,
+// or whose expression language has no registered evaluator (see WithExpressionEvaluator)
+type ExpressionSyntaxError struct {
+	ElementId  string
+	Attribute  string // e.g. "conditionExpression", "zeebe:input" or "timeDuration"
+	Language   string // e.g. ExpressionLanguageFEEL
+	Expression string
+	Err        error
+}
+
+func (e *ExpressionSyntaxError) Error() string {
+	// the FEEL parser appends its call stack in further lines
+	reason, _, _ := strings.Cut(e.Err.Error(), "\n")
+	language := e.Language
+	if language == ExpressionLanguageFEEL {
+		language = "FEEL"
+	}
+	return fmt.Sprintf("invalid %s expression %q in element id=%s attribute=%s: %s", language, e.Expression, e.ElementId, e.Attribute, reason)
+}
+
+func (e *ExpressionSyntaxError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,67 @@
+package bpmn_engine
+
+import (
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// throwEscalation resolves the escalation up the chain of the running (sub) processes, starting at the thrower's scope:
+// per scope, an event sub-process with a matching start event is triggered, or else a matching boundary event,
+// which is attached to the sub-process. When nothing catches the escalation, an exporter.EscalationNotCaught event
+// is exported, and the flow continues.
+func (state *BpmnEngineState) throwEscalation(act activity, instance *processInstanceInfo, thrower *BPMN20.BaseElement, escalationRef string) []command {
+	graph := instance.ProcessInfo.graph
+	escalationCode := graph.escalationCodes[escalationRef]
+	matches := func(escalationDefinition BPMN20.TEscalationEventDefinition) (matched bool, exact bool) {
+		if escalationDefinition.Id == "" {
+			return false, false
+		}
+		if escalationDefinition.EscalationRef == "" {
+			// catches all escalations
+			return true, false
+		}
+		return graph.escalationCodes[escalationDefinition.EscalationRef] == escalationCode, true
+	}
+	matchesStartEvent := func(catchEvent BPMN20.BaseElement) (bool, bool) {
+		return matches(catchEvent.(BPMN20.TStartEvent).EscalationEventDefinition)
+	}
+	matchesBoundaryEvent := func(catchEvent BPMN20.BaseElement) (bool, bool) {
+		return matches(catchEvent.(BPMN20.TBoundaryEvent).EscalationEventDefinition)
+	}
+	for a := act; a != nil; a = parentActivityOf(a) {
+		scopeId := (*a.Element()).GetId()
+		if esp, startEvent := graph.catchingEventSubProcessIn(scopeId, (*thrower).GetId(), matchesStartEvent); esp != nil {
+			return state.triggerEventSubProcess(a, instance, esp, (*startEvent).(BPMN20.TStartEvent).Interrupting())
+		}
+		if subProcess, ok := a.(*subProcessInfo); ok {
+			if boundaryEvent := graph.catchingBoundaryEvent(scopeId, matchesBoundaryEvent); boundaryEvent != nil {
+				state.triggerBoundaryEvent(instance, subProcess, boundaryEvent)
+				return nil
+			}
+		}
+	}
+	state.exportElementEvent(graph.scopes[(*thrower).GetId()], *instance, *thrower, exporter.EscalationNotCaught)
+	return nil
+}
+
+// triggerBoundaryEvent lets the run of the sub-process's parent continue at the boundary event,
+// after the sub-process returns; an interrupting boundary event terminates the sub-process first
+func (state *BpmnEngineState) triggerBoundaryEvent(instance *processInstanceInfo, subProcess *subProcessInfo, boundaryEvent *BPMN20.BaseElement) {
+	if (*boundaryEvent).(BPMN20.TBoundaryEvent).Interrupting() {
+		state.interruptScope(instance, subProcess.ElementId, "")
+		subProcess.SetState(Terminated)
+	}
+	subProcess.boundaryEventCommands = append(subProcess.boundaryEventCommands, activityCommand{
+		sourceId:       subProcess.ElementId,
+		element:        boundaryEvent,
+		originActivity: subProcess,
+	})
+}
+
+// parentActivityOf returns the activity of the (sub) process, which runs the given sub-process, or nil for the process instance
+func parentActivityOf(a activity) activity {
+	if subProcess, ok := a.(*subProcessInfo); ok {
+		return subProcess.parentActivity
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package bpmn_engine
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+)
+
+func Test_escalation_boundary_events_on_sub_process(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		callPath  string
+	}{
+		{"not escalated", map[string]interface{}{"inStock": true, "amount": 500}, "check-order,prepare,ship"},
+		{"non-interrupting", map[string]interface{}{"inStock": true, "amount": 5000}, "check-order,prepare,ship,notify-manager"},
+		{"interrupting", map[string]interface{}{"inStock": false, "amount": 500}, "check-order,reorder"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/escalation-boundary-event.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"check-order", "prepare", "ship", "notify-manager", "reorder"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, test.variables)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_escalation_is_resolved_up_the_scope_chain_to_an_event_sub_process(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	recorder := &elementRecordingExporter{}
+	bpmnEngine.AddEventExporter(recorder)
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/escalation-event-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("inform-manager").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("review-document").Handler(cp.TaskHandler)
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("inform-manager,review-document"))
+	then.AssertThat(t, recorder.elements, is.ValueContaining(
+		"SUB_PROCESS:escalation-handling:"+string(exporter.ElementCompleted),
+		"INTERMEDIATE_THROW_EVENT:take-note:"+string(exporter.EscalationNotCaught),
+	))
+	then.AssertThat(t, recorder.elements, is.Not(is.ValueContaining(
+		"INTERMEDIATE_THROW_EVENT:escalate-review:"+string(exporter.EscalationNotCaught),
+	)))
+}
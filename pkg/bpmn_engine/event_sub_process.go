@@ -0,0 +1,371 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// scopeInterruption marks a scope, which was interrupted by an event sub-process;
+// the elements of the scope, except the ones of the event sub-process, must not continue
+type scopeInterruption struct {
+	scopeId           string
+	eventSubProcessId string
+}
+
+// BroadcastSignal triggers the event sub-processes with a signal start event of the given name,
+// in all active process instances; the variables are set in each triggered process instance.
+// Might return BpmnEngineError or ExpressionEvaluationError of the first failing process instance.
+func (state *BpmnEngineState) BroadcastSignal(signalName string, variables map[string]interface{}) error {
+	var firstErr error
+	instances := make([]*processInstanceInfo, len(state.instances()))
+	copy(instances, state.instances())
+	for _, instance := range instances {
+		if instance.ActivityState != Active {
+			continue
+		}
+		if err := state.triggerSignalEventSubProcesses(instance, instance.ProcessInfo.definitions.Process, instance, signalName, variables); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// triggerSignalEventSubProcesses triggers the matching event sub-processes of the scope and its waiting nested
+// sub-processes; scopeActivity is the activity of the scope, which an interrupting event sub-process completes
+func (state *BpmnEngineState) triggerSignalEventSubProcesses(instance *processInstanceInfo, scope BPMN20.ProcessElement, scopeActivity activity, signalName string, variables map[string]interface{}) error {
+	graph := instance.ProcessInfo.graph
+	for _, subProcess := range scope.GetSubProcess() {
+		if subProcess.TriggeredByEvent && state.isScopeActive(instance, scope.GetId()) {
+			for _, startEvent := range subProcess.StartEvents {
+				if startEvent.SignalEventDefinition.Id == "" || graph.signalNames[startEvent.SignalEventDefinition.SignalRef] != signalName {
+					continue
+				}
+				for k, v := range variables {
+					instance.SetVariable(k, v)
+				}
+				cmds := state.triggerEventSubProcess(scopeActivity, instance, graph.element(subProcess.Id), startEvent.Interrupting())
+				if len(cmds) == 0 {
+					cmds = state.continueCompletedSubProcesses(instance, scopeActivity)
+				}
+				for _, cmd := range cmds {
+					if errCmd, ok := cmd.(errorCommand); ok {
+						instance.ActivityState = Failed
+						state.finishRun(instance)
+						return errCmd.err
+					}
+				}
+				state.finishRun(instance)
+			}
+		}
+		subProcessActivity := instance.waitingSubProcesses[subProcess.Id]
+		if subProcessActivity == nil || subProcessActivity.State() != Active || !state.isScopeActive(instance, subProcess.Id) {
+			continue
+		}
+		if err := state.triggerSignalEventSubProcesses(instance, &subProcess, subProcessActivity, signalName, variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// continueCompletedSubProcesses continues the flow after the sub-process, when it was completed by an interrupting
+// event sub-process outside its own run, and so on up the parent scopes, which are completed by that flow
+func (state *BpmnEngineState) continueCompletedSubProcesses(instance *processInstanceInfo, act activity) []command {
+	graph := instance.ProcessInfo.graph
+	for subProcess, ok := act.(*subProcessInfo); ok && subProcess.State() == Completed; subProcess, ok = subProcess.parentActivity.(*subProcessInfo) {
+		parentScope := graph.scopes[subProcess.ElementId]
+		delete(instance.waitingSubProcesses, subProcess.ElementId)
+		state.exportElementEvent(parentScope, *instance, *subProcess.Element(), exporter.ElementCompleted)
+		if err := state.runCommands(parentScope, instance, subProcess.parentActivity, createNextCommands(instance, subProcess.Element(), subProcess)); err != nil {
+			return []command{errorCommand{
+				err:         err,
+				elementId:   subProcess.ElementId,
+				elementName: (*subProcess.Element()).GetName(),
+			}}
+		}
+	}
+	return nil
+}
+
+// isScopeActive returns true, when the process instance is active, and, for sub-processes,
+// when any job, message subscription or timer within the sub-process is active
+func (state *BpmnEngineState) isScopeActive(instance *processInstanceInfo, scopeId string) bool {
+	if instance.ActivityState != Active {
+		return false
+	}
+	graph := instance.ProcessInfo.graph
+	if scopeId == instance.ProcessInfo.definitions.Process.Id {
+		return true
+	}
+	for _, j := range state.jobs.ofInstance(instance.InstanceKey) {
+		if j.JobState == Active && graph.isInScope(j.ElementId, scopeId) {
+			return true
+		}
+	}
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if ms.MessageState == Active && graph.isInScope(ms.ElementId, scopeId) {
+			return true
+		}
+	}
+	for _, t := range state.timers.ofInstance(instance.InstanceKey) {
+		if t.TimerState == TimerCreated && graph.isInScope(t.ElementId, scopeId) {
+			return true
+		}
+	}
+	return false
+}
+
+// armEventSubProcesses creates the timers, message subscriptions and waiting conditional events for the start events
+// of the scope's event sub-processes, which can be triggered, as long as the scope is active
+func (state *BpmnEngineState) armEventSubProcesses(instance *processInstanceInfo, scope BPMN20.ProcessElement) error {
+	graph := instance.ProcessInfo.graph
+	for _, esp := range graph.eventSubProcesses[scope.GetId()] {
+		for _, element := range graph.startEvents[(*esp).GetId()] {
+			startEvent := (*element).(BPMN20.TStartEvent)
+			if startEvent.TimerEventDefinition.Id != "" {
+				if _, err := state.createTimer(instance, startEvent, startEvent.TimerEventDefinition, nil); err != nil {
+					return err
+				}
+			} else if startEvent.MessageEventDefinition.Id != "" {
+				state.createMessageSubscription(instance, startEvent)
+			} else if startEvent.ConditionalEventDefinition.Id != "" {
+				instance.appendActivity(&conditionalEventActivity{
+					key:     state.generateKey(),
+					state:   Active,
+					element: element,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// disarmEventSubProcesses withdraws the message subscriptions and conditional events, and cancels the timers,
+// which were created for the start events of the scope's event sub-processes
+func (state *BpmnEngineState) disarmEventSubProcesses(instance *processInstanceInfo, scopeId string) {
+	graph := instance.ProcessInfo.graph
+	for _, esp := range graph.eventSubProcesses[scopeId] {
+		for _, startEvent := range graph.startEvents[(*esp).GetId()] {
+			for _, ms := range state.messageSubscriptions.ofElement(instance.InstanceKey, (*startEvent).GetId()) {
+				if ms.MessageState == Active {
+					ms.MessageState = Withdrawn
+				}
+			}
+			for _, t := range state.timers.ofElement(instance.InstanceKey, (*startEvent).GetId()) {
+				if t.TimerState == TimerCreated {
+					t.TimerState = TimerCancelled
+				}
+			}
+			if ce := instance.findActiveActivityByElementId((*startEvent).GetId()); ce != nil {
+				ce.SetState(Withdrawn)
+			}
+		}
+	}
+}
+
+// handleEventSubProcessStartEvent triggers the event sub-process, when the timer of the start event is due,
+// or its message was caught; a non-interrupting message start event is armed again, afterward
+func (state *BpmnEngineState) handleEventSubProcessStartEvent(act activity, instance *processInstanceInfo, esp *BPMN20.TSubProcess, element *BPMN20.BaseElement) (activity, []command) {
+	graph := instance.ProcessInfo.graph
+	startEvent := (*element).(BPMN20.TStartEvent)
+	if startEvent.TimerEventDefinition.Id != "" {
+		timer := findExistingTimerNotYetTriggered(state, startEvent.Id, instance)
+		if timer == nil {
+			return nil, nil
+		}
+		if !time.Now().After(timer.DueAt) {
+			return timer, nil
+		}
+		timer.TimerState = TimerTriggered
+		return timer, state.triggerEventSubProcess(act, instance, graph.element(esp.Id), startEvent.Interrupting())
+	}
+	ms := findMatchingActiveSubscriptions(state.messageSubscriptions.ofElement(instance.InstanceKey, startEvent.Id))
+	if ms == nil {
+		return nil, nil
+	}
+	caughtEvent := findMatchingCaughtEvent(instance, startEvent.MessageEventDefinition.MessageRef)
+	if caughtEvent == nil {
+		return ms, nil
+	}
+	caughtEvent.IsConsumed = true
+	for k, v := range caughtEvent.Variables {
+		instance.SetVariable(k, v)
+	}
+	if err := evaluateLocalVariables(graph.expressions, &instance.VariableHolder, startEvent.Output); err != nil {
+		ms.MessageState = Failed
+		return ms, []command{errorCommand{
+			err: &ExpressionEvaluationError{
+				Msg: fmt.Sprintf("Error evaluating expression in message start event element id='%s' name='%s'", startEvent.Id, startEvent.Name),
+				Err: err,
+			},
+			elementId:   startEvent.Id,
+			elementName: startEvent.Name,
+		}}
+	}
+	ms.MessageState = Completed
+	if !startEvent.Interrupting() {
+		state.createMessageSubscription(instance, startEvent)
+	}
+	return ms, state.triggerEventSubProcess(act, instance, graph.element(esp.Id), startEvent.Interrupting())
+}
+
+// throwError triggers the event sub-process, which catches the error of the end event, within the end event's scope,
+// or else the error boundary event, which is attached to the scope, and so on up the parent scopes;
+// an uncaught error fails the process instance
+func (state *BpmnEngineState) throwError(act activity, instance *processInstanceInfo, endEvent BPMN20.TEndEvent) []command {
+	graph := instance.ProcessInfo.graph
+	errorCode := graph.errorCodes[endEvent.ErrorEventDefinition.ErrorRef]
+	matches := func(errorDefinition BPMN20.TErrorEventDefinition) (matched bool, exact bool) {
+		if errorDefinition.Id == "" {
+			return false, false
+		}
+		if errorDefinition.ErrorRef == "" {
+			// catches all errors
+			return true, false
+		}
+		return graph.errorCodes[errorDefinition.ErrorRef] == errorCode, true
+	}
+	matchesStartEvent := func(catchEvent BPMN20.BaseElement) (bool, bool) {
+		return matches(catchEvent.(BPMN20.TStartEvent).ErrorEventDefinition)
+	}
+	matchesBoundaryEvent := func(catchEvent BPMN20.BaseElement) (bool, bool) {
+		return matches(catchEvent.(BPMN20.TBoundaryEvent).ErrorEventDefinition)
+	}
+	for scope := graph.scopes[endEvent.Id]; scope != nil; scope = graph.scopes[scope.GetId()] {
+		if esp, _ := graph.catchingEventSubProcessIn(scope.GetId(), endEvent.Id, matchesStartEvent); esp != nil {
+			return state.triggerEventSubProcess(act, instance, esp, true)
+		}
+		if subProcess := runningSubProcess(act, scope.GetId()); subProcess != nil {
+			if boundaryEvent := graph.catchingBoundaryEvent(scope.GetId(), matchesBoundaryEvent); boundaryEvent != nil {
+				state.triggerBoundaryEvent(instance, subProcess, boundaryEvent)
+				return nil
+			}
+		}
+	}
+	return []command{errorCommand{
+		err:         newEngineErrorf("no event sub-process catches the error with code=%s, thrown by end event id=%s", errorCode, endEvent.Id),
+		elementId:   endEvent.Id,
+		elementName: endEvent.Name,
+	}}
+}
+
+// triggerEventSubProcess runs the event sub-process; an interrupting one interrupts its parent scope first,
+// and completes the scope, when it's completed itself
+func (state *BpmnEngineState) triggerEventSubProcess(act activity, instance *processInstanceInfo, espElement *BPMN20.BaseElement, interrupting bool) []command {
+	graph := instance.ProcessInfo.graph
+	esp := (*espElement).(BPMN20.TSubProcess)
+	scope := graph.scopes[esp.Id]
+	if interrupting {
+		state.interruptScope(instance, scope.GetId(), esp.Id)
+	}
+	espActivity := &subProcessInfo{
+		ElementId:       esp.Id,
+		ProcessInstance: instance,
+		ProcessId:       state.generateKey(),
+		CreatedAt:       time.Now(),
+		processState:    Ready,
+		variableHolder:  NewVarHolder(&instance.VariableHolder, nil),
+		baseElement:     espElement,
+		parentActivity:  act,
+	}
+	state.exportElementEvent(scope, *instance, *espElement, exporter.ElementActivated)
+	if err := state.run(&esp, instance, espActivity); err != nil {
+		return []command{errorCommand{
+			err:         err,
+			elementId:   esp.Id,
+			elementName: esp.Name,
+		}}
+	}
+	if espActivity.State() == Completed {
+		state.exportElementEvent(scope, *instance, *espElement, exporter.ElementCompleted)
+		if interrupting {
+			completeScope(act, scope.GetId())
+		}
+	}
+	return nil
+}
+
+// interruptScope terminates the jobs, withdraws the message subscriptions and cancels the timers of the scope,
+// except the ones within the interrupting event sub-process, if any
+func (state *BpmnEngineState) interruptScope(instance *processInstanceInfo, scopeId string, eventSubProcessId string) {
+	interruption := scopeInterruption{scopeId: scopeId, eventSubProcessId: eventSubProcessId}
+	for _, j := range state.jobs.ofInstance(instance.InstanceKey) {
+		if j.JobState == Active && instance.isInterruptedBy(j.ElementId, interruption) {
+			j.JobState = Terminated
+		}
+	}
+	for _, ms := range state.messageSubscriptions.ofInstance(instance.InstanceKey) {
+		if ms.MessageState == Active && instance.isInterruptedBy(ms.ElementId, interruption) {
+			ms.MessageState = Withdrawn
+		}
+	}
+	for _, t := range state.timers.ofInstance(instance.InstanceKey) {
+		if t.TimerState == TimerCreated && instance.isInterruptedBy(t.ElementId, interruption) {
+			t.TimerState = TimerCancelled
+		}
+	}
+	for _, a := range instance.activities {
+		if a.State() == Active && instance.isInterruptedBy((*a.Element()).GetId(), interruption) {
+			a.SetState(Withdrawn)
+		}
+	}
+	instance.interruptedScopes = append(instance.interruptedScopes, interruption)
+}
+
+// completeScope completes the activity of the scope, within the chain of the running (sub) processes
+func completeScope(act activity, scopeId string) {
+	for a := act; a != nil; a = parentActivityOf(a) {
+		if (*a.Element()).GetId() == scopeId {
+			a.SetState(Completed)
+			return
+		}
+	}
+}
+
+// runningSubProcess returns the sub-process of the given scope, within the chain of the running (sub) processes,
+// or nil, when it's not running
+func runningSubProcess(act activity, scopeId string) *subProcessInfo {
+	for a := act; a != nil; a = parentActivityOf(a) {
+		if subProcess, ok := a.(*subProcessInfo); ok && subProcess.ElementId == scopeId {
+			return subProcess
+		}
+	}
+	return nil
+}
+
+// isInterrupted returns true, when the element belongs to a scope, which was interrupted
+// after the given number of interruptions
+func (pii *processInstanceInfo) isInterrupted(elementId string, since int) bool {
+	if elementId == "" {
+		return false
+	}
+	for _, interruption := range pii.interruptedScopes[since:] {
+		if pii.isInterruptedBy(elementId, interruption) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pii *processInstanceInfo) isInterruptedBy(elementId string, interruption scopeInterruption) bool {
+	graph := pii.ProcessInfo.graph
+	return graph.isInScope(elementId, interruption.scopeId) && !graph.isInScope(elementId, interruption.eventSubProcessId)
+}
+
+// commandElementId returns the ID of the element, which the command continues, or an empty string
+func commandElementId(cmd command) string {
+	switch c := cmd.(type) {
+	case flowTransitionCommand:
+		return c.sourceId
+	case activityCommand:
+		return (*c.element).GetId()
+	case continueActivityCommand:
+		return (*c.activity.Element()).GetId()
+	case checkExclusiveGatewayDoneCommand:
+		return (*c.gatewayActivity.Element()).GetId()
+	}
+	return ""
+}
@@ -0,0 +1,244 @@
+package bpmn_engine
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_interrupting_timer_event_sub_process_terminates_the_process_scope(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-timer.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("escalate").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"timeout": "PT0S"})
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, bpmnEngine.GetTimersScheduled(), has.Length(1))
+
+	// when
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("escalate"))
+	then.AssertThat(t, bpmnEngine.GetTimersScheduled()[0].TimerState, is.EqualTo(TimerTriggered))
+	then.AssertThat(t, bpmnEngine.jobs.all[0].ElementId, is.EqualTo("work"))
+	then.AssertThat(t, bpmnEngine.jobs.all[0].JobState, is.EqualTo(Terminated))
+}
+
+func Test_timer_event_sub_process_is_disarmed_when_the_process_completes(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-timer.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("escalate").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"timeout": "PT1H"})
+	then.AssertThat(t, err, is.Nil())
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+
+	// when
+	bpmnEngine.NewTaskHandler().Type("work").Handler(cp.TaskHandler)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("work"))
+	then.AssertThat(t, bpmnEngine.GetTimersScheduled()[0].TimerState, is.EqualTo(TimerCancelled))
+}
+
+func Test_non_interrupting_message_event_sub_process_runs_for_every_message(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-message.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("report-status").Handler(cp.TaskHandler)
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	for i := 0; i < 2; i++ {
+		err = bpmnEngine.PublishEventForInstance(instance.InstanceKey, "status", nil)
+		then.AssertThat(t, err, is.Nil())
+		_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+		then.AssertThat(t, err, is.Nil())
+	}
+
+	// then
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("report-status,report-status"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "work")[0].JobState, is.EqualTo(Active))
+	var activeSubscriptions []string
+	for _, ms := range bpmnEngine.GetMessageSubscriptions() {
+		if ms.State() == Active {
+			activeSubscriptions = append(activeSubscriptions, ms.ElementId)
+		}
+	}
+	then.AssertThat(t, activeSubscriptions, is.EqualTo([]string{"cancel-requested", "status-requested"}))
+}
+
+func Test_interrupting_message_event_sub_process_withdraws_the_other_subscriptions(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-message.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("handle-cancel").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("report-status").Handler(cp.TaskHandler)
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	err = bpmnEngine.PublishEventForInstance(instance.InstanceKey, "cancel", map[string]interface{}{"reason": "changed my mind"})
+	then.AssertThat(t, err, is.Nil())
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("handle-cancel"))
+	then.AssertThat(t, instance.GetVariable("cancelReason"), is.EqualTo("changed my mind"))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "work")[0].JobState, is.EqualTo(Terminated))
+	for _, ms := range bpmnEngine.GetMessageSubscriptions() {
+		then.AssertThat(t, ms.State(), is.Not(is.EqualTo(Active)))
+	}
+}
+
+func Test_error_end_events_trigger_the_catching_event_sub_process(t *testing.T) {
+	tests := []struct {
+		status   string
+		callPath string
+	}{
+		{"ok", "charge,ship"},
+		{"declined", "charge,notify-customer,ship"},
+		{"fraud", "charge,block-order"},
+	}
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-error.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"charge", "notify-customer", "block-order", "ship"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"status": test.status})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_uncaught_error_end_event_fails_the_instance(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/error-end-event-uncaught.bpmn")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.EqualTo("no event sub-process catches the error with code=FAILURE, thrown by end event id=failed"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+}
+
+func Test_broadcast_signal_triggers_event_sub_processes_of_active_instances(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-signal.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("recalculate").Handler(cp.TaskHandler)
+	first, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	second, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	notStarted, err := bpmnEngine.CreateInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	err = bpmnEngine.BroadcastSignal("otherSignal", nil)
+	then.AssertThat(t, err, is.Nil())
+	err = bpmnEngine.BroadcastSignal("priceChanged", map[string]interface{}{"price": 42})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("recalculate,recalculate"))
+	then.AssertThat(t, first.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, first.GetVariable("price"), is.EqualTo(42))
+	then.AssertThat(t, second.GetState(), is.EqualTo(Active))
+	then.AssertThat(t, notStarted.GetState(), is.EqualTo(Ready))
+	then.AssertThat(t, notStarted.GetVariable("price"), is.Nil())
+}
+
+func Test_broadcast_signal_interrupts_the_sub_process_of_a_nested_event_sub_process(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-signal-in-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("handle-cancel").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("archive").Handler(cp.TaskHandler)
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+
+	// when
+	err = bpmnEngine.BroadcastSignal("cancelOrder", nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("handle-cancel,archive"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "work")[0].JobState, is.EqualTo(Terminated))
+}
+
+func Test_armed_event_sub_processes_survive_marshalling(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-message.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+		then.AssertThat(t, err, is.Nil())
+
+		// given
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		cp := CallPath{}
+		restored.NewTaskHandler().Type("handle-cancel").Handler(cp.TaskHandler)
+
+		// when
+		err = restored.PublishEventForInstance(instance.InstanceKey, "cancel", map[string]interface{}{"reason": "too late"})
+		then.AssertThat(t, err, is.Nil())
+		restoredInstance, err := restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restoredInstance.GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, cp.CallPath, is.EqualTo("handle-cancel"))
+		then.AssertThat(t, restoredInstance.GetVariable("cancelReason"), is.EqualTo("too late"))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20/extensions"
 )
 
 type MessageSubscription struct {
@@ -36,10 +37,10 @@ func (m MessageSubscription) Element() *BPMN20.BaseElement {
 }
 
 type catchEvent struct {
-	name       string
-	caughtAt   time.Time
-	isConsumed bool
-	variables  map[string]interface{}
+	Name       string                 `json:"n"`
+	CaughtAt   time.Time              `json:"ca"`
+	IsConsumed bool                   `json:"ic,omitempty"`
+	Variables  map[string]interface{} `json:"v,omitempty"`
 }
 
 // PublishEventForInstance publishes a message with a given name and also adds variables to the process instance, which fetches this event
@@ -47,10 +48,10 @@ func (state *BpmnEngineState) PublishEventForInstance(processInstanceKey int64,
 	processInstance := state.FindProcessInstance(processInstanceKey)
 	if processInstance != nil {
 		event := catchEvent{
-			caughtAt:   time.Now(),
-			name:       messageName,
-			variables:  variables,
-			isConsumed: false,
+			CaughtAt:   time.Now(),
+			Name:       messageName,
+			Variables:  variables,
+			IsConsumed: false,
 		}
 		processInstance.CaughtEvents = append(processInstance.CaughtEvents, event)
 	} else {
@@ -60,11 +61,12 @@ func (state *BpmnEngineState) PublishEventForInstance(processInstanceKey int64,
 }
 
 // GetMessageSubscriptions the list of message subscriptions
-// hint: each intermediate message catch event, will create such an active subscription,
+// hint: each intermediate message catch event and receive task, will create such an active subscription,
 // when a processes instance reaches such an element.
 func (state *BpmnEngineState) GetMessageSubscriptions() []MessageSubscription {
-	subscriptions := make([]MessageSubscription, len(state.messageSubscriptions))
-	for i, ms := range state.messageSubscriptions {
+	records := state.messageSubscriptions.records()
+	subscriptions := make([]MessageSubscription, len(records))
+	for i, ms := range records {
 		subscriptions[i] = *ms
 	}
 	return subscriptions
@@ -74,15 +76,27 @@ func (state *BpmnEngineState) GetMessageSubscriptions() []MessageSubscription {
 // A Timer is created, when a process instance reaches a Timer Intermediate Catch Event element
 // and expresses a timestamp in the future
 func (state *BpmnEngineState) GetTimersScheduled() []Timer {
-	timers := make([]Timer, len(state.timers))
-	for i, t := range state.timers {
+	records := state.timers.records()
+	timers := make([]Timer, len(records))
+	for i, t := range records {
 		timers[i] = *t
 	}
 	return timers
 }
 
 func (state *BpmnEngineState) handleIntermediateMessageCatchEvent(process BPMN20.ProcessElement, instance *processInstanceInfo, ice BPMN20.TIntermediateCatchEvent, originActivity activity) (continueFlow bool, ms *MessageSubscription, err error) {
-	ms = findMatchingActiveSubscriptions(state.messageSubscriptions, instance.InstanceKey, ice.Id)
+	return state.handleMessageCatch(instance, ice, ice.MessageEventDefinition.MessageRef, ice.Output, originActivity)
+}
+
+// handleReceiveTask waits for the message like an intermediate message catch event
+func (state *BpmnEngineState) handleReceiveTask(instance *processInstanceInfo, receiveTask BPMN20.TReceiveTask, originActivity activity) (continueFlow bool, ms *MessageSubscription, err error) {
+	return state.handleMessageCatch(instance, receiveTask, receiveTask.MessageRef, receiveTask.Output, originActivity)
+}
+
+// handleMessageCatch creates a message subscription for the element, or completes it, when the message was caught;
+// the output mappings are evaluated with the message's variables
+func (state *BpmnEngineState) handleMessageCatch(instance *processInstanceInfo, element BPMN20.BaseElement, messageRef string, output []extensions.TIoMapping, originActivity activity) (continueFlow bool, ms *MessageSubscription, err error) {
+	ms = findMatchingActiveSubscriptions(state.messageSubscriptions.ofElement(instance.InstanceKey, element.GetId()))
 
 	if originActivity != nil && (*originActivity.Element()).GetType() == BPMN20.EventBasedGateway {
 		ebgActivity := originActivity.(*eventBasedGatewayActivity)
@@ -93,23 +107,22 @@ func (state *BpmnEngineState) handleIntermediateMessageCatchEvent(process BPMN20
 	}
 
 	if ms == nil {
-		ms = state.createMessageSubscription(instance, ice)
+		ms = state.createMessageSubscription(instance, element)
 		ms.originActivity = originActivity
 	}
 
-	messages := state.findMessagesByProcessKey(instance.ProcessInfo.ProcessKey)
-	caughtEvent := findMatchingCaughtEvent(messages, instance, ice)
+	caughtEvent := findMatchingCaughtEvent(instance, messageRef)
 
 	if caughtEvent != nil {
-		caughtEvent.isConsumed = true
-		for k, v := range caughtEvent.variables {
+		caughtEvent.IsConsumed = true
+		for k, v := range caughtEvent.Variables {
 			instance.SetVariable(k, v)
 		}
-		if err := evaluateLocalVariables(&instance.VariableHolder, ice.Output); err != nil {
+		if err := evaluateLocalVariables(instance.ProcessInfo.graph.expressions, &instance.VariableHolder, output); err != nil {
 			ms.MessageState = Failed
 			instance.ActivityState = Failed
 			evalErr := &ExpressionEvaluationError{
-				Msg: fmt.Sprintf("Error evaluating expression in intermediate message catch event element id='%s' name='%s'", ice.Id, ice.Name),
+				Msg: fmt.Sprintf("Error evaluating expression in %s element id='%s' name='%s'", messageCatchDescription(element), element.GetId(), element.GetName()),
 				Err: err,
 			}
 			return false, ms, evalErr
@@ -119,7 +132,7 @@ func (state *BpmnEngineState) handleIntermediateMessageCatchEvent(process BPMN20
 			originActivity := instance.findActivity(ms.originActivity.Key())
 			if originActivity != nil && (*originActivity.Element()).GetType() == BPMN20.EventBasedGateway {
 				ebgActivity := originActivity.(*eventBasedGatewayActivity)
-				ebgActivity.SetOutboundCompleted(ice.Id)
+				ebgActivity.SetOutboundCompleted(element.GetId())
 			}
 		}
 		return true, ms, err
@@ -127,58 +140,45 @@ func (state *BpmnEngineState) handleIntermediateMessageCatchEvent(process BPMN20
 	return false, ms, err
 }
 
-func (state *BpmnEngineState) createMessageSubscription(instance *processInstanceInfo, ice BPMN20.TIntermediateCatchEvent) *MessageSubscription {
-	var be BPMN20.BaseElement = ice
+func messageCatchDescription(element BPMN20.BaseElement) string {
+	if element.GetType() == BPMN20.ReceiveTask {
+		return "receive task"
+	}
+	return "intermediate message catch event"
+}
+
+func (state *BpmnEngineState) createMessageSubscription(instance *processInstanceInfo, element BPMN20.BaseElement) *MessageSubscription {
 	ms := &MessageSubscription{
-		ElementId:          ice.Id,
+		ElementId:          element.GetId(),
 		ElementInstanceKey: state.generateKey(),
 		ProcessKey:         instance.ProcessInfo.ProcessKey,
 		ProcessInstanceKey: instance.GetInstanceKey(),
-		Name:               ice.Name,
+		Name:               element.GetName(),
 		CreatedAt:          time.Now(),
 		MessageState:       Active,
-		baseElement:        &be,
+		baseElement:        &element,
 	}
-	state.messageSubscriptions = append(state.messageSubscriptions, ms)
+	state.messageSubscriptions.add(ms)
 	return ms
 }
 
-func (state *BpmnEngineState) findMessagesByProcessKey(processKey int64) *[]BPMN20.TMessage {
-	for _, p := range state.processes {
-		if p.ProcessKey == processKey {
-			return &p.definitions.Messages
-		}
-	}
-	return nil
-}
-
 // find first matching catchEvent
-func findMatchingCaughtEvent(messages *[]BPMN20.TMessage, instance *processInstanceInfo, ice BPMN20.TIntermediateCatchEvent) *catchEvent {
-	msgName := findMessageNameById(messages, ice.MessageEventDefinition.MessageRef)
+func findMatchingCaughtEvent(instance *processInstanceInfo, messageRef string) *catchEvent {
+	msgName := instance.ProcessInfo.graph.messageNames[messageRef]
 	for i := 0; i < len(instance.CaughtEvents); i++ {
 		var caughtEvent = &instance.CaughtEvents[i]
-		if !caughtEvent.isConsumed && msgName == caughtEvent.name {
+		if !caughtEvent.IsConsumed && msgName == caughtEvent.Name {
 			return caughtEvent
 		}
 	}
 	return nil
 }
 
-func findMessageNameById(messages *[]BPMN20.TMessage, msgId string) string {
-	for _, message := range *messages {
-		if message.Id == msgId {
-			return message.Name
-		}
-	}
-	return ""
-}
-
-func findMatchingActiveSubscriptions(messageSubscriptions []*MessageSubscription, processInstanceKey int64, elementId string) *MessageSubscription {
-	var existingSubscription *MessageSubscription
-	for _, ms := range messageSubscriptions {
-		if ms.ProcessInstanceKey == processInstanceKey && ms.MessageState == Active && ms.ElementId == elementId {
-			existingSubscription = ms
-			return existingSubscription
+// findMatchingActiveSubscriptions returns the first active one of the element's subscriptions
+func findMatchingActiveSubscriptions(elementSubscriptions []*MessageSubscription) *MessageSubscription {
+	for _, ms := range elementSubscriptions {
+		if ms.MessageState == Active {
+			return ms
 		}
 	}
 	return nil
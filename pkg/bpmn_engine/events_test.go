@@ -355,5 +355,5 @@ func Test_intermediate_message_catch_event_output_mapping_failed(t *testing.T) {
 	// then
 	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
 	then.AssertThat(t, instance.GetVariable("mappedFoo"), is.Nil())
-	then.AssertThat(t, bpmnEngine.messageSubscriptions[0].MessageState, is.EqualTo(Failed))
+	then.AssertThat(t, bpmnEngine.messageSubscriptions.all[0].MessageState, is.EqualTo(Failed))
 }
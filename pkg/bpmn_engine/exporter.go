@@ -81,3 +81,17 @@ func (state *BpmnEngineState) exportSequenceFlowEvent(process ProcessInfo, proce
 		exp.NewElementEvent(&event, &info)
 	}
 }
+
+func (state *BpmnEngineState) exportDeleteProcessInstanceEvent(processInstance processInstanceInfo) {
+	event := exporter.ProcessInstanceEvent{
+		ProcessId:          processInstance.ProcessInfo.BpmnProcessId,
+		ProcessKey:         processInstance.ProcessInfo.ProcessKey,
+		Version:            processInstance.ProcessInfo.Version,
+		ProcessInstanceKey: processInstance.InstanceKey,
+	}
+	for _, exp := range state.exporters {
+		if deletionExporter, ok := exp.(exporter.DeletionEventExporter); ok {
+			deletionExporter.DeleteProcessInstanceEvent(&event)
+		}
+	}
+}
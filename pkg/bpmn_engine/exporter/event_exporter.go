@@ -7,6 +7,12 @@ type EventExporter interface {
 	NewElementEvent(event *ProcessInstanceEvent, elementInfo *ElementInfo)
 }
 
+// DeletionEventExporter can optionally be implemented by an EventExporter,
+// to get notified, when process instances are removed from the engine (e.g. by PurgeCompleted)
+type DeletionEventExporter interface {
+	DeleteProcessInstanceEvent(event *ProcessInstanceEvent)
+}
+
 type Intent string
 
 const (
@@ -16,6 +22,11 @@ const (
 	ElementCompleted  Intent = "ELEMENT_COMPLETED"
 	SequenceFlowTaken Intent = "SEQUENCE_FLOW_TAKEN"
 	Created           Intent = "CREATED"
+	// EscalationNotCaught is exported for the throwing element, when no event sub-process or boundary event catches an escalation
+	EscalationNotCaught Intent = "ESCALATION_NOT_CAUGHT"
+	// MessageReceiverFailed is exported for the sending element, when the instance of another process,
+	// which received the message along a message flow, failed
+	MessageReceiverFailed Intent = "MESSAGE_RECEIVER_FAILED"
 )
 
 type ProcessEvent struct {
@@ -37,5 +48,5 @@ type ProcessInstanceEvent struct {
 type ElementInfo struct {
 	BpmnElementType string
 	ElementId       string
-	Intent          string // ELEMENT_ACTIVATING || ELEMENT_ACTIVATED || ELEMENT_COMPLETING || ELEMENT_COMPLETED
+	Intent          string // ELEMENT_ACTIVATING || ELEMENT_ACTIVATED || ELEMENT_COMPLETING || ELEMENT_COMPLETED || ESCALATION_NOT_CAUGHT || MESSAGE_RECEIVER_FAILED
 }
@@ -0,0 +1,100 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pbinitiative/feel"
+)
+
+// ExpressionLanguageFEEL identifies FEEL, the default expression language,
+// used when neither the definitions nor the expression itself declare a language
+const ExpressionLanguageFEEL = "https://www.omg.org/spec/FEEL/20140401"
+
+// expressionLanguageXPath is the default of the BPMN specification, which modelers write,
+// even though the expressions are FEEL, so it's treated like an unknown default language (see newCompiledExpressions)
+const expressionLanguageXPath = "http://www.w3.org/1999/XPath"
+
+// normalizeExpressionLanguage returns ExpressionLanguageFEEL for all spellings of the FEEL URI,
+// i.e. with http or https, with trailing slash, or as defined by the DMN specification, e.g. "https://www.omg.org/spec/DMN/20191111/FEEL/"
+func normalizeExpressionLanguage(language string) string {
+	uri := strings.ToLower(strings.TrimSuffix(language, "/"))
+	uri = strings.TrimPrefix(strings.TrimPrefix(uri, "https://"), "http://")
+	if uri == "www.omg.org/spec/feel/20140401" ||
+		strings.HasPrefix(uri, "www.omg.org/spec/dmn/") && strings.HasSuffix(uri, "/feel") {
+		return ExpressionLanguageFEEL
+	}
+	return language
+}
+
+// ExpressionEvaluator parses the expressions of one expression language (see WithExpressionEvaluator)
+type ExpressionEvaluator interface {
+	// Compile parses the expression, when a process is loaded;
+	// an error makes the loading fail with an ExpressionSyntaxError
+	Compile(expression string) (CompiledExpression, error)
+}
+
+// CompiledExpression is a parsed expression, which is evaluated many times, with the variables of a process instance
+type CompiledExpression interface {
+	// Evaluate returns the result, i.e. a bool for conditions, or the value stored as variable by a mapping
+	Evaluate(variables map[string]interface{}) (interface{}, error)
+}
+
+// WithExpressionEvaluator registers an evaluator for the given expression language,
+// which is selected by the 'expressionLanguage' attribute of the definitions,
+// or by the 'language' attribute of a single expression, e.g. of a sequence flow's condition.
+// The language is matched exactly, e.g. "https://www.omg.org/spec/FEEL/20140401" or a short name like "expr";
+// only the spellings of the FEEL URI, e.g. with http instead of https, are the same language.
+// Registering an evaluator for ExpressionLanguageFEEL replaces the built-in one,
+// and thus custom functions and context values (see WithExpressionFunction) aren't available anymore.
+func WithExpressionEvaluator(language string, evaluator ExpressionEvaluator) EngineOption {
+	return func(state *BpmnEngineState) {
+		state.expressionEvaluators[normalizeExpressionLanguage(language)] = evaluator
+	}
+}
+
+// feelEvaluator is the built-in evaluator for FEEL,
+// with the engine's custom functions and context values (see WithExpressionFunction and WithExpressionContext)
+type feelEvaluator struct {
+	scope map[string]interface{}
+}
+
+type feelExpression struct {
+	node  feel.Node
+	scope map[string]interface{}
+}
+
+func (fe feelEvaluator) Compile(expression string) (CompiledExpression, error) {
+	expression = strings.TrimSpace(expression)
+	expression = strings.TrimPrefix(expression, "=") // Zeebe models mark expressions with a leading '=', which isn't part of FEEL
+	node, err := feel.ParseString(expression)
+	if err != nil {
+		return nil, err
+	}
+	return feelExpression{node: node, scope: fe.scope}, nil
+}
+
+// Evaluate converts the variables and the result between Go and FEEL (see toFeelValue and fromFeelValue)
+func (fe feelExpression) Evaluate(variables map[string]interface{}) (result interface{}, err error) {
+	defer recoverFeelPanic(&err)
+	interpreter := feel.NewIntepreter()
+	if len(fe.scope) > 0 {
+		interpreter.Push(fe.scope) // variables are pushed afterward, so that they shadow the engine's context
+	}
+	values := &feelValues{}
+	if variables != nil {
+		interpreter.Push(values.toFeelScope(variables))
+	}
+	res, err := fe.node.Eval(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	return values.fromFeelValue(res), nil
+}
+
+// recoverFeelPanic turns a panic of the FEEL library into an error, e.g. on operands of invalid types like `1 - "a"`
+func recoverFeelPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%v", r)
+	}
+}
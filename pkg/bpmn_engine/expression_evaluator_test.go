@@ -0,0 +1,213 @@
+package bpmn_engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+// comparisonEvaluator is a minimal expression language, which only supports comparisons like "price > 100"
+type comparisonEvaluator struct{}
+
+type comparison struct {
+	variable string
+	operator string
+	value    float64
+}
+
+func (comparisonEvaluator) Compile(expression string) (CompiledExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 3 || (fields[1] != ">" && fields[1] != "<=") {
+		return nil, fmt.Errorf("expected a comparison, like 'price > 100', but got %q", expression)
+	}
+	value, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, err
+	}
+	return comparison{variable: fields[0], operator: fields[1], value: value}, nil
+}
+
+func (c comparison) Evaluate(variables map[string]interface{}) (interface{}, error) {
+	value, ok := variables[c.variable].(int)
+	if !ok {
+		return nil, fmt.Errorf("variable %s must be an int", c.variable)
+	}
+	if c.operator == ">" {
+		return float64(value) > c.value, nil
+	}
+	return float64(value) <= c.value, nil
+}
+
+func Test_expression_language_of_a_condition_selects_the_evaluator(t *testing.T) {
+	tests := []struct {
+		price    int
+		expected string
+	}{
+		{150, "task-a"},
+		{50, "task-b"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			// setup
+			bpmnEngine := New(WithExpressionEvaluator("expr", comparisonEvaluator{}))
+			cp := CallPath{}
+
+			// given
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-expression-languages.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+			bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+
+			// when
+			_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": test.price})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.expected))
+			_, compiled := process.graph.expressions.compiled[expressionKey{language: "expr", expression: "price > 100"}]
+			then.AssertThat(t, compiled, is.True())
+			_, compiled = process.graph.expressions.compiled[expressionKey{language: ExpressionLanguageFEEL, expression: "price <= 100"}]
+			then.AssertThat(t, compiled, is.True())
+		})
+	}
+}
+
+func Test_expression_language_of_the_definitions_is_the_default(t *testing.T) {
+	// setup
+	xmlData, err := os.ReadFile("../../test-cases/exclusive-gateway-with-expression-languages.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), `id="Definitions_0l7x2kq"`, `id="Definitions_0l7x2kq" expressionLanguage="expr"`, 1))
+	bpmnEngine := New(WithExpressionEvaluator("expr", comparisonEvaluator{}))
+	cp := CallPath{}
+
+	// given
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": 50})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("task-b"))
+	_, compiled := process.graph.expressions.compiled[expressionKey{language: "expr", expression: "price <= 100"}]
+	then.AssertThat(t, compiled, is.True())
+}
+
+func Test_FEEL_is_used_for_definitions_declaring_another_language_without_evaluator(t *testing.T) {
+	tests := []string{
+		"http://www.w3.org/1999/XPath",
+		"http://www.omg.org/spec/FEEL/20140401",
+		"https://www.omg.org/spec/DMN/20191111/FEEL/",
+		"javascript",
+	}
+	for _, expressionLanguage := range tests {
+		t.Run(expressionLanguage, func(t *testing.T) {
+			// setup
+			xmlData, err := os.ReadFile("../../test-cases/exclusive-gateway-with-condition.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			xmlData = []byte(strings.Replace(string(xmlData), `id="Definitions_12fuprs"`, `id="Definitions_12fuprs" expressionLanguage="`+expressionLanguage+`"`, 1))
+			bpmnEngine := New()
+			cp := CallPath{}
+
+			// given
+			process, err := bpmnEngine.LoadFromBytes(xmlData)
+			then.AssertThat(t, err, is.Nil())
+			bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+			bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+
+			// when
+			_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": -50})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, cp.CallPath, is.EqualTo("task-b"))
+			_, compiled := process.graph.expressions.compiled[expressionKey{language: ExpressionLanguageFEEL, expression: "price < 0"}]
+			then.AssertThat(t, compiled, is.True())
+		})
+	}
+}
+
+func Test_FEEL_spellings_of_an_expression_language_select_the_FEEL_evaluator(t *testing.T) {
+	// setup
+	xmlData, err := os.ReadFile("../../test-cases/exclusive-gateway-with-expression-languages.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), `language="expr"`, `language="http://www.omg.org/spec/FEEL/20140401"`, 1))
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	_, compiled := process.graph.expressions.compiled[expressionKey{language: ExpressionLanguageFEEL, expression: "price > 100"}]
+	then.AssertThat(t, compiled, is.True())
+}
+
+func Test_loading_fails_for_expression_language_without_evaluator(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	_, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-expression-languages.bpmn")
+
+	// then
+	var syntaxError *ExpressionSyntaxError
+	then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+	then.AssertThat(t, syntaxError.ElementId, is.EqualTo("expensive"))
+	then.AssertThat(t, syntaxError.Language, is.EqualTo("expr"))
+	then.AssertThat(t, err.Error(), has.Prefix("invalid expr expression"))
+}
+
+func Test_loading_fails_on_syntax_error_of_another_expression_language(t *testing.T) {
+	// setup
+	xmlData, err := os.ReadFile("../../test-cases/exclusive-gateway-with-expression-languages.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), "price &gt; 100<", "price &gt;<", 1))
+	bpmnEngine := New(WithExpressionEvaluator("expr", comparisonEvaluator{}))
+
+	// when
+	_, err = bpmnEngine.LoadFromBytes(xmlData)
+
+	// then
+	var syntaxError *ExpressionSyntaxError
+	then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+	then.AssertThat(t, syntaxError.ElementId, is.EqualTo("expensive"))
+	then.AssertThat(t, syntaxError.Attribute, is.EqualTo("conditionExpression"))
+}
+
+func Test_FEEL_expressions_may_start_with_an_equal_sign(t *testing.T) {
+	// setup
+	evaluator := feelEvaluator{}
+
+	// when
+	compiled, err := evaluator.Compile("= 1 + 2")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	result, err := compiled.Evaluate(nil)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result, is.EqualTo(3))
+}
+
+func Test_FEEL_evaluation_of_invalid_operand_types_returns_an_error(t *testing.T) {
+	// setup
+	evaluator := feelEvaluator{}
+	compiled, err := evaluator.Compile("1 - discount")
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	_, err = compiled.Evaluate(map[string]interface{}{"discount": "none"})
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
@@ -0,0 +1,82 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pbinitiative/feel"
+)
+
+// WithExpressionFunction registers a Go function, which can be called by the given name
+// in all FEEL expressions of the engine (conditions, input and output mappings and timer durations).
+// The function may have any number of parameters, also a variadic one, and must return a single value,
+// optionally followed by an error. Arguments are converted from FEEL to the parameter types, and the result back to FEEL,
+// e.g. a FEEL number can be passed to an int or float64 parameter, and a returned time.Time becomes a FEEL date and time.
+// When the function returns an error, or an argument can't be converted, the evaluation of the expression fails.
+// It panics, when fn isn't a function with a supported signature.
+func WithExpressionFunction(name string, fn interface{}) EngineOption {
+	nativeFun := newNativeFunction(name, fn)
+	return func(state *BpmnEngineState) {
+		state.expressionScope[name] = nativeFun
+	}
+}
+
+// WithExpressionContext registers a static value, which can be used by the given name
+// in all FEEL expressions of the engine. Process instance variables with the same name take precedence.
+// The value is converted to FEEL, like results of functions (see WithExpressionFunction).
+func WithExpressionContext(name string, value interface{}) EngineOption {
+	feelValue := toFeelValue(value)
+	return func(state *BpmnEngineState) {
+		state.expressionScope[name] = feelValue
+	}
+}
+
+var goErrorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func newNativeFunction(name string, fn interface{}) *feel.NativeFun {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		panic(fmt.Sprintf("expression function %s must be a function, but is %s", name, ft))
+	}
+	if ft.NumOut() != 1 && (ft.NumOut() != 2 || ft.Out(1) != goErrorType) {
+		panic(fmt.Sprintf("expression function %s must return a single value, optionally followed by an error", name))
+	}
+	fixedArgs := ft.NumIn()
+	if ft.IsVariadic() {
+		fixedArgs--
+	}
+	argNames := make([]string, fixedArgs)
+	for i := range argNames {
+		argNames[i] = fmt.Sprintf("arg%d", i)
+	}
+	nativeFun := feel.NewNativeFunc(func(args map[string]interface{}) (interface{}, error) {
+		in := make([]reflect.Value, 0, ft.NumIn())
+		for i, argName := range argNames {
+			arg, err := fromFeelValueTo(args[argName], ft.In(i))
+			if err != nil {
+				return nil, fmt.Errorf("expression function %s, argument %d: %w", name, i+1, err)
+			}
+			in = append(in, arg)
+		}
+		if ft.IsVariadic() {
+			varArgs, _ := args["args"].([]interface{})
+			for i, varArg := range varArgs {
+				arg, err := fromFeelValueTo(varArg, ft.In(fixedArgs).Elem())
+				if err != nil {
+					return nil, fmt.Errorf("expression function %s, argument %d: %w", name, fixedArgs+i+1, err)
+				}
+				in = append(in, arg)
+			}
+		}
+		out := fv.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return nil, out[1].Interface().(error)
+		}
+		return toFeelValue(out[0].Interface()), nil
+	}).Required(argNames...)
+	if ft.IsVariadic() {
+		nativeFun.Vararg("args")
+	}
+	return nativeFun
+}
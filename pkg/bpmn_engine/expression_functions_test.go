@@ -0,0 +1,143 @@
+package bpmn_engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func discount(price float64, rate int) float64 {
+	return price * float64(100-rate) / 100
+}
+
+func Test_custom_function_is_used_in_conditions_and_mappings(t *testing.T) {
+	// setup
+	bpmnEngine := New(WithExpressionFunction("discount", discount), WithExpressionContext("threshold", 50))
+	cp := CallPath{}
+	var discountedPrice interface{}
+
+	// given
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-custom-function.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Id("task-a").Handler(func(job ActivatedJob) {
+		discountedPrice = job.Variable("discountedPrice")
+		cp.TaskHandler(job)
+	})
+	bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": 80, "rate": 25})
+	then.AssertThat(t, err, is.Nil())
+
+	// then
+	then.AssertThat(t, cp.CallPath, is.EqualTo("task-a"))
+	then.AssertThat(t, discountedPrice, is.EqualTo(60))
+}
+
+func Test_variables_shadow_the_expression_context(t *testing.T) {
+	// setup
+	bpmnEngine := New(WithExpressionFunction("discount", discount), WithExpressionContext("threshold", 50))
+	cp := CallPath{}
+
+	// given
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-custom-function.bpmn")
+	bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+
+	// when
+	_, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": 80, "rate": 25, "threshold": 70})
+	then.AssertThat(t, err, is.Nil())
+
+	// then
+	then.AssertThat(t, cp.CallPath, is.EqualTo("task-b"))
+}
+
+func Test_custom_function_error_fails_the_evaluation(t *testing.T) {
+	// setup
+	bpmnEngine := New(WithExpressionFunction("discount", func(price float64, rate int) (float64, error) {
+		return 0, errors.New("no discount available")
+	}), WithExpressionContext("threshold", 50))
+
+	// given
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-custom-function.bpmn")
+
+	// when
+	_, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"price": 80, "rate": 25})
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("no discount available"))
+}
+
+func Test_custom_functions_are_available_after_unmarshalling(t *testing.T) {
+	// setup
+	options := []EngineOption{WithExpressionFunction("discount", discount), WithExpressionContext("threshold", 50)}
+	bpmnEngine := New(options...)
+	cp := CallPath{}
+
+	// given
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-custom-function.bpmn")
+	instance, _ := bpmnEngine.CreateInstance(process.ProcessKey, map[string]interface{}{"price": 60, "rate": 25})
+	data := bpmnEngine.Marshal()
+
+	// when
+	bpmnEngine, err := Unmarshal(data, WithEngineOptions(options...))
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Id("task-a").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Id("task-b").Handler(cp.TaskHandler)
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, cp.CallPath, is.EqualTo("task-b"))
+}
+
+func Test_custom_function_arguments_and_results_are_converted(t *testing.T) {
+	// setup
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := New(
+		WithExpressionFunction("join", func(separator string, words ...string) string {
+			return strings.Join(words, separator)
+		}),
+		WithExpressionFunction("sum", func(numbers []int64) int64 {
+			var sum int64
+			for _, n := range numbers {
+				sum += n
+			}
+			return sum
+		}),
+		WithExpressionFunction("later", func(t time.Time, d time.Duration) time.Time {
+			return t.Add(d)
+		}),
+		WithExpressionContext("start", start),
+		WithExpressionContext("limits", map[string]int{"max": 3}),
+	)
+	expressions := newCompiledExpressions(state.expressionEvaluators, "")
+
+	// when
+	joined, err1 := expressions.evaluate("", `join("-", "a", "b", "c")`, nil)
+	sum, err2 := expressions.evaluate("", "sum([1, 2, 3])", nil)
+	later, err3 := expressions.evaluate("", `later(start, duration("PT2H")) = date and time("2024-03-01T14:00:00+00:00")`, nil)
+	limit, err4 := expressions.evaluate("", "limits.max", nil)
+
+	// then
+	then.AssertThat(t, err1, is.Nil())
+	then.AssertThat(t, joined, is.EqualTo("a-b-c"))
+	then.AssertThat(t, err2, is.Nil())
+	then.AssertThat(t, sum, is.EqualTo(6))
+	then.AssertThat(t, err3, is.Nil())
+	then.AssertThat(t, later, is.True())
+	then.AssertThat(t, err4, is.Nil())
+	then.AssertThat(t, limit, is.EqualTo(3))
+}
+
+func Test_custom_function_with_unsupported_signature_panics(t *testing.T) {
+	defer func() {
+		then.AssertThat(t, recover(), is.Not(is.Nil()))
+	}()
+	WithExpressionFunction("noResult", func() {})
+}
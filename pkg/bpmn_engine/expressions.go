@@ -1,48 +1,101 @@
 package bpmn_engine
 
 import (
-	"github.com/pbinitiative/feel"
-	"strings"
+	"fmt"
 
 	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20/extensions"
 )
 
+// compiledExpressions holds the parsed expressions of a process, by their language and source text,
+// so that they don't need to be parsed again on every evaluation
+type compiledExpressions struct {
+	evaluators      map[string]ExpressionEvaluator // by expression language
+	defaultLanguage string                         // of the definitions, used for expressions without a language
+	compiled        map[expressionKey]CompiledExpression
+}
+
+type expressionKey struct {
+	language   string
+	expression string
+}
+
+// newCompiledExpressions uses FEEL as default language, when the definitions declare none, or one without a registered
+// evaluator, especially XPath, the default of the BPMN specification; only expressions declaring an unknown language fail
+func newCompiledExpressions(evaluators map[string]ExpressionEvaluator, defaultLanguage string) compiledExpressions {
+	defaultLanguage = normalizeExpressionLanguage(defaultLanguage)
+	if _, registered := evaluators[defaultLanguage]; !registered {
+		defaultLanguage = ExpressionLanguageFEEL
+	}
+	return compiledExpressions{evaluators: evaluators, defaultLanguage: defaultLanguage, compiled: map[expressionKey]CompiledExpression{}}
+}
+
+// compileExpression parses the expression with the evaluator of the language, or of the default language, when it's empty
+func (ce compiledExpressions) compileExpression(language string, expression string) (expressionKey, CompiledExpression, error) {
+	if language == "" {
+		language = ce.defaultLanguage
+	}
+	language = normalizeExpressionLanguage(language)
+	key := expressionKey{language: language, expression: expression}
+	if compiled, found := ce.compiled[key]; found {
+		return key, compiled, nil
+	}
+	evaluator, found := ce.evaluators[language]
+	if !found {
+		return key, nil, fmt.Errorf("no evaluator registered for expression language %q", language)
+	}
+	compiled, err := evaluator.Compile(expression)
+	return key, compiled, err
+}
+
+// compile parses the expression once, and returns an ExpressionSyntaxError, when it's invalid, or its language is unknown
+func (ce compiledExpressions) compile(elementId string, attribute string, language string, expression string) error {
+	key, compiled, err := ce.compileExpression(language, expression)
+	if err != nil {
+		return &ExpressionSyntaxError{ElementId: elementId, Attribute: attribute, Language: key.language, Expression: expression, Err: err}
+	}
+	ce.compiled[key] = compiled
+	return nil
+}
+
+// evaluateExpression evaluates a FEEL expression, without the engine's custom functions and context values
 func evaluateExpression(expression string, variableContext map[string]interface{}) (interface{}, error) {
-	expression = strings.TrimSpace(expression)
-	expression = strings.TrimPrefix(expression, "=") // FIXME: this is just for convenience, but should be removed
-	res, err := feel.EvalStringWithScope(expression, variableContext)
-	if err == nil {
-		if num, ok := res.(*feel.Number); ok {
-			// TODO: tbc: what about smart conversion to int, in case of integer value?
-			return num.Float64(), nil
-		}
-		if b, ok := res.(bool); ok {
-			return b, nil
-		}
+	compiled, err := feelEvaluator{}.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Evaluate(variableContext)
+}
+
+// evaluate uses the compiled expression, or parses expressions, which weren't compiled in advance;
+// an empty language means the default language of the process
+func (ce compiledExpressions) evaluate(language string, expression string, variableContext map[string]interface{}) (interface{}, error) {
+	_, compiled, err := ce.compileExpression(language, expression)
+	if err != nil {
+		return nil, err
 	}
-	return res, err
+	return compiled.Evaluate(variableContext)
 }
 
-func evaluateLocalVariables(varHolder *VariableHolder, mappings []extensions.TIoMapping) error {
-	return mapVariables(varHolder, mappings, func(key string, value interface{}) {
+func evaluateLocalVariables(expressions compiledExpressions, varHolder *VariableHolder, mappings []extensions.TIoMapping) error {
+	return mapVariables(expressions, varHolder, mappings, func(key string, value interface{}) {
 		varHolder.SetVariable(key, value)
 	})
 }
 
-func propagateProcessInstanceVariables(varHolder *VariableHolder, mappings []extensions.TIoMapping) error {
+func propagateProcessInstanceVariables(expressions compiledExpressions, varHolder *VariableHolder, mappings []extensions.TIoMapping) error {
 	if len(mappings) == 0 {
 		for k, v := range varHolder.Variables() {
 			varHolder.PropagateVariable(k, v)
 		}
 	}
-	return mapVariables(varHolder, mappings, func(key string, value interface{}) {
+	return mapVariables(expressions, varHolder, mappings, func(key string, value interface{}) {
 		varHolder.PropagateVariable(key, value)
 	})
 }
 
-func mapVariables(varHolder *VariableHolder, mappings []extensions.TIoMapping, setVarFunc func(key string, value interface{})) error {
+func mapVariables(expressions compiledExpressions, varHolder *VariableHolder, mappings []extensions.TIoMapping, setVarFunc func(key string, value interface{})) error {
 	for _, mapping := range mappings {
-		evalResult, err := evaluateExpression(mapping.Source, varHolder.Variables())
+		evalResult, err := expressions.evaluate("", mapping.Source, varHolder.Variables())
 		if err != nil {
 			return err
 		}
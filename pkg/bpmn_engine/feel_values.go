@@ -0,0 +1,210 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/pbinitiative/feel"
+)
+
+// The conversion between Go and FEEL values is applied to all variables, before an expression is evaluated,
+// and to all results of expressions, before they're stored as variables.
+// Since FEEL has only one number type, the Go type of a number can't be preserved by FEEL:
+// numbers without fraction become int, all others float64 (see fromFeelValue).
+
+// feelValues converts the values of a single evaluation. FEEL durations have whole seconds only,
+// so it remembers the durations, which were converted into FEEL durations, to restore them exactly,
+// when they're passed through the expression unchanged. The zero value converts like toFeelValue and fromFeelValue.
+type feelValues struct {
+	durations map[*feel.FEELDuration]time.Duration
+}
+
+// toFeelScope converts all variables into values, the FEEL interpreter can work with (see toFeelValue)
+func (fv *feelValues) toFeelScope(variables map[string]interface{}) map[string]interface{} {
+	scope := make(map[string]interface{}, len(variables))
+	for key, value := range variables {
+		scope[key] = fv.toFeelValue(value)
+	}
+	return scope
+}
+
+// toFeelValue converts a Go value into a value, the FEEL interpreter can work with:
+// all numbers become *feel.Number, time.Time becomes a date and time, time.Duration a days and time duration,
+// and slices and maps with string keys become FEEL lists and contexts. Other values, e.g. structs, are passed as they are.
+func toFeelValue(value interface{}) interface{} {
+	return (&feelValues{}).toFeelValue(value)
+}
+
+func (fv *feelValues) toFeelValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return newFeelDatetime(v)
+	case time.Duration:
+		d := feel.NewFEELDuration(v)
+		if v < 0 {
+			d = feel.NewFEELDuration(-v).Negative()
+		}
+		if v%time.Second != 0 {
+			if fv.durations == nil {
+				fv.durations = map[*feel.FEELDuration]time.Duration{}
+			}
+			fv.durations[d] = v
+		}
+		return d
+	case []byte:
+		return v
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return feel.NewNumberFromInt64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return feel.NewNumberFromFloat(float64(rv.Uint()))
+		}
+		return feel.NewNumberFromInt64(int64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return feel.NewNumberFromFloat(rv.Float())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = fv.toFeelValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		context := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			context[key.String()] = fv.toFeelValue(rv.MapIndex(key).Interface())
+		}
+		return context
+	}
+	return value
+}
+
+// fromFeelValue converts a FEEL value into a plain Go value:
+// numbers without fraction become int, all other numbers float64, date and time values time.Time,
+// days and time durations time.Duration, years and months durations an ISO 8601 string (e.g. "P1Y2M"),
+// and lists and contexts []interface{} and map[string]interface{}. Other values are returned as they are.
+func fromFeelValue(value interface{}) interface{} {
+	return (&feelValues{}).fromFeelValue(value)
+}
+
+func (fv *feelValues) fromFeelValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *feel.NullValue:
+		return nil
+	case *feel.Number:
+		if i := v.Int64(); i >= math.MinInt && i <= math.MaxInt && v.Cmp(feel.NewNumberFromInt64(i)) == 0 {
+			return int(i)
+		}
+		return v.Float64()
+	case *feel.FEELDatetime:
+		return v.Time()
+	case *feel.FEELDate:
+		return v.Date()
+	case *feel.FEELTime:
+		return v.Time()
+	case *feel.FEELDuration:
+		if v.Years != 0 || v.Months != 0 {
+			return v.String()
+		}
+		if d, ok := fv.durations[v]; ok {
+			return d
+		}
+		return v.Duration()
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, element := range v {
+			list[i] = fv.fromFeelValue(element)
+		}
+		return list
+	case map[string]interface{}:
+		return fv.fromFeelContext(v)
+	case feel.Scope:
+		// context variables are evaluated as the scope, they were pushed as
+		return fv.fromFeelContext(v)
+	}
+	return value
+}
+
+func (fv *feelValues) fromFeelContext(v map[string]interface{}) map[string]interface{} {
+	context := make(map[string]interface{}, len(v))
+	for key, element := range v {
+		context[key] = fv.fromFeelValue(element)
+	}
+	return context
+}
+
+// newFeelDatetime creates a FEEL date and time with the time's nanoseconds and location;
+// the FEEL library only creates date and times by parsing strings, which keep neither of them
+func newFeelDatetime(t time.Time) *feel.FEELDatetime {
+	dt := &feel.FEELDatetime{}
+	field := reflect.ValueOf(dt).Elem().Field(0)
+	if field.Type() != reflect.TypeOf(t) {
+		// the FEEL library changed its representation, so the time is parsed, which keeps at least the offset
+		parsed, _ := feel.ParseDatetime(t.Format("2006-01-02T15:04:05.999999999-07:00"))
+		return parsed
+	}
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(t))
+	return dt
+}
+
+// fromFeelValueTo converts a FEEL value into a Go value of the given type,
+// or returns an error, when the types don't match
+func fromFeelValueTo(value interface{}, target reflect.Type) (reflect.Value, error) {
+	value = fromFeelValue(value)
+	if value == nil {
+		return reflect.Zero(target), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Type().AssignableTo(target):
+		return rv, nil
+	case isNumberKind(rv.Kind()) && isNumberKind(target.Kind()):
+		return rv.Convert(target), nil
+	case rv.Kind() == reflect.Slice && target.Kind() == reflect.Slice:
+		result := reflect.MakeSlice(target, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			element, err := fromFeelValueTo(rv.Index(i).Interface(), target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(element)
+		}
+		return result, nil
+	case rv.Kind() == reflect.Map && target.Kind() == reflect.Map && target.Key().Kind() == reflect.String:
+		result := reflect.MakeMapWithSize(target, rv.Len())
+		for _, key := range rv.MapKeys() {
+			element, err := fromFeelValueTo(rv.MapIndex(key).Interface(), target.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetMapIndex(key.Convert(target.Key()), element)
+		}
+		return result, nil
+	case rv.Kind() == target.Kind() && rv.Type().ConvertibleTo(target):
+		return rv.Convert(target), nil
+	}
+	return reflect.Value{}, fmt.Errorf("can't convert FEEL value of type %T to %s", value, target)
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
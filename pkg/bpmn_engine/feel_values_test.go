@@ -0,0 +1,131 @@
+package bpmn_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_expression_results_are_converted_to_go_types(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{"1 + 1", 2},
+		{"0.5 + 1", 1.5},
+		{"1.5 * 2", 3}, // FEEL has only one number type, so the result has no fraction
+		{`"text"`, "text"},
+		{"null", nil},
+		{`date and time("2024-03-01T12:30:00+02:00")`, time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("", 2*60*60))},
+		{`date("2024-03-01")`, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{`duration("PT1H30M")`, 90 * time.Minute},
+		{`duration("P1Y2M")`, "P1Y2M"},
+		{`[1, 2.5, "three"]`, []interface{}{1, 2.5, "three"}},
+		{`{"a": 1, "b": [true]}`, map[string]interface{}{"a": 1, "b": []interface{}{true}}},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			// when
+			result, err := evaluateExpression(test.expression, nil)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			if expectedTime, ok := test.expected.(time.Time); ok {
+				then.AssertThat(t, result.(time.Time).Equal(expectedTime), is.True())
+				return
+			}
+			then.AssertThat(t, result, is.EqualTo(test.expected))
+		})
+	}
+}
+
+func Test_variables_are_converted_to_feel_types(t *testing.T) {
+	// setup
+	variables := map[string]interface{}{
+		"count":    int32(3),
+		"price":    float32(2.5),
+		"start":    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		"timeout":  2 * time.Hour,
+		"items":    []int{1, 2, 3},
+		"customer": map[string]int{"age": 42},
+	}
+	tests := []string{
+		"count * price = 7.5",
+		`start < date and time("2024-03-01T13:00:00+00:00")`,
+		`start + timeout = date and time("2024-03-01T14:00:00+00:00")`,
+		"timeout.hours = 2",
+		"sum(items) = 6",
+		"customer.age = 42",
+	}
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			// when
+			result, err := evaluateExpression(expression, variables)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, result, is.EqualTo(true))
+		})
+	}
+}
+
+func Test_time_and_duration_variables_survive_a_mapping(t *testing.T) {
+	// setup
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	variables := map[string]interface{}{"start": start, "timeout": -90 * time.Second}
+
+	// when
+	mappedStart, err1 := evaluateExpression("start", variables)
+	mappedTimeout, err2 := evaluateExpression("timeout", variables)
+
+	// then
+	then.AssertThat(t, err1, is.Nil())
+	then.AssertThat(t, mappedStart.(time.Time).Equal(start), is.True())
+	then.AssertThat(t, err2, is.Nil())
+	then.AssertThat(t, mappedTimeout, is.EqualTo(-90*time.Second))
+}
+
+func Test_time_and_duration_variables_keep_their_precision_through_a_mapping(t *testing.T) {
+	// setup
+	location := time.FixedZone("CET", 60*60)
+	start := time.Date(2024, 1, 2, 3, 4, 5, 123456789, location)
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"time with nanoseconds", time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)},
+		{"time with location", start},
+		{"milliseconds", 1500 * time.Millisecond},
+		{"negative milliseconds", -1500 * time.Millisecond},
+		{"nanoseconds", 2*time.Hour + time.Nanosecond},
+		{"list", []interface{}{start, 250 * time.Millisecond}},
+		{"context", map[string]interface{}{"start": start, "timeout": 250 * time.Millisecond}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			result, err := evaluateExpression("value", map[string]interface{}{"value": test.value})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, result, is.EqualTo(test.value))
+		})
+	}
+}
+
+func Test_time_with_nanoseconds_is_compared_exactly(t *testing.T) {
+	// setup
+	variables := map[string]interface{}{
+		"start": time.Date(2024, 1, 2, 3, 4, 5, 1, time.UTC),
+		"end":   time.Date(2024, 1, 2, 3, 4, 5, 2, time.UTC),
+	}
+
+	// when
+	result, err := evaluateExpression("start < end", variables)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, result, is.EqualTo(true))
+}
@@ -0,0 +1,277 @@
+package bpmn_engine
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// History records which elements the process instances passed through, and how the variables changed.
+// It's disabled by default, see EnableHistory. The history is kept in memory only and is not marshalled.
+type History struct {
+	retention HistoryRetention
+	// elementInstances are in the order they started, endedElementInstances in the order they ended;
+	// both may contain records, which were removed by the retention already, until they're trimmed (see isRecorded)
+	elementInstances      []*ElementInstanceRecord
+	endedElementInstances []*ElementInstanceRecord
+	elementIndex          map[int64]*ElementInstanceRecord
+	variableChanges       []VariableChangeRecord
+	lastVariables         map[int64]map[string]interface{} // per active process instance key, to detect changes
+	now                   func() time.Time
+}
+
+// HistoryRetention limits the amount of records kept in the History; zero values mean unlimited
+type HistoryRetention struct {
+	// MaxAge removes element instances which ended, and variable changes which happened, longer ago
+	MaxAge time.Duration
+	// MaxRecords keeps only the latest element instances and the latest variable changes, each
+	MaxRecords int
+}
+
+// ElementInstanceRecord is the history of a single element instance, e.g. a service task's job
+type ElementInstanceRecord struct {
+	ProcessInstanceKey int64
+	ElementInstanceKey int64
+	ElementId          string
+	ElementType        BPMN20.ElementType
+	State              ActivityState
+	StartedAt          time.Time
+	EndedAt            time.Time              // zero, as long as the element instance is not ended
+	Variables          map[string]interface{} // snapshot of the process instance's variables, when the element was last handled; must not be modified
+	Error              string                 // the handler's fail reason or the error, which made the element fail
+}
+
+// Duration from start to end; zero, as long as the element instance is not ended
+func (r ElementInstanceRecord) Duration() time.Duration {
+	if r.EndedAt.IsZero() {
+		return 0
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// VariableChangeRecord is a single change of a process instance's variable
+type VariableChangeRecord struct {
+	ProcessInstanceKey int64
+	ElementInstanceKey int64  // zero, when the variable was set on creation of the process instance
+	ElementId          string // empty, when the variable was set on creation of the process instance
+	Name               string
+	OldValue           interface{} // nil, when the variable was created
+	NewValue           interface{} // nil, when the variable was removed
+	ChangedAt          time.Time
+}
+
+// HistoryQuery filters the history records; zero values match all records
+type HistoryQuery struct {
+	ProcessInstanceKey int64
+	ElementId          string
+	// From and To define the time range [From, To), in which element instances started, or variables changed
+	From time.Time
+	To   time.Time
+}
+
+// EnableHistory starts recording the history of all process instances, with the given retention
+func (state *BpmnEngineState) EnableHistory(retention HistoryRetention) {
+	state.history = &History{
+		retention:     retention,
+		elementIndex:  map[int64]*ElementInstanceRecord{},
+		lastVariables: map[int64]map[string]interface{}{},
+		now:           time.Now,
+	}
+}
+
+// History returns the recorded history, or nil when it's not enabled (see EnableHistory)
+func (state *BpmnEngineState) History() *History {
+	return state.history
+}
+
+// FindElementInstances returns all matching element instance records, in the order they started
+func (h *History) FindElementInstances(query HistoryQuery) (result []ElementInstanceRecord) {
+	if h == nil {
+		return nil
+	}
+	for _, record := range h.elementInstances {
+		if h.isRecorded(record) && query.matches(record.ProcessInstanceKey, record.ElementId, record.StartedAt) {
+			result = append(result, *record)
+		}
+	}
+	return result
+}
+
+// FindVariableChanges returns all matching variable change records, in the order they happened
+func (h *History) FindVariableChanges(query HistoryQuery) (result []VariableChangeRecord) {
+	if h == nil {
+		return nil
+	}
+	for _, record := range h.variableChanges {
+		if query.matches(record.ProcessInstanceKey, record.ElementId, record.ChangedAt) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// currentTime returns the zero time, when the history is not enabled
+func (h *History) currentTime() time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return h.now()
+}
+
+func (q HistoryQuery) matches(processInstanceKey int64, elementId string, t time.Time) bool {
+	return (q.ProcessInstanceKey == 0 || q.ProcessInstanceKey == processInstanceKey) &&
+		(q.ElementId == "" || q.ElementId == elementId) &&
+		(q.From.IsZero() || !t.Before(q.From)) &&
+		(q.To.IsZero() || t.Before(q.To))
+}
+
+// recordInstanceCreated records the initial variables as changes
+func (h *History) recordInstanceCreated(instance *processInstanceInfo) {
+	if h == nil {
+		return
+	}
+	h.recordVariableChanges(instance, 0, "")
+	h.applyRetention()
+}
+
+// recordElementInstance creates or updates the record of the element instance, with the given key
+func (h *History) recordElementInstance(instance *processInstanceInfo, key int64, element BPMN20.BaseElement, activityState ActivityState, ended bool, startedAt time.Time, err string) {
+	if h == nil {
+		return
+	}
+	record, found := h.elementIndex[key]
+	if !found {
+		record = &ElementInstanceRecord{
+			ProcessInstanceKey: instance.InstanceKey,
+			ElementInstanceKey: key,
+			ElementId:          element.GetId(),
+			ElementType:        element.GetType(),
+			StartedAt:          startedAt,
+		}
+		h.elementInstances = append(h.elementInstances, record)
+		h.elementIndex[key] = record
+	}
+	record.State = activityState
+	if len(err) > 0 {
+		record.Error = err
+	}
+	if ended && record.EndedAt.IsZero() {
+		record.EndedAt = h.now()
+		if h.retention.MaxAge > 0 {
+			h.endedElementInstances = append(h.endedElementInstances, record)
+		}
+	}
+	h.recordVariableChanges(instance, key, element.GetId())
+	// snapshots are shared between records, a new one is only taken, when the variables changed
+	record.Variables = h.lastVariables[instance.InstanceKey]
+	h.applyRetention()
+}
+
+// recordInstanceCompleted forgets the last known variables of the process instance, which can't change anymore
+func (h *History) recordInstanceCompleted(instance *processInstanceInfo) {
+	if h == nil {
+		return
+	}
+	delete(h.lastVariables, instance.InstanceKey)
+}
+
+// recordVariableChanges compares the current variables with the last known ones
+func (h *History) recordVariableChanges(instance *processInstanceInfo, elementInstanceKey int64, elementId string) {
+	current := instance.VariableHolder.Variables()
+	last := h.lastVariables[instance.InstanceKey]
+	changedAt := h.now()
+	appendChange := func(name string, oldValue interface{}, newValue interface{}) {
+		h.variableChanges = append(h.variableChanges, VariableChangeRecord{
+			ProcessInstanceKey: instance.InstanceKey,
+			ElementInstanceKey: elementInstanceKey,
+			ElementId:          elementId,
+			Name:               name,
+			OldValue:           oldValue,
+			NewValue:           newValue,
+			ChangedAt:          changedAt,
+		})
+	}
+	changes := len(h.variableChanges)
+	for _, name := range sortedKeys(current) {
+		oldValue, existed := last[name]
+		if !existed || !reflect.DeepEqual(oldValue, current[name]) {
+			appendChange(name, oldValue, current[name])
+		}
+	}
+	for _, name := range sortedKeys(last) {
+		if _, exists := current[name]; !exists {
+			appendChange(name, last[name], nil)
+		}
+	}
+	if _, known := h.lastVariables[instance.InstanceKey]; !known || len(h.variableChanges) > changes {
+		h.lastVariables[instance.InstanceKey] = copyVariables(current)
+	}
+}
+
+// applyRetention removes the oldest records from the front, so each record is visited only once when it's removed
+func (h *History) applyRetention() {
+	if h.retention.MaxAge > 0 {
+		deadline := h.now().Add(-h.retention.MaxAge)
+		for len(h.endedElementInstances) > 0 && h.endedElementInstances[0].EndedAt.Before(deadline) {
+			delete(h.elementIndex, h.endedElementInstances[0].ElementInstanceKey)
+			h.endedElementInstances = h.endedElementInstances[1:]
+		}
+		for len(h.variableChanges) > 0 && h.variableChanges[0].ChangedAt.Before(deadline) {
+			h.variableChanges = h.variableChanges[1:]
+		}
+	}
+	if h.retention.MaxRecords > 0 {
+		for len(h.elementIndex) > h.retention.MaxRecords {
+			delete(h.elementIndex, h.elementInstances[0].ElementInstanceKey)
+			h.elementInstances = h.elementInstances[1:]
+		}
+		if excess := len(h.variableChanges) - h.retention.MaxRecords; excess > 0 {
+			h.variableChanges = h.variableChanges[excess:]
+		}
+	}
+	h.trimRemovedElementInstances()
+}
+
+// trimRemovedElementInstances drops removed records from the front of both slices,
+// and compacts the slice of started ones, when it consists of removed records mostly
+func (h *History) trimRemovedElementInstances() {
+	for len(h.elementInstances) > 0 && !h.isRecorded(h.elementInstances[0]) {
+		h.elementInstances = h.elementInstances[1:]
+	}
+	for len(h.endedElementInstances) > 0 && !h.isRecorded(h.endedElementInstances[0]) {
+		h.endedElementInstances = h.endedElementInstances[1:]
+	}
+	if len(h.elementInstances) > 2*len(h.elementIndex) {
+		kept := make([]*ElementInstanceRecord, 0, len(h.elementIndex))
+		for _, record := range h.elementInstances {
+			if h.isRecorded(record) {
+				kept = append(kept, record)
+			}
+		}
+		h.elementInstances = kept
+	}
+}
+
+// isRecorded returns false, when the record was removed by the retention
+func (h *History) isRecorded(record *ElementInstanceRecord) bool {
+	return h.elementIndex[record.ElementInstanceKey] == record
+}
+
+func sortedKeys(variables map[string]interface{}) []string {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func copyVariables(variables map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,237 @@
+package bpmn_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// fakeClock returns a time, which advances by one second on every call
+func fakeClock(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func Test_history_is_disabled_by_default(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+
+	// when
+	_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, bpmnEngine.History(), is.Nil())
+	then.AssertThat(t, bpmnEngine.History().FindElementInstances(HistoryQuery{}), has.Length(0))
+}
+
+func Test_history_records_all_element_instances(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.SetVariable("variable_name", "done")
+		job.Complete()
+	})
+
+	// when
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"variable_name": "new"})
+
+	// then
+	records := bpmnEngine.History().FindElementInstances(HistoryQuery{ProcessInstanceKey: instance.InstanceKey})
+	then.AssertThat(t, records, has.Length(3))
+	then.AssertThat(t, records[0].ElementId, is.EqualTo("StartEvent_1"))
+	then.AssertThat(t, records[1].ElementId, is.EqualTo("id"))
+	then.AssertThat(t, records[1].ElementType, is.EqualTo(BPMN20.ServiceTask))
+	then.AssertThat(t, records[1].State, is.EqualTo(Completed))
+	then.AssertThat(t, records[1].Variables["variable_name"], is.EqualTo("done"))
+	then.AssertThat(t, records[2].ElementId, is.EqualTo("Event_1j4mcqg"))
+	for _, record := range records {
+		then.AssertThat(t, record.EndedAt.IsZero(), is.False())
+	}
+}
+
+func Test_history_records_element_instance_until_job_is_completed(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	bpmnEngine.history.now = fakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	query := HistoryQuery{ProcessInstanceKey: instance.InstanceKey, ElementId: "id"}
+	activeRecord := bpmnEngine.History().FindElementInstances(query)[0]
+
+	// when
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+	_, _ = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, activeRecord.State, is.EqualTo(Active))
+	then.AssertThat(t, activeRecord.Duration(), is.EqualTo(time.Duration(0)))
+	records := bpmnEngine.History().FindElementInstances(query)
+	then.AssertThat(t, records, has.Length(1))
+	then.AssertThat(t, records[0].ElementInstanceKey, is.EqualTo(activeRecord.ElementInstanceKey))
+	then.AssertThat(t, records[0].State, is.EqualTo(Completed))
+	then.AssertThat(t, records[0].StartedAt, is.EqualTo(activeRecord.StartedAt))
+	then.AssertThat(t, records[0].Duration() > 0, is.True())
+}
+
+func Test_history_records_handler_errors(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Fail("service unavailable")
+	})
+
+	// when
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	records := bpmnEngine.History().FindElementInstances(HistoryQuery{ProcessInstanceKey: instance.InstanceKey, ElementId: "id"})
+	then.AssertThat(t, records, has.Length(1))
+	then.AssertThat(t, records[0].State, is.EqualTo(Failed))
+	then.AssertThat(t, records[0].Error, is.EqualTo("service unavailable"))
+}
+
+func Test_history_records_variable_changes(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.SetVariable("variable_name", "done")
+		job.Complete()
+	})
+
+	// when
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"variable_name": "new"})
+
+	// then
+	changes := bpmnEngine.History().FindVariableChanges(HistoryQuery{ProcessInstanceKey: instance.InstanceKey})
+	then.AssertThat(t, changes, has.Length(2))
+	then.AssertThat(t, changes[0].ElementId, is.EqualTo(""))
+	then.AssertThat(t, changes[0].OldValue, is.Nil())
+	then.AssertThat(t, changes[0].NewValue, is.EqualTo("new"))
+	then.AssertThat(t, changes[1].ElementId, is.EqualTo("id"))
+	then.AssertThat(t, changes[1].Name, is.EqualTo("variable_name"))
+	then.AssertThat(t, changes[1].OldValue, is.EqualTo("new"))
+	then.AssertThat(t, changes[1].NewValue, is.EqualTo("done"))
+}
+
+func Test_history_query_by_time_range(t *testing.T) {
+	// setup
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	bpmnEngine.history.now = fakeClock(start)
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	first, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	between := bpmnEngine.history.now()
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// when
+	before := bpmnEngine.History().FindElementInstances(HistoryQuery{To: between})
+	after := bpmnEngine.History().FindElementInstances(HistoryQuery{From: between})
+
+	// then
+	then.AssertThat(t, before, has.Length(2))
+	then.AssertThat(t, before[0].ProcessInstanceKey, is.EqualTo(first.InstanceKey))
+	then.AssertThat(t, after, has.Length(2))
+	then.AssertThat(t, after[0].ProcessInstanceKey, is.EqualTo(second.InstanceKey))
+}
+
+func Test_history_retention_removes_old_records(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{MaxAge: 10 * time.Second})
+	bpmnEngine.history.now = fakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	first, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"a": 1})
+	for i := 0; i < 10; i++ {
+		bpmnEngine.history.now()
+	}
+
+	// when
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"a": 1})
+
+	// then
+	// the job is still active, so it is kept
+	then.AssertThat(t, bpmnEngine.History().FindElementInstances(HistoryQuery{ProcessInstanceKey: first.InstanceKey}), has.Length(1))
+	then.AssertThat(t, bpmnEngine.History().FindVariableChanges(HistoryQuery{ProcessInstanceKey: first.InstanceKey}), has.Length(0))
+	then.AssertThat(t, bpmnEngine.History().FindElementInstances(HistoryQuery{ProcessInstanceKey: second.InstanceKey}), has.Length(2))
+}
+
+func Test_history_retention_keeps_max_records(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{MaxRecords: 3})
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	records := bpmnEngine.History().FindElementInstances(HistoryQuery{})
+	then.AssertThat(t, records, has.Length(3))
+	for _, record := range records {
+		then.AssertThat(t, record.ProcessInstanceKey, is.EqualTo(second.InstanceKey))
+	}
+}
+
+func Test_history_retention_bounds_recorded_element_instances(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{MaxAge: 10 * time.Second, MaxRecords: 3})
+	bpmnEngine.history.now = fakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	for i := 0; i < 100; i++ {
+		_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"i": i})
+	}
+
+	// then
+	then.AssertThat(t, bpmnEngine.History().FindElementInstances(HistoryQuery{}), has.Length(3))
+	then.AssertThat(t, len(bpmnEngine.history.elementInstances), is.LessThanOrEqualTo(6))
+	then.AssertThat(t, len(bpmnEngine.history.endedElementInstances), is.LessThanOrEqualTo(10))
+}
+
+func Test_history_forgets_variables_of_completed_instances(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	bpmnEngine.EnableHistory(HistoryRetention{})
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"a": 1})
+
+	// then
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, bpmnEngine.history.lastVariables, has.Length(0))
+	records := bpmnEngine.History().FindElementInstances(HistoryQuery{ProcessInstanceKey: instance.InstanceKey})
+	then.AssertThat(t, records, has.Length(3))
+	then.AssertThat(t, records[0].Variables, is.EqualTo(map[string]interface{}{"a": 1}))
+	then.AssertThat(t, records[2].Variables, is.EqualTo(map[string]interface{}{"a": 1, "variable_name": nil}))
+}
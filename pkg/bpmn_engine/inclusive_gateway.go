@@ -0,0 +1,90 @@
+package bpmn_engine
+
+import (
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// handleInclusiveGateway forwards the token of a diverging inclusive gateway right away. A converging one
+// collects the arriving tokens, and continues once, when tokens arrived on all incoming flows;
+// otherwise, it's joined when the run has no more commands, see joinInclusiveGateways.
+func (state *BpmnEngineState) handleInclusiveGateway(instance *processInstanceInfo, element *BPMN20.BaseElement, originActivity activity) (continueFlow bool, resultActivity activity) {
+	graph := instance.ProcessInfo.graph
+	if _, converging := graph.joinSources[(*element).GetId()]; !converging {
+		return true, &elementActivity{
+			key:     state.generateKey(),
+			state:   Active,
+			element: element,
+		}
+	}
+	resultActivity = instance.findActiveActivityByElementId((*element).GetId())
+	if resultActivity == nil {
+		resultActivity = &gatewayActivity{
+			key:     state.generateKey(),
+			state:   Active,
+			element: element,
+		}
+		instance.appendActivity(resultActivity)
+	}
+	gateway := resultActivity.(*gatewayActivity)
+	if originActivity != nil {
+		if sourceFlow := graph.incomingFlowFrom((*originActivity.Element()).GetId(), (*element).GetId()); sourceFlow != nil {
+			gateway.SetInboundFlowCompleted(sourceFlow.Id)
+		}
+	}
+	continueFlow = gateway.AreInboundFlowsCompleted()
+	if continueFlow {
+		gateway.SetState(Completed)
+	}
+	return continueFlow, gateway
+}
+
+// joinInclusiveGateways continues the first waiting converging inclusive gateway of the scope,
+// at which no other token can arrive anymore. Tokens wait at active jobs, message subscriptions,
+// created timers and other waiting gateways. Only one gateway is joined at a time, because its
+// outgoing token may still arrive at another waiting gateway.
+func (state *BpmnEngineState) joinInclusiveGateways(process BPMN20.ProcessElement, instance *processInstanceInfo) []command {
+	graph := instance.ProcessInfo.graph
+	for _, a := range instance.activities {
+		gateway, ok := a.(*gatewayActivity)
+		if !ok || (*gateway.Element()).GetType() != BPMN20.InclusiveGateway || gateway.State() != Active || graph.scopes[(*gateway.Element()).GetId()].GetId() != process.GetId() {
+			continue
+		}
+		if state.canTokenArriveAt(instance, gateway) {
+			continue
+		}
+		startedAt := state.history.currentTime()
+		gateway.SetState(Completed)
+		state.exportElementEvent(process, *instance, *gateway.Element(), exporter.ElementCompleted)
+		state.recordElementHistory(instance, gateway.Element(), instance, gateway, true, startedAt, nil)
+		return createNextCommands(instance, gateway.Element(), gateway)
+	}
+	return nil
+}
+
+// canTokenArriveAt returns true, when any waiting token of the process instance can still arrive at the gateway
+func (state *BpmnEngineState) canTokenArriveAt(instance *processInstanceInfo, gateway *gatewayActivity) bool {
+	graph := instance.ProcessInfo.graph
+	gatewayId := (*gateway.Element()).GetId()
+	for _, j := range state.findActiveJobsForContinuation(instance) {
+		if graph.canReachJoin(j.ElementId, gatewayId) {
+			return true
+		}
+	}
+	for _, ms := range state.findActiveSubscriptions(instance) {
+		if graph.canReachJoin(ms.ElementId, gatewayId) {
+			return true
+		}
+	}
+	for _, t := range state.findCreatedTimers(instance) {
+		if graph.canReachJoin(t.ElementId, gatewayId) {
+			return true
+		}
+	}
+	for _, a := range instance.activities {
+		if a != activity(gateway) && a.State() == Active && graph.canReachJoin((*a.Element()).GetId(), gatewayId) {
+			return true
+		}
+	}
+	return false
+}
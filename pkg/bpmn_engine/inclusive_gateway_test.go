@@ -0,0 +1,84 @@
+package bpmn_engine
+
+import (
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_converging_inclusive_gateway_continues_once_per_activation(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		callPath  string
+	}{
+		{"a,x", map[string]interface{}{"a": true, "b": false, "skipB": false, "x": true, "y": false}, "task-a,task-x,task-after"},
+		{"b,y", map[string]interface{}{"a": false, "b": true, "skipB": false, "x": false, "y": true}, "task-b,task-y,task-after"},
+		{"a,b,x,y", map[string]interface{}{"a": true, "b": true, "skipB": false, "x": true, "y": true}, "task-a,task-b,task-x,task-y,task-after"},
+		{"a,b skipped,x", map[string]interface{}{"a": true, "b": true, "skipB": true, "x": true, "y": false}, "task-a,task-b,task-x,task-after"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/inclusive-gateway-join.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"task-a", "task-b", "task-x", "task-y", "task-after"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, test.variables)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_converging_inclusive_gateway_waits_for_tokens_which_can_still_arrive(t *testing.T) {
+	tests := []struct {
+		name     string
+		skipB    bool
+		callPath string
+	}{
+		{"arriving", false, "task-a,task-b,task-x,task-after"},
+		{"skipped", true, "task-a,task-b,task-x,task-after"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/inclusive-gateway-join.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			bpmnEngine.NewTaskHandler().Type("task-a").Handler(cp.TaskHandler)
+			variables := map[string]interface{}{"a": true, "b": true, "skipB": test.skipB, "x": true, "y": false}
+
+			// given
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, variables)
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+			then.AssertThat(t, cp.CallPath, is.EqualTo("task-a"))
+			then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "task-after"), has.Length(0))
+			restored, err := Unmarshal(bpmnEngine.Marshal())
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"task-b", "task-x", "task-y", "task-after"} {
+				restored.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			restoredInstance, err := restored.RunOrContinueInstance(instance.InstanceKey)
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, restoredInstance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package bpmn_engine
+
+// instanceRecord is a record, which belongs to an element of a process instance, e.g. a job
+type instanceRecord interface {
+	recordInstanceKey() int64
+	recordElementId() string
+}
+
+type elementRecordKey struct {
+	processInstanceKey int64
+	elementId          string
+}
+
+// instanceRecords keeps records in the order of their creation, and indexes them by process instance and element,
+// so that finding the records of one process instance doesn't depend on the total number of records in the engine.
+// The records of removed instances are removed from all and byElementId lazily, see compact.
+type instanceRecords[T instanceRecord] struct {
+	all         []T
+	byInstance  map[int64][]T
+	byElement   map[elementRecordKey][]T
+	byElementId map[string][]T // across all process instances
+	// removed are the keys of removed process instances, whose records are still in all and byElementId
+	removed        map[int64]bool
+	removedRecords int
+}
+
+func (r *instanceRecords[T]) add(record T) {
+	if r.byInstance == nil {
+		r.byInstance = map[int64][]T{}
+		r.byElement = map[elementRecordKey][]T{}
+		r.byElementId = map[string][]T{}
+	}
+	instanceKey := record.recordInstanceKey()
+	elementKey := elementRecordKey{processInstanceKey: instanceKey, elementId: record.recordElementId()}
+	r.all = append(r.all, record)
+	r.byInstance[instanceKey] = append(r.byInstance[instanceKey], record)
+	r.byElement[elementKey] = append(r.byElement[elementKey], record)
+	r.byElementId[elementKey.elementId] = append(r.byElementId[elementKey.elementId], record)
+}
+
+// records returns all records, in the order of their creation
+func (r *instanceRecords[T]) records() []T {
+	r.compact()
+	return r.all
+}
+
+// ofInstance returns the records of the given process instance, in the order of their creation
+func (r *instanceRecords[T]) ofInstance(processInstanceKey int64) []T {
+	return r.byInstance[processInstanceKey]
+}
+
+// ofElement returns the records of the given element within the process instance, in the order of their creation
+func (r *instanceRecords[T]) ofElement(processInstanceKey int64, elementId string) []T {
+	return r.byElement[elementRecordKey{processInstanceKey: processInstanceKey, elementId: elementId}]
+}
+
+// withElementId returns the records of the given element in all process instances, in the order of their creation
+func (r *instanceRecords[T]) withElementId(elementId string) []T {
+	records := r.byElementId[elementId]
+	if r.removedRecords == 0 {
+		return records
+	}
+	var kept []T
+	for _, record := range records {
+		if !r.removed[record.recordInstanceKey()] {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// removeInstances removes all records of the given process instances from the indexes by instance and element right away;
+// all and byElementId are compacted, when the records of removed instances are at least half of all records
+func (r *instanceRecords[T]) removeInstances(processInstanceKeys map[int64]bool) {
+	for key := range processInstanceKeys {
+		records, found := r.byInstance[key]
+		if !found {
+			continue
+		}
+		for _, record := range records {
+			delete(r.byElement, elementRecordKey{processInstanceKey: key, elementId: record.recordElementId()})
+		}
+		delete(r.byInstance, key)
+		if r.removed == nil {
+			r.removed = map[int64]bool{}
+		}
+		r.removed[key] = true
+		r.removedRecords += len(records)
+	}
+	if r.removedRecords*2 >= len(r.all) {
+		r.compact()
+	}
+}
+
+// compact removes the records of removed instances from all and byElementId
+func (r *instanceRecords[T]) compact() {
+	if r.removedRecords == 0 {
+		return
+	}
+	var kept []T
+	byElementId := map[string][]T{}
+	for _, record := range r.all {
+		if !r.removed[record.recordInstanceKey()] {
+			kept = append(kept, record)
+			byElementId[record.recordElementId()] = append(byElementId[record.recordElementId()], record)
+		}
+	}
+	r.all = kept
+	r.byElementId = byElementId
+	r.removed = nil
+	r.removedRecords = 0
+}
+
+func (j *job) recordInstanceKey() int64 { return j.ProcessInstanceKey }
+func (j *job) recordElementId() string  { return j.ElementId }
+
+func (t *Timer) recordInstanceKey() int64 { return t.ProcessInstanceKey }
+func (t *Timer) recordElementId() string  { return t.ElementId }
+
+func (m *MessageSubscription) recordInstanceKey() int64 { return m.ProcessInstanceKey }
+func (m *MessageSubscription) recordElementId() string  { return m.ElementId }
@@ -0,0 +1,126 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_instance_records_are_found_by_instance_and_element(t *testing.T) {
+	// setup
+	records := instanceRecords[*job]{}
+	first := &job{ProcessInstanceKey: 1, ElementId: "a"}
+	second := &job{ProcessInstanceKey: 2, ElementId: "a"}
+	third := &job{ProcessInstanceKey: 1, ElementId: "b"}
+
+	// when
+	records.add(first)
+	records.add(second)
+	records.add(third)
+
+	// then
+	then.AssertThat(t, records.all, is.EqualTo([]*job{first, second, third}))
+	then.AssertThat(t, records.ofInstance(1), is.EqualTo([]*job{first, third}))
+	then.AssertThat(t, records.ofElement(1, "a"), is.EqualTo([]*job{first}))
+	then.AssertThat(t, records.ofElement(3, "a"), has.Length(0))
+	then.AssertThat(t, records.withElementId("a"), is.EqualTo([]*job{first, second}))
+}
+
+func Test_instance_records_of_removed_instances_are_not_found(t *testing.T) {
+	// setup
+	records := instanceRecords[*job]{}
+	removed := &job{ProcessInstanceKey: 1, ElementId: "a"}
+	kept := &job{ProcessInstanceKey: 2, ElementId: "a"}
+	records.add(removed)
+	records.add(kept)
+
+	// when
+	records.removeInstances(map[int64]bool{1: true})
+
+	// then
+	then.AssertThat(t, records.all, is.EqualTo([]*job{kept}))
+	then.AssertThat(t, records.ofInstance(1), has.Length(0))
+	then.AssertThat(t, records.ofElement(1, "a"), has.Length(0))
+	then.AssertThat(t, records.ofElement(2, "a"), is.EqualTo([]*job{kept}))
+	then.AssertThat(t, records.withElementId("a"), is.EqualTo([]*job{kept}))
+}
+
+func Test_instance_records_are_compacted_when_half_of_them_are_removed(t *testing.T) {
+	// setup
+	records := instanceRecords[*job]{}
+	for key := int64(1); key <= 4; key++ {
+		records.add(&job{ProcessInstanceKey: key, ElementId: "a"})
+	}
+
+	// when
+	records.removeInstances(map[int64]bool{1: true})
+
+	// then
+	then.AssertThat(t, records.all, has.Length(4))
+	then.AssertThat(t, records.withElementId("a"), has.Length(3))
+
+	// when
+	records.removeInstances(map[int64]bool{2: true})
+
+	// then
+	then.AssertThat(t, records.all, has.Length(2))
+	then.AssertThat(t, records.records(), is.EqualTo(records.withElementId("a")))
+}
+
+func Test_unmarshalled_state_is_indexed(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey), is.Not(is.Nil()))
+		then.AssertThat(t, restored.jobs.ofElement(instance.InstanceKey, "id"), has.Length(1))
+	})
+}
+
+// newEngineWithActiveInstances creates instances, which all wait for the job of the service task to be completed
+func newEngineWithActiveInstances(b *testing.B, count int) (BpmnEngineState, []*processInstanceInfo) {
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	then.AssertThat(b, err, is.Nil())
+	instances := make([]*processInstanceInfo, count)
+	for i := range instances {
+		instances[i], err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+		then.AssertThat(b, err, is.Nil())
+	}
+	return bpmnEngine, instances
+}
+
+// Benchmark_RunOrContinueInstance shows, that continuing a single instance doesn't depend on the total number of instances
+func Benchmark_RunOrContinueInstance(b *testing.B) {
+	for _, count := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("instances=%d", count), func(b *testing.B) {
+			bpmnEngine, instances := newEngineWithActiveInstances(b, count)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = bpmnEngine.RunOrContinueInstance(instances[i%count].InstanceKey)
+			}
+		})
+	}
+}
+
+func Benchmark_PublishEventForInstance(b *testing.B) {
+	for _, count := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("instances=%d", count), func(b *testing.B) {
+			bpmnEngine, instances := newEngineWithActiveInstances(b, count)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = bpmnEngine.PublishEventForInstance(instances[i%count].InstanceKey, "message", nil)
+			}
+		})
+	}
+}
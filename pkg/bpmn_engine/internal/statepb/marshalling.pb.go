@@ -0,0 +1,1875 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: marshalling.proto
+
+package statepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The version is always the first field, which is used to detect the protobuf encoding when unmarshalling
+type EngineState struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Version              int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Name                 string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Processes            []*ProcessReference    `protobuf:"bytes,3,rep,name=processes,proto3" json:"processes,omitempty"`
+	ProcessInstances     []*ProcessInstance     `protobuf:"bytes,4,rep,name=process_instances,json=processInstances,proto3" json:"process_instances,omitempty"`
+	MessageSubscriptions []*MessageSubscription `protobuf:"bytes,5,rep,name=message_subscriptions,json=messageSubscriptions,proto3" json:"message_subscriptions,omitempty"`
+	Timers               []*Timer               `protobuf:"bytes,6,rep,name=timers,proto3" json:"timers,omitempty"`
+	Jobs                 []*Job                 `protobuf:"bytes,7,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	DecisionResources    []*DecisionResource    `protobuf:"bytes,8,rep,name=decision_resources,json=decisionResources,proto3" json:"decision_resources,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *EngineState) Reset() {
+	*x = EngineState{}
+	mi := &file_marshalling_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EngineState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EngineState) ProtoMessage() {}
+
+func (x *EngineState) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EngineState.ProtoReflect.Descriptor instead.
+func (*EngineState) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EngineState) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *EngineState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EngineState) GetProcesses() []*ProcessReference {
+	if x != nil {
+		return x.Processes
+	}
+	return nil
+}
+
+func (x *EngineState) GetProcessInstances() []*ProcessInstance {
+	if x != nil {
+		return x.ProcessInstances
+	}
+	return nil
+}
+
+func (x *EngineState) GetMessageSubscriptions() []*MessageSubscription {
+	if x != nil {
+		return x.MessageSubscriptions
+	}
+	return nil
+}
+
+func (x *EngineState) GetTimers() []*Timer {
+	if x != nil {
+		return x.Timers
+	}
+	return nil
+}
+
+func (x *EngineState) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *EngineState) GetDecisionResources() []*DecisionResource {
+	if x != nil {
+		return x.DecisionResources
+	}
+	return nil
+}
+
+type ProcessReference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BpmnProcessId string                 `protobuf:"bytes,1,opt,name=bpmn_process_id,json=bpmnProcessId,proto3" json:"bpmn_process_id,omitempty"`
+	ProcessKey    int64                  `protobuf:"varint,2,opt,name=process_key,json=processKey,proto3" json:"process_key,omitempty"`
+	// the BPMN XML, compressed via flate (but not ascii85 encoded, like in JSON)
+	BpmnData         []byte `protobuf:"bytes,3,opt,name=bpmn_data,json=bpmnData,proto3" json:"bpmn_data,omitempty"`
+	BpmnResourceName string `protobuf:"bytes,4,opt,name=bpmn_resource_name,json=bpmnResourceName,proto3" json:"bpmn_resource_name,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ProcessReference) Reset() {
+	*x = ProcessReference{}
+	mi := &file_marshalling_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessReference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessReference) ProtoMessage() {}
+
+func (x *ProcessReference) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessReference.ProtoReflect.Descriptor instead.
+func (*ProcessReference) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ProcessReference) GetBpmnProcessId() string {
+	if x != nil {
+		return x.BpmnProcessId
+	}
+	return ""
+}
+
+func (x *ProcessReference) GetProcessKey() int64 {
+	if x != nil {
+		return x.ProcessKey
+	}
+	return 0
+}
+
+func (x *ProcessReference) GetBpmnData() []byte {
+	if x != nil {
+		return x.BpmnData
+	}
+	return nil
+}
+
+func (x *ProcessReference) GetBpmnResourceName() string {
+	if x != nil {
+		return x.BpmnResourceName
+	}
+	return ""
+}
+
+// A DMN file, which may contain multiple decisions
+type DecisionResource struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the keys of the decisions, in order of the definitions in the DMN XML
+	DecisionKeys []int64 `protobuf:"varint,1,rep,packed,name=decision_keys,json=decisionKeys,proto3" json:"decision_keys,omitempty"`
+	// the DMN XML, compressed via flate (but not ascii85 encoded, like in JSON)
+	DmnData         []byte `protobuf:"bytes,2,opt,name=dmn_data,json=dmnData,proto3" json:"dmn_data,omitempty"`
+	DmnResourceName string `protobuf:"bytes,3,opt,name=dmn_resource_name,json=dmnResourceName,proto3" json:"dmn_resource_name,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DecisionResource) Reset() {
+	*x = DecisionResource{}
+	mi := &file_marshalling_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecisionResource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecisionResource) ProtoMessage() {}
+
+func (x *DecisionResource) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecisionResource.ProtoReflect.Descriptor instead.
+func (*DecisionResource) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DecisionResource) GetDecisionKeys() []int64 {
+	if x != nil {
+		return x.DecisionKeys
+	}
+	return nil
+}
+
+func (x *DecisionResource) GetDmnData() []byte {
+	if x != nil {
+		return x.DmnData
+	}
+	return nil
+}
+
+func (x *DecisionResource) GetDmnResourceName() string {
+	if x != nil {
+		return x.DmnResourceName
+	}
+	return ""
+}
+
+type ProcessInstance struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	ProcessKey            int64                  `protobuf:"varint,1,opt,name=process_key,json=processKey,proto3" json:"process_key,omitempty"`
+	InstanceKey           int64                  `protobuf:"varint,2,opt,name=instance_key,json=instanceKey,proto3" json:"instance_key,omitempty"`
+	VariableHolder        *VariableHolder        `protobuf:"bytes,3,opt,name=variable_holder,json=variableHolder,proto3" json:"variable_holder,omitempty"`
+	CreatedAt             *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	State                 string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	CaughtEvents          []*CatchEvent          `protobuf:"bytes,6,rep,name=caught_events,json=caughtEvents,proto3" json:"caught_events,omitempty"`
+	Activities            []*Activity            `protobuf:"bytes,7,rep,name=activities,proto3" json:"activities,omitempty"`
+	CompletedAt           *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	CompensableActivities []*CompensableActivity `protobuf:"bytes,9,rep,name=compensable_activities,json=compensableActivities,proto3" json:"compensable_activities,omitempty"`
+	// the key of the instance of another process, whose message started this instance
+	MessageSenderKey int64 `protobuf:"varint,10,opt,name=message_sender_key,json=messageSenderKey,proto3" json:"message_sender_key,omitempty"`
+	// the keys of all variables of this instance, which are referenced by VariableEntry.key_ref (since version 4)
+	VariableKeys  []string `protobuf:"bytes,11,rep,name=variable_keys,json=variableKeys,proto3" json:"variable_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessInstance) Reset() {
+	*x = ProcessInstance{}
+	mi := &file_marshalling_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessInstance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessInstance) ProtoMessage() {}
+
+func (x *ProcessInstance) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessInstance.ProtoReflect.Descriptor instead.
+func (*ProcessInstance) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProcessInstance) GetProcessKey() int64 {
+	if x != nil {
+		return x.ProcessKey
+	}
+	return 0
+}
+
+func (x *ProcessInstance) GetInstanceKey() int64 {
+	if x != nil {
+		return x.InstanceKey
+	}
+	return 0
+}
+
+func (x *ProcessInstance) GetVariableHolder() *VariableHolder {
+	if x != nil {
+		return x.VariableHolder
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ProcessInstance) GetCaughtEvents() []*CatchEvent {
+	if x != nil {
+		return x.CaughtEvents
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetActivities() []*Activity {
+	if x != nil {
+		return x.Activities
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetCompensableActivities() []*CompensableActivity {
+	if x != nil {
+		return x.CompensableActivities
+	}
+	return nil
+}
+
+func (x *ProcessInstance) GetMessageSenderKey() int64 {
+	if x != nil {
+		return x.MessageSenderKey
+	}
+	return 0
+}
+
+func (x *ProcessInstance) GetVariableKeys() []string {
+	if x != nil {
+		return x.VariableKeys
+	}
+	return nil
+}
+
+type VariableHolder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Parent        *VariableHolder        `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
+	Variables     *Variables             `protobuf:"bytes,2,opt,name=variables,proto3" json:"variables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VariableHolder) Reset() {
+	*x = VariableHolder{}
+	mi := &file_marshalling_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VariableHolder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VariableHolder) ProtoMessage() {}
+
+func (x *VariableHolder) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VariableHolder.ProtoReflect.Descriptor instead.
+func (*VariableHolder) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VariableHolder) GetParent() *VariableHolder {
+	if x != nil {
+		return x.Parent
+	}
+	return nil
+}
+
+func (x *VariableHolder) GetVariables() *Variables {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+type CatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	CaughtAt      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=caught_at,json=caughtAt,proto3" json:"caught_at,omitempty"`
+	IsConsumed    bool                   `protobuf:"varint,3,opt,name=is_consumed,json=isConsumed,proto3" json:"is_consumed,omitempty"`
+	Variables     *Variables             `protobuf:"bytes,4,opt,name=variables,proto3" json:"variables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CatchEvent) Reset() {
+	*x = CatchEvent{}
+	mi := &file_marshalling_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatchEvent) ProtoMessage() {}
+
+func (x *CatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatchEvent.ProtoReflect.Descriptor instead.
+func (*CatchEvent) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CatchEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CatchEvent) GetCaughtAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CaughtAt
+	}
+	return nil
+}
+
+func (x *CatchEvent) GetIsConsumed() bool {
+	if x != nil {
+		return x.IsConsumed
+	}
+	return false
+}
+
+func (x *CatchEvent) GetVariables() *Variables {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+type CompensableActivity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ElementId     string                 `protobuf:"bytes,1,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	Key           int64                  `protobuf:"varint,2,opt,name=key,proto3" json:"key,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Variables     *Variables             `protobuf:"bytes,4,opt,name=variables,proto3" json:"variables,omitempty"`
+	ThrowerId     string                 `protobuf:"bytes,5,opt,name=thrower_id,json=throwerId,proto3" json:"thrower_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompensableActivity) Reset() {
+	*x = CompensableActivity{}
+	mi := &file_marshalling_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompensableActivity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompensableActivity) ProtoMessage() {}
+
+func (x *CompensableActivity) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompensableActivity.ProtoReflect.Descriptor instead.
+func (*CompensableActivity) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CompensableActivity) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+func (x *CompensableActivity) GetKey() int64 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
+
+func (x *CompensableActivity) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *CompensableActivity) GetVariables() *Variables {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+func (x *CompensableActivity) GetThrowerId() string {
+	if x != nil {
+		return x.ThrowerId
+	}
+	return ""
+}
+
+type Activity struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Type                      int32                  `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Key                       int64                  `protobuf:"varint,2,opt,name=key,proto3" json:"key,omitempty"`
+	State                     string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	ElementId                 string                 `protobuf:"bytes,4,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	Parallel                  bool                   `protobuf:"varint,5,opt,name=parallel,proto3" json:"parallel,omitempty"`
+	InboundFlowIdsCompleted   []string               `protobuf:"bytes,6,rep,name=inbound_flow_ids_completed,json=inboundFlowIdsCompleted,proto3" json:"inbound_flow_ids_completed,omitempty"`
+	OutboundActivityCompleted string                 `protobuf:"bytes,7,opt,name=outbound_activity_completed,json=outboundActivityCompleted,proto3" json:"outbound_activity_completed,omitempty"`
+	Satisfied                 bool                   `protobuf:"varint,8,opt,name=satisfied,proto3" json:"satisfied,omitempty"`
+	LoopCounter               int64                  `protobuf:"varint,9,opt,name=loop_counter,json=loopCounter,proto3" json:"loop_counter,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *Activity) Reset() {
+	*x = Activity{}
+	mi := &file_marshalling_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Activity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Activity) ProtoMessage() {}
+
+func (x *Activity) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Activity.ProtoReflect.Descriptor instead.
+func (*Activity) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Activity) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Activity) GetKey() int64 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
+
+func (x *Activity) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Activity) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+func (x *Activity) GetParallel() bool {
+	if x != nil {
+		return x.Parallel
+	}
+	return false
+}
+
+func (x *Activity) GetInboundFlowIdsCompleted() []string {
+	if x != nil {
+		return x.InboundFlowIdsCompleted
+	}
+	return nil
+}
+
+func (x *Activity) GetOutboundActivityCompleted() string {
+	if x != nil {
+		return x.OutboundActivityCompleted
+	}
+	return ""
+}
+
+func (x *Activity) GetSatisfied() bool {
+	if x != nil {
+		return x.Satisfied
+	}
+	return false
+}
+
+func (x *Activity) GetLoopCounter() int64 {
+	if x != nil {
+		return x.LoopCounter
+	}
+	return 0
+}
+
+type ActivitySurrogate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           int64                  `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	ElementId     string                 `protobuf:"bytes,3,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActivitySurrogate) Reset() {
+	*x = ActivitySurrogate{}
+	mi := &file_marshalling_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActivitySurrogate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivitySurrogate) ProtoMessage() {}
+
+func (x *ActivitySurrogate) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivitySurrogate.ProtoReflect.Descriptor instead.
+func (*ActivitySurrogate) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ActivitySurrogate) GetKey() int64 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
+
+func (x *ActivitySurrogate) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ActivitySurrogate) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+type MessageSubscription struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ElementId          string                 `protobuf:"bytes,1,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	ElementInstanceKey int64                  `protobuf:"varint,2,opt,name=element_instance_key,json=elementInstanceKey,proto3" json:"element_instance_key,omitempty"`
+	ProcessKey         int64                  `protobuf:"varint,3,opt,name=process_key,json=processKey,proto3" json:"process_key,omitempty"`
+	ProcessInstanceKey int64                  `protobuf:"varint,4,opt,name=process_instance_key,json=processInstanceKey,proto3" json:"process_instance_key,omitempty"`
+	Name               string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	State              string                 `protobuf:"bytes,6,opt,name=state,proto3" json:"state,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	OriginActivity     *ActivitySurrogate     `protobuf:"bytes,8,opt,name=origin_activity,json=originActivity,proto3" json:"origin_activity,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MessageSubscription) Reset() {
+	*x = MessageSubscription{}
+	mi := &file_marshalling_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageSubscription) ProtoMessage() {}
+
+func (x *MessageSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageSubscription.ProtoReflect.Descriptor instead.
+func (*MessageSubscription) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MessageSubscription) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+func (x *MessageSubscription) GetElementInstanceKey() int64 {
+	if x != nil {
+		return x.ElementInstanceKey
+	}
+	return 0
+}
+
+func (x *MessageSubscription) GetProcessKey() int64 {
+	if x != nil {
+		return x.ProcessKey
+	}
+	return 0
+}
+
+func (x *MessageSubscription) GetProcessInstanceKey() int64 {
+	if x != nil {
+		return x.ProcessInstanceKey
+	}
+	return 0
+}
+
+func (x *MessageSubscription) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MessageSubscription) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *MessageSubscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *MessageSubscription) GetOriginActivity() *ActivitySurrogate {
+	if x != nil {
+		return x.OriginActivity
+	}
+	return nil
+}
+
+type Timer struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ElementId          string                 `protobuf:"bytes,1,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	ElementInstanceKey int64                  `protobuf:"varint,2,opt,name=element_instance_key,json=elementInstanceKey,proto3" json:"element_instance_key,omitempty"`
+	ProcessKey         int64                  `protobuf:"varint,3,opt,name=process_key,json=processKey,proto3" json:"process_key,omitempty"`
+	ProcessInstanceKey int64                  `protobuf:"varint,4,opt,name=process_instance_key,json=processInstanceKey,proto3" json:"process_instance_key,omitempty"`
+	State              string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	DueAt              *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	// in nanoseconds
+	Duration       int64              `protobuf:"varint,8,opt,name=duration,proto3" json:"duration,omitempty"`
+	OriginActivity *ActivitySurrogate `protobuf:"bytes,9,opt,name=origin_activity,json=originActivity,proto3" json:"origin_activity,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Timer) Reset() {
+	*x = Timer{}
+	mi := &file_marshalling_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Timer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Timer) ProtoMessage() {}
+
+func (x *Timer) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Timer.ProtoReflect.Descriptor instead.
+func (*Timer) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Timer) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+func (x *Timer) GetElementInstanceKey() int64 {
+	if x != nil {
+		return x.ElementInstanceKey
+	}
+	return 0
+}
+
+func (x *Timer) GetProcessKey() int64 {
+	if x != nil {
+		return x.ProcessKey
+	}
+	return 0
+}
+
+func (x *Timer) GetProcessInstanceKey() int64 {
+	if x != nil {
+		return x.ProcessInstanceKey
+	}
+	return 0
+}
+
+func (x *Timer) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Timer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Timer) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *Timer) GetDuration() int64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *Timer) GetOriginActivity() *ActivitySurrogate {
+	if x != nil {
+		return x.OriginActivity
+	}
+	return nil
+}
+
+type Job struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ElementId          string                 `protobuf:"bytes,1,opt,name=element_id,json=elementId,proto3" json:"element_id,omitempty"`
+	ElementInstanceKey int64                  `protobuf:"varint,2,opt,name=element_instance_key,json=elementInstanceKey,proto3" json:"element_instance_key,omitempty"`
+	ProcessInstanceKey int64                  `protobuf:"varint,3,opt,name=process_instance_key,json=processInstanceKey,proto3" json:"process_instance_key,omitempty"`
+	JobKey             int64                  `protobuf:"varint,4,opt,name=job_key,json=jobKey,proto3" json:"job_key,omitempty"`
+	State              string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_marshalling_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Job) GetElementId() string {
+	if x != nil {
+		return x.ElementId
+	}
+	return ""
+}
+
+func (x *Job) GetElementInstanceKey() int64 {
+	if x != nil {
+		return x.ElementInstanceKey
+	}
+	return 0
+}
+
+func (x *Job) GetProcessInstanceKey() int64 {
+	if x != nil {
+		return x.ProcessInstanceKey
+	}
+	return 0
+}
+
+func (x *Job) GetJobKey() int64 {
+	if x != nil {
+		return x.JobKey
+	}
+	return 0
+}
+
+func (x *Job) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Like google.protobuf.Struct, but more compact: the values are part of the entries,
+// integral floats are varint encoded, and keys are stored once per process instance. When unmarshalling,
+// the variables get the same types as with JSON, e.g. int_value becomes int, and integer_value, number_value
+// as well as float_value become float64.
+type Variables struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*VariableEntry       `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variables) Reset() {
+	*x = Variables{}
+	mi := &file_marshalling_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variables) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variables) ProtoMessage() {}
+
+func (x *Variables) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variables.ProtoReflect.Descriptor instead.
+func (*Variables) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Variables) GetEntries() []*VariableEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type VariableEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// either the key, or key_ref is given
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// the key by its position in ProcessInstance.variable_keys, starting with 1 (since version 4)
+	KeyRef uint32 `protobuf:"varint,12,opt,name=key_ref,json=keyRef,proto3" json:"key_ref,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*VariableEntry_NullValue
+	//	*VariableEntry_IntegerValue
+	//	*VariableEntry_NumberValue
+	//	*VariableEntry_StringValue
+	//	*VariableEntry_BoolValue
+	//	*VariableEntry_MapValue
+	//	*VariableEntry_ListValue
+	//	*VariableEntry_IntValue
+	//	*VariableEntry_TimeValue
+	//	*VariableEntry_DurationValue
+	//	*VariableEntry_FloatValue
+	Value         isVariableEntry_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VariableEntry) Reset() {
+	*x = VariableEntry{}
+	mi := &file_marshalling_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VariableEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VariableEntry) ProtoMessage() {}
+
+func (x *VariableEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VariableEntry.ProtoReflect.Descriptor instead.
+func (*VariableEntry) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *VariableEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *VariableEntry) GetKeyRef() uint32 {
+	if x != nil {
+		return x.KeyRef
+	}
+	return 0
+}
+
+func (x *VariableEntry) GetValue() isVariableEntry_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *VariableEntry) GetNullValue() bool {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_NullValue); ok {
+			return x.NullValue
+		}
+	}
+	return false
+}
+
+func (x *VariableEntry) GetIntegerValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_IntegerValue); ok {
+			return x.IntegerValue
+		}
+	}
+	return 0
+}
+
+func (x *VariableEntry) GetNumberValue() float64 {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_NumberValue); ok {
+			return x.NumberValue
+		}
+	}
+	return 0
+}
+
+func (x *VariableEntry) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *VariableEntry) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *VariableEntry) GetMapValue() *Variables {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_MapValue); ok {
+			return x.MapValue
+		}
+	}
+	return nil
+}
+
+func (x *VariableEntry) GetListValue() *ValueList {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_ListValue); ok {
+			return x.ListValue
+		}
+	}
+	return nil
+}
+
+func (x *VariableEntry) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *VariableEntry) GetTimeValue() string {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_TimeValue); ok {
+			return x.TimeValue
+		}
+	}
+	return ""
+}
+
+func (x *VariableEntry) GetDurationValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_DurationValue); ok {
+			return x.DurationValue
+		}
+	}
+	return 0
+}
+
+func (x *VariableEntry) GetFloatValue() float32 {
+	if x != nil {
+		if x, ok := x.Value.(*VariableEntry_FloatValue); ok {
+			return x.FloatValue
+		}
+	}
+	return 0
+}
+
+type isVariableEntry_Value interface {
+	isVariableEntry_Value()
+}
+
+type VariableEntry_NullValue struct {
+	NullValue bool `protobuf:"varint,2,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type VariableEntry_IntegerValue struct {
+	IntegerValue int64 `protobuf:"zigzag64,3,opt,name=integer_value,json=integerValue,proto3,oneof"`
+}
+
+type VariableEntry_NumberValue struct {
+	NumberValue float64 `protobuf:"fixed64,4,opt,name=number_value,json=numberValue,proto3,oneof"`
+}
+
+type VariableEntry_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type VariableEntry_BoolValue struct {
+	BoolValue bool `protobuf:"varint,6,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type VariableEntry_MapValue struct {
+	MapValue *Variables `protobuf:"bytes,7,opt,name=map_value,json=mapValue,proto3,oneof"`
+}
+
+type VariableEntry_ListValue struct {
+	ListValue *ValueList `protobuf:"bytes,8,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+type VariableEntry_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,9,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type VariableEntry_TimeValue struct {
+	TimeValue string `protobuf:"bytes,10,opt,name=time_value,json=timeValue,proto3,oneof"` // RFC 3339
+}
+
+type VariableEntry_DurationValue struct {
+	DurationValue int64 `protobuf:"zigzag64,11,opt,name=duration_value,json=durationValue,proto3,oneof"` // nanoseconds
+}
+
+type VariableEntry_FloatValue struct {
+	FloatValue float32 `protobuf:"fixed32,13,opt,name=float_value,json=floatValue,proto3,oneof"` // a float64, which is exactly representable as float (since version 4)
+}
+
+func (*VariableEntry_NullValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_IntegerValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_NumberValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_StringValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_BoolValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_MapValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_ListValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_IntValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_TimeValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_DurationValue) isVariableEntry_Value() {}
+
+func (*VariableEntry_FloatValue) isVariableEntry_Value() {}
+
+// Same as the oneof in VariableEntry, but without a key
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Value_NullValue
+	//	*Value_IntegerValue
+	//	*Value_NumberValue
+	//	*Value_StringValue
+	//	*Value_BoolValue
+	//	*Value_MapValue
+	//	*Value_ListValue
+	//	*Value_IntValue
+	//	*Value_TimeValue
+	//	*Value_DurationValue
+	//	*Value_FloatValue
+	Kind          isValue_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_marshalling_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Value) GetKind() isValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Value) GetNullValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_NullValue); ok {
+			return x.NullValue
+		}
+	}
+	return false
+}
+
+func (x *Value) GetIntegerValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_IntegerValue); ok {
+			return x.IntegerValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetNumberValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_NumberValue); ok {
+			return x.NumberValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *Value) GetMapValue() *Variables {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_MapValue); ok {
+			return x.MapValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetListValue() *ValueList {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_ListValue); ok {
+			return x.ListValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetTimeValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_TimeValue); ok {
+			return x.TimeValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetDurationValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_DurationValue); ok {
+			return x.DurationValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetFloatValue() float32 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_FloatValue); ok {
+			return x.FloatValue
+		}
+	}
+	return 0
+}
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_NullValue struct {
+	NullValue bool `protobuf:"varint,2,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type Value_IntegerValue struct {
+	IntegerValue int64 `protobuf:"zigzag64,3,opt,name=integer_value,json=integerValue,proto3,oneof"`
+}
+
+type Value_NumberValue struct {
+	NumberValue float64 `protobuf:"fixed64,4,opt,name=number_value,json=numberValue,proto3,oneof"`
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,6,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type Value_MapValue struct {
+	MapValue *Variables `protobuf:"bytes,7,opt,name=map_value,json=mapValue,proto3,oneof"`
+}
+
+type Value_ListValue struct {
+	ListValue *ValueList `protobuf:"bytes,8,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,9,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_TimeValue struct {
+	TimeValue string `protobuf:"bytes,10,opt,name=time_value,json=timeValue,proto3,oneof"` // RFC 3339
+}
+
+type Value_DurationValue struct {
+	DurationValue int64 `protobuf:"zigzag64,11,opt,name=duration_value,json=durationValue,proto3,oneof"` // nanoseconds
+}
+
+type Value_FloatValue struct {
+	FloatValue float32 `protobuf:"fixed32,13,opt,name=float_value,json=floatValue,proto3,oneof"` // a float64, which is exactly representable as float (since version 4)
+}
+
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_IntegerValue) isValue_Kind() {}
+
+func (*Value_NumberValue) isValue_Kind() {}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_BoolValue) isValue_Kind() {}
+
+func (*Value_MapValue) isValue_Kind() {}
+
+func (*Value_ListValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_TimeValue) isValue_Kind() {}
+
+func (*Value_DurationValue) isValue_Kind() {}
+
+func (*Value_FloatValue) isValue_Kind() {}
+
+type ValueList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*Value               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueList) Reset() {
+	*x = ValueList{}
+	mi := &file_marshalling_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueList) ProtoMessage() {}
+
+func (x *ValueList) ProtoReflect() protoreflect.Message {
+	mi := &file_marshalling_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueList.ProtoReflect.Descriptor instead.
+func (*ValueList) Descriptor() ([]byte, []int) {
+	return file_marshalling_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ValueList) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+var File_marshalling_proto protoreflect.FileDescriptor
+
+const file_marshalling_proto_rawDesc = "" +
+	"\n" +
+	"\x11marshalling.proto\x12\vbpmn_engine\x1a\x1fgoogle/protobuf/timestamp.proto\"\xba\x03\n" +
+	"\vEngineState\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12;\n" +
+	"\tprocesses\x18\x03 \x03(\v2\x1d.bpmn_engine.ProcessReferenceR\tprocesses\x12I\n" +
+	"\x11process_instances\x18\x04 \x03(\v2\x1c.bpmn_engine.ProcessInstanceR\x10processInstances\x12U\n" +
+	"\x15message_subscriptions\x18\x05 \x03(\v2 .bpmn_engine.MessageSubscriptionR\x14messageSubscriptions\x12*\n" +
+	"\x06timers\x18\x06 \x03(\v2\x12.bpmn_engine.TimerR\x06timers\x12$\n" +
+	"\x04jobs\x18\a \x03(\v2\x10.bpmn_engine.JobR\x04jobs\x12L\n" +
+	"\x12decision_resources\x18\b \x03(\v2\x1d.bpmn_engine.DecisionResourceR\x11decisionResources\"\xa6\x01\n" +
+	"\x10ProcessReference\x12&\n" +
+	"\x0fbpmn_process_id\x18\x01 \x01(\tR\rbpmnProcessId\x12\x1f\n" +
+	"\vprocess_key\x18\x02 \x01(\x03R\n" +
+	"processKey\x12\x1b\n" +
+	"\tbpmn_data\x18\x03 \x01(\fR\bbpmnData\x12,\n" +
+	"\x12bpmn_resource_name\x18\x04 \x01(\tR\x10bpmnResourceName\"~\n" +
+	"\x10DecisionResource\x12#\n" +
+	"\rdecision_keys\x18\x01 \x03(\x03R\fdecisionKeys\x12\x19\n" +
+	"\bdmn_data\x18\x02 \x01(\fR\admnData\x12*\n" +
+	"\x11dmn_resource_name\x18\x03 \x01(\tR\x0fdmnResourceName\"\xcc\x04\n" +
+	"\x0fProcessInstance\x12\x1f\n" +
+	"\vprocess_key\x18\x01 \x01(\x03R\n" +
+	"processKey\x12!\n" +
+	"\finstance_key\x18\x02 \x01(\x03R\vinstanceKey\x12D\n" +
+	"\x0fvariable_holder\x18\x03 \x01(\v2\x1b.bpmn_engine.VariableHolderR\x0evariableHolder\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x12<\n" +
+	"\rcaught_events\x18\x06 \x03(\v2\x17.bpmn_engine.CatchEventR\fcaughtEvents\x125\n" +
+	"\n" +
+	"activities\x18\a \x03(\v2\x15.bpmn_engine.ActivityR\n" +
+	"activities\x12=\n" +
+	"\fcompleted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12W\n" +
+	"\x16compensable_activities\x18\t \x03(\v2 .bpmn_engine.CompensableActivityR\x15compensableActivities\x12,\n" +
+	"\x12message_sender_key\x18\n" +
+	" \x01(\x03R\x10messageSenderKey\x12#\n" +
+	"\rvariable_keys\x18\v \x03(\tR\fvariableKeys\"{\n" +
+	"\x0eVariableHolder\x123\n" +
+	"\x06parent\x18\x01 \x01(\v2\x1b.bpmn_engine.VariableHolderR\x06parent\x124\n" +
+	"\tvariables\x18\x02 \x01(\v2\x16.bpmn_engine.VariablesR\tvariables\"\xb0\x01\n" +
+	"\n" +
+	"CatchEvent\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x127\n" +
+	"\tcaught_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\bcaughtAt\x12\x1f\n" +
+	"\vis_consumed\x18\x03 \x01(\bR\n" +
+	"isConsumed\x124\n" +
+	"\tvariables\x18\x04 \x01(\v2\x16.bpmn_engine.VariablesR\tvariables\"\xb1\x01\n" +
+	"\x13CompensableActivity\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x01 \x01(\tR\telementId\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x124\n" +
+	"\tvariables\x18\x04 \x01(\v2\x16.bpmn_engine.VariablesR\tvariables\x12\x1d\n" +
+	"\n" +
+	"thrower_id\x18\x05 \x01(\tR\tthrowerId\"\xbf\x02\n" +
+	"\bActivity\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\x05R\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x04 \x01(\tR\telementId\x12\x1a\n" +
+	"\bparallel\x18\x05 \x01(\bR\bparallel\x12;\n" +
+	"\x1ainbound_flow_ids_completed\x18\x06 \x03(\tR\x17inboundFlowIdsCompleted\x12>\n" +
+	"\x1boutbound_activity_completed\x18\a \x01(\tR\x19outboundActivityCompleted\x12\x1c\n" +
+	"\tsatisfied\x18\b \x01(\bR\tsatisfied\x12!\n" +
+	"\floop_counter\x18\t \x01(\x03R\vloopCounter\"Z\n" +
+	"\x11ActivitySurrogate\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x03 \x01(\tR\telementId\"\xe7\x02\n" +
+	"\x13MessageSubscription\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x01 \x01(\tR\telementId\x120\n" +
+	"\x14element_instance_key\x18\x02 \x01(\x03R\x12elementInstanceKey\x12\x1f\n" +
+	"\vprocess_key\x18\x03 \x01(\x03R\n" +
+	"processKey\x120\n" +
+	"\x14process_instance_key\x18\x04 \x01(\x03R\x12processInstanceKey\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x06 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12G\n" +
+	"\x0forigin_activity\x18\b \x01(\v2\x1e.bpmn_engine.ActivitySurrogateR\x0eoriginActivity\"\x94\x03\n" +
+	"\x05Timer\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x01 \x01(\tR\telementId\x120\n" +
+	"\x14element_instance_key\x18\x02 \x01(\x03R\x12elementInstanceKey\x12\x1f\n" +
+	"\vprocess_key\x18\x03 \x01(\x03R\n" +
+	"processKey\x120\n" +
+	"\x14process_instance_key\x18\x04 \x01(\x03R\x12processInstanceKey\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x121\n" +
+	"\x06due_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x12\x1a\n" +
+	"\bduration\x18\b \x01(\x03R\bduration\x12G\n" +
+	"\x0forigin_activity\x18\t \x01(\v2\x1e.bpmn_engine.ActivitySurrogateR\x0eoriginActivity\"\xf2\x01\n" +
+	"\x03Job\x12\x1d\n" +
+	"\n" +
+	"element_id\x18\x01 \x01(\tR\telementId\x120\n" +
+	"\x14element_instance_key\x18\x02 \x01(\x03R\x12elementInstanceKey\x120\n" +
+	"\x14process_instance_key\x18\x03 \x01(\x03R\x12processInstanceKey\x12\x17\n" +
+	"\ajob_key\x18\x04 \x01(\x03R\x06jobKey\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"A\n" +
+	"\tVariables\x124\n" +
+	"\aentries\x18\x01 \x03(\v2\x1a.bpmn_engine.VariableEntryR\aentries\"\xf2\x03\n" +
+	"\rVariableEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x17\n" +
+	"\akey_ref\x18\f \x01(\rR\x06keyRef\x12\x1f\n" +
+	"\n" +
+	"null_value\x18\x02 \x01(\bH\x00R\tnullValue\x12%\n" +
+	"\rinteger_value\x18\x03 \x01(\x12H\x00R\fintegerValue\x12#\n" +
+	"\fnumber_value\x18\x04 \x01(\x01H\x00R\vnumberValue\x12#\n" +
+	"\fstring_value\x18\x05 \x01(\tH\x00R\vstringValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x06 \x01(\bH\x00R\tboolValue\x125\n" +
+	"\tmap_value\x18\a \x01(\v2\x16.bpmn_engine.VariablesH\x00R\bmapValue\x127\n" +
+	"\n" +
+	"list_value\x18\b \x01(\v2\x16.bpmn_engine.ValueListH\x00R\tlistValue\x12\x1d\n" +
+	"\tint_value\x18\t \x01(\x12H\x00R\bintValue\x12\x1f\n" +
+	"\n" +
+	"time_value\x18\n" +
+	" \x01(\tH\x00R\ttimeValue\x12'\n" +
+	"\x0eduration_value\x18\v \x01(\x12H\x00R\rdurationValue\x12!\n" +
+	"\vfloat_value\x18\r \x01(\x02H\x00R\n" +
+	"floatValueB\a\n" +
+	"\x05value\"\xc4\x03\n" +
+	"\x05Value\x12\x1f\n" +
+	"\n" +
+	"null_value\x18\x02 \x01(\bH\x00R\tnullValue\x12%\n" +
+	"\rinteger_value\x18\x03 \x01(\x12H\x00R\fintegerValue\x12#\n" +
+	"\fnumber_value\x18\x04 \x01(\x01H\x00R\vnumberValue\x12#\n" +
+	"\fstring_value\x18\x05 \x01(\tH\x00R\vstringValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x06 \x01(\bH\x00R\tboolValue\x125\n" +
+	"\tmap_value\x18\a \x01(\v2\x16.bpmn_engine.VariablesH\x00R\bmapValue\x127\n" +
+	"\n" +
+	"list_value\x18\b \x01(\v2\x16.bpmn_engine.ValueListH\x00R\tlistValue\x12\x1d\n" +
+	"\tint_value\x18\t \x01(\x12H\x00R\bintValue\x12\x1f\n" +
+	"\n" +
+	"time_value\x18\n" +
+	" \x01(\tH\x00R\ttimeValue\x12'\n" +
+	"\x0eduration_value\x18\v \x01(\x12H\x00R\rdurationValue\x12!\n" +
+	"\vfloat_value\x18\r \x01(\x02H\x00R\n" +
+	"floatValueB\x06\n" +
+	"\x04kindJ\x04\b\x01\x10\x02\"7\n" +
+	"\tValueList\x12*\n" +
+	"\x06values\x18\x01 \x03(\v2\x12.bpmn_engine.ValueR\x06valuesBGZEgithub.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/internal/statepbb\x06proto3"
+
+var (
+	file_marshalling_proto_rawDescOnce sync.Once
+	file_marshalling_proto_rawDescData []byte
+)
+
+func file_marshalling_proto_rawDescGZIP() []byte {
+	file_marshalling_proto_rawDescOnce.Do(func() {
+		file_marshalling_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_marshalling_proto_rawDesc), len(file_marshalling_proto_rawDesc)))
+	})
+	return file_marshalling_proto_rawDescData
+}
+
+var file_marshalling_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_marshalling_proto_goTypes = []any{
+	(*EngineState)(nil),           // 0: bpmn_engine.EngineState
+	(*ProcessReference)(nil),      // 1: bpmn_engine.ProcessReference
+	(*DecisionResource)(nil),      // 2: bpmn_engine.DecisionResource
+	(*ProcessInstance)(nil),       // 3: bpmn_engine.ProcessInstance
+	(*VariableHolder)(nil),        // 4: bpmn_engine.VariableHolder
+	(*CatchEvent)(nil),            // 5: bpmn_engine.CatchEvent
+	(*CompensableActivity)(nil),   // 6: bpmn_engine.CompensableActivity
+	(*Activity)(nil),              // 7: bpmn_engine.Activity
+	(*ActivitySurrogate)(nil),     // 8: bpmn_engine.ActivitySurrogate
+	(*MessageSubscription)(nil),   // 9: bpmn_engine.MessageSubscription
+	(*Timer)(nil),                 // 10: bpmn_engine.Timer
+	(*Job)(nil),                   // 11: bpmn_engine.Job
+	(*Variables)(nil),             // 12: bpmn_engine.Variables
+	(*VariableEntry)(nil),         // 13: bpmn_engine.VariableEntry
+	(*Value)(nil),                 // 14: bpmn_engine.Value
+	(*ValueList)(nil),             // 15: bpmn_engine.ValueList
+	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+}
+var file_marshalling_proto_depIdxs = []int32{
+	1,  // 0: bpmn_engine.EngineState.processes:type_name -> bpmn_engine.ProcessReference
+	3,  // 1: bpmn_engine.EngineState.process_instances:type_name -> bpmn_engine.ProcessInstance
+	9,  // 2: bpmn_engine.EngineState.message_subscriptions:type_name -> bpmn_engine.MessageSubscription
+	10, // 3: bpmn_engine.EngineState.timers:type_name -> bpmn_engine.Timer
+	11, // 4: bpmn_engine.EngineState.jobs:type_name -> bpmn_engine.Job
+	2,  // 5: bpmn_engine.EngineState.decision_resources:type_name -> bpmn_engine.DecisionResource
+	4,  // 6: bpmn_engine.ProcessInstance.variable_holder:type_name -> bpmn_engine.VariableHolder
+	16, // 7: bpmn_engine.ProcessInstance.created_at:type_name -> google.protobuf.Timestamp
+	5,  // 8: bpmn_engine.ProcessInstance.caught_events:type_name -> bpmn_engine.CatchEvent
+	7,  // 9: bpmn_engine.ProcessInstance.activities:type_name -> bpmn_engine.Activity
+	16, // 10: bpmn_engine.ProcessInstance.completed_at:type_name -> google.protobuf.Timestamp
+	6,  // 11: bpmn_engine.ProcessInstance.compensable_activities:type_name -> bpmn_engine.CompensableActivity
+	4,  // 12: bpmn_engine.VariableHolder.parent:type_name -> bpmn_engine.VariableHolder
+	12, // 13: bpmn_engine.VariableHolder.variables:type_name -> bpmn_engine.Variables
+	16, // 14: bpmn_engine.CatchEvent.caught_at:type_name -> google.protobuf.Timestamp
+	12, // 15: bpmn_engine.CatchEvent.variables:type_name -> bpmn_engine.Variables
+	12, // 16: bpmn_engine.CompensableActivity.variables:type_name -> bpmn_engine.Variables
+	16, // 17: bpmn_engine.MessageSubscription.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 18: bpmn_engine.MessageSubscription.origin_activity:type_name -> bpmn_engine.ActivitySurrogate
+	16, // 19: bpmn_engine.Timer.created_at:type_name -> google.protobuf.Timestamp
+	16, // 20: bpmn_engine.Timer.due_at:type_name -> google.protobuf.Timestamp
+	8,  // 21: bpmn_engine.Timer.origin_activity:type_name -> bpmn_engine.ActivitySurrogate
+	16, // 22: bpmn_engine.Job.created_at:type_name -> google.protobuf.Timestamp
+	13, // 23: bpmn_engine.Variables.entries:type_name -> bpmn_engine.VariableEntry
+	12, // 24: bpmn_engine.VariableEntry.map_value:type_name -> bpmn_engine.Variables
+	15, // 25: bpmn_engine.VariableEntry.list_value:type_name -> bpmn_engine.ValueList
+	12, // 26: bpmn_engine.Value.map_value:type_name -> bpmn_engine.Variables
+	15, // 27: bpmn_engine.Value.list_value:type_name -> bpmn_engine.ValueList
+	14, // 28: bpmn_engine.ValueList.values:type_name -> bpmn_engine.Value
+	29, // [29:29] is the sub-list for method output_type
+	29, // [29:29] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
+}
+
+func init() { file_marshalling_proto_init() }
+func file_marshalling_proto_init() {
+	if File_marshalling_proto != nil {
+		return
+	}
+	file_marshalling_proto_msgTypes[13].OneofWrappers = []any{
+		(*VariableEntry_NullValue)(nil),
+		(*VariableEntry_IntegerValue)(nil),
+		(*VariableEntry_NumberValue)(nil),
+		(*VariableEntry_StringValue)(nil),
+		(*VariableEntry_BoolValue)(nil),
+		(*VariableEntry_MapValue)(nil),
+		(*VariableEntry_ListValue)(nil),
+		(*VariableEntry_IntValue)(nil),
+		(*VariableEntry_TimeValue)(nil),
+		(*VariableEntry_DurationValue)(nil),
+		(*VariableEntry_FloatValue)(nil),
+	}
+	file_marshalling_proto_msgTypes[14].OneofWrappers = []any{
+		(*Value_NullValue)(nil),
+		(*Value_IntegerValue)(nil),
+		(*Value_NumberValue)(nil),
+		(*Value_StringValue)(nil),
+		(*Value_BoolValue)(nil),
+		(*Value_MapValue)(nil),
+		(*Value_ListValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_TimeValue)(nil),
+		(*Value_DurationValue)(nil),
+		(*Value_FloatValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_marshalling_proto_rawDesc), len(file_marshalling_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_marshalling_proto_goTypes,
+		DependencyIndexes: file_marshalling_proto_depIdxs,
+		MessageInfos:      file_marshalling_proto_msgTypes,
+	}.Build()
+	File_marshalling_proto = out.File
+	file_marshalling_proto_goTypes = nil
+	file_marshalling_proto_depIdxs = nil
+}
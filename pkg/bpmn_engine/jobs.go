@@ -14,6 +14,7 @@ type job struct {
 	JobState           ActivityState `json:"s"`
 	CreatedAt          time.Time     `json:"c"`
 	baseElement        *BPMN20.BaseElement
+	failReason         string
 }
 
 func (j job) Key() int64 {
@@ -32,11 +33,15 @@ func (j job) Element() *BPMN20.BaseElement {
 	return j.baseElement
 }
 
-func findOrCreateJob(jobs *[]*job, element *BPMN20.TaskElement, instance *processInstanceInfo, generateKey func() int64) *job {
+func findOrCreateJob(jobs *instanceRecords[*job], element *BPMN20.TaskElement, instance *processInstanceInfo, generateKey func() int64) *job {
 	be := (*element).(BPMN20.BaseElement)
-	for _, job := range *jobs {
-		if job.ElementId == be.GetId() && job.ProcessInstanceKey == instance.GetInstanceKey() {
-			return job
+	if existing := jobs.ofElement(instance.GetInstanceKey(), be.GetId()); len(existing) > 0 {
+		if !instance.ProcessInfo.graph.isLooping(be.GetId()) {
+			return existing[0]
+		}
+		// every iteration of a loop has its own jobs, so only the current iteration's job is continued
+		if latest := existing[len(existing)-1]; latest.JobState == Active {
+			return latest
 		}
 	}
 
@@ -51,7 +56,7 @@ func findOrCreateJob(jobs *[]*job, element *BPMN20.TaskElement, instance *proces
 		baseElement:        &be,
 	}
 
-	*jobs = append(*jobs, &job)
+	jobs.add(&job)
 
 	return &job
 }
@@ -1,6 +1,8 @@
 package bpmn_engine
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/corbym/gocrest/has"
@@ -15,7 +17,7 @@ const (
 )
 
 func increaseCounterHandler(job ActivatedJob) {
-	counter := job.Variable(varCounter).(float64)
+	counter := job.Variable(varCounter).(int)
 	counter = counter + 1
 	job.SetVariable(varCounter, counter)
 	job.Complete()
@@ -52,10 +54,10 @@ func Test_simple_count_loop(t *testing.T) {
 	bpmnEngine.NewTaskHandler().Id("id-increaseCounter").Handler(increaseCounterHandler)
 
 	vars := map[string]interface{}{}
-	vars[varCounter] = 0.0
+	vars[varCounter] = 0
 	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, vars)
 
-	then.AssertThat(t, instance.GetVariable(varCounter), is.EqualTo(4.0))
+	then.AssertThat(t, instance.GetVariable(varCounter), is.EqualTo(4))
 	then.AssertThat(t, instance.ActivityState, is.EqualTo(Completed))
 }
 
@@ -65,10 +67,10 @@ func Test_simple_count_loop_with_message(t *testing.T) {
 	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple-count-loop-with-message.bpmn")
 
 	vars := map[string]interface{}{}
-	vars[varEngineValidationAttempts] = 0.0
+	vars[varEngineValidationAttempts] = 0
 	bpmnEngine.NewTaskHandler().Id("do-nothing").Handler(jobCompleteHandler)
 	bpmnEngine.NewTaskHandler().Id("validate").Handler(func(job ActivatedJob) {
-		attempts := job.Variable(varEngineValidationAttempts).(float64)
+		attempts := job.Variable(varEngineValidationAttempts).(int)
 		foobar := attempts >= 1
 		attempts++
 		job.SetVariable(varEngineValidationAttempts, attempts)
@@ -86,7 +88,7 @@ func Test_simple_count_loop_with_message(t *testing.T) {
 	// validation happened
 
 	then.AssertThat(t, instance.GetVariable(varHasReachedMaxAttempts), is.True())
-	then.AssertThat(t, instance.GetVariable(varEngineValidationAttempts), is.EqualTo(2.0))
+	then.AssertThat(t, instance.GetVariable(varEngineValidationAttempts), is.EqualTo(2))
 	then.AssertThat(t, instance.ActivityState, is.EqualTo(Completed))
 
 	// internal State expected
@@ -128,7 +130,7 @@ func Test_task_InputOutput_mapping_happy_path(t *testing.T) {
 	then.AssertThat(t, err, is.Nil())
 
 	// then
-	for _, job := range bpmnEngine.jobs {
+	for _, job := range bpmnEngine.jobs.all {
 		then.AssertThat(t, job.JobState, is.EqualTo(Completed))
 	}
 	then.AssertThat(t, cp.CallPath, is.EqualTo("service-task-1,user-task-2"))
@@ -140,7 +142,7 @@ func Test_task_InputOutput_mapping_happy_path(t *testing.T) {
 		"name": "order1",
 		"id":   "1234",
 	}))
-	then.AssertThat(t, pi.GetVariable("orderId"), is.EqualTo(1234.0))
+	then.AssertThat(t, pi.GetVariable("orderId"), is.EqualTo(1234))
 	then.AssertThat(t, pi.GetVariable("orderName"), is.EqualTo("order1"))
 }
 
@@ -150,8 +152,8 @@ func Test_instance_fails_on_Invalid_Input_mapping(t *testing.T) {
 	cp := CallPath{}
 
 	// give
-	process, _ := bpmnEngine.LoadFromFile("../../test-cases/service-task-invalid-input.bpmn")
-	bpmnEngine.NewTaskHandler().Id("invalid-input").Handler(cp.TaskHandler)
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/service-task-failing-input.bpmn")
+	bpmnEngine.NewTaskHandler().Id("failing-input").Handler(cp.TaskHandler)
 
 	// when
 	pi, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
@@ -160,7 +162,7 @@ func Test_instance_fails_on_Invalid_Input_mapping(t *testing.T) {
 	// then
 	then.AssertThat(t, cp.CallPath, is.EqualTo(""))
 	then.AssertThat(t, pi.GetVariable("id"), is.Nil())
-	then.AssertThat(t, bpmnEngine.jobs[0].JobState, is.EqualTo(Failed))
+	then.AssertThat(t, bpmnEngine.jobs.all[0].JobState, is.EqualTo(Failed))
 	then.AssertThat(t, pi.GetState(), is.EqualTo(Failed))
 }
 
@@ -170,20 +172,44 @@ func Test_job_fails_on_Invalid_Output_mapping(t *testing.T) {
 	cp := CallPath{}
 
 	// give
-	process, _ := bpmnEngine.LoadFromFile("../../test-cases/service-task-invalid-output.bpmn")
-	bpmnEngine.NewTaskHandler().Id("invalid-output").Handler(cp.TaskHandler)
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/service-task-failing-output.bpmn")
+	bpmnEngine.NewTaskHandler().Id("failing-output").Handler(cp.TaskHandler)
 
 	// when
 	pi, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
 	then.AssertThat(t, err, is.Nil())
 
 	// then
-	then.AssertThat(t, cp.CallPath, is.EqualTo("invalid-output"))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("failing-output"))
 	then.AssertThat(t, pi.GetVariable("order"), is.Nil())
-	then.AssertThat(t, bpmnEngine.jobs[0].JobState, is.EqualTo(Failed))
+	then.AssertThat(t, bpmnEngine.jobs.all[0].JobState, is.EqualTo(Failed))
 	then.AssertThat(t, pi.GetState(), is.EqualTo(Failed))
 }
 
+func Test_loading_fails_on_invalid_mapping_syntax(t *testing.T) {
+	tests := map[string]string{
+		"service-task-invalid-input.bpmn":  "zeebe:input",
+		"service-task-invalid-output.bpmn": "zeebe:output",
+	}
+	for file, attribute := range tests {
+		t.Run(file, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+
+			// when
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/" + file)
+
+			// then
+			var syntaxError *ExpressionSyntaxError
+			then.AssertThat(t, process, is.Nil())
+			then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+			then.AssertThat(t, syntaxError.ElementId, is.EqualTo(strings.TrimSuffix(strings.TrimPrefix(file, "service-task-"), ".bpmn")))
+			then.AssertThat(t, syntaxError.Attribute, is.EqualTo(attribute))
+			then.AssertThat(t, bpmnEngine.processes, has.Length(0))
+		})
+	}
+}
+
 func Test_task_type_handler(t *testing.T) {
 	// setup
 	bpmnEngine := New()
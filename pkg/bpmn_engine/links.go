@@ -7,7 +7,7 @@ import (
 	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
 )
 
-func (state *BpmnEngineState) handleIntermediateThrowEvent(process BPMN20.ProcessElement, instance *processInstanceInfo, ite BPMN20.TIntermediateThrowEvent, activity activity) (nextCommands []command) {
+func (state *BpmnEngineState) handleIntermediateThrowEvent(instance *processInstanceInfo, ite BPMN20.TIntermediateThrowEvent, activity activity) (nextCommands []command) {
 	linkName := ite.LinkEventDefinition.Name
 	if len(strings.TrimSpace(linkName)) == 0 {
 		nextCommands = []command{errorCommand{
@@ -16,25 +16,23 @@ func (state *BpmnEngineState) handleIntermediateThrowEvent(process BPMN20.Proces
 			elementName: ite.Name,
 		}}
 	}
-	for _, ice := range process.GetIntermediateCatchEvent() {
-		if ice.LinkEventDefinition.Name == linkName {
-			elementVarHolder := NewVarHolder(&instance.VariableHolder, nil)
-			if err := propagateProcessInstanceVariables(&elementVarHolder, ite.Output); err != nil {
-				msg := fmt.Sprintf("Can't evaluate expression in element id=%s name=%s", ite.Id, ite.Name)
-				nextCommands = []command{errorCommand{
-					err:         &ExpressionEvaluationError{Msg: msg, Err: err},
-					elementId:   ite.Id,
-					elementName: ite.Name,
-				}}
-			} else {
-				var element BPMN20.BaseElement = ice
-				nextCommands = []command{activityCommand{
-					sourceId:       ice.Id,
-					element:        &element,
-					originActivity: activity,
-				}}
-			}
-			break
+	// link events only connect elements within the same (sub) process
+	graph := instance.ProcessInfo.graph
+	if element, found := graph.linkCatchEvents[graph.scopes[ite.Id].GetId()][linkName]; found {
+		elementVarHolder := NewVarHolder(&instance.VariableHolder, nil)
+		if err := propagateProcessInstanceVariables(graph.expressions, &elementVarHolder, ite.Output); err != nil {
+			msg := fmt.Sprintf("Can't evaluate expression in element id=%s name=%s", ite.Id, ite.Name)
+			nextCommands = []command{errorCommand{
+				err:         &ExpressionEvaluationError{Msg: msg, Err: err},
+				elementId:   ite.Id,
+				elementName: ite.Name,
+			}}
+		} else {
+			nextCommands = []command{activityCommand{
+				sourceId:       (*element).GetId(),
+				element:        element,
+				originActivity: activity,
+			}}
 		}
 	}
 	if len(nextCommands) == 0 {
@@ -0,0 +1,125 @@
+package bpmn_engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// loopCounterVariable is the local variable, which holds the number of the current iteration of a looping activity
+const loopCounterVariable = "loopCounter"
+
+// enterLoop returns the loop of the activity, when it has standard loop characteristics, and starts it with the
+// first iteration, unless it's looping already; run is false, when the loop's test before the first iteration fails,
+// i.e. the activity is completed without any iteration
+func (state *BpmnEngineState) enterLoop(instance *processInstanceInfo, element *BPMN20.BaseElement) (loop *loopActivity, run bool, err error) {
+	if standardLoopOf(*element) == nil {
+		return nil, true, nil
+	}
+	if loop = instance.findActiveLoop((*element).GetId()); loop != nil {
+		return loop, true, nil
+	}
+	loop = &loopActivity{
+		key:     state.generateKey(),
+		state:   Active,
+		element: element,
+	}
+	if standardLoopOf(*element).TestBefore {
+		if run, err = isLoopContinued(instance, loop); err != nil || !run {
+			loop.SetState(Completed)
+			return loop, false, err
+		}
+	}
+	loop.loopCounter = 1
+	instance.appendActivity(loop)
+	return loop, true, nil
+}
+
+// skipLoop continues the flow at the outgoing flows of an activity, whose loop runs no iteration,
+// or fails the process instance, when the loop condition can't be evaluated
+func (state *BpmnEngineState) skipLoop(instance *processInstanceInfo, element *BPMN20.BaseElement, act activity, loop *loopActivity, startedAt time.Time, err error) []command {
+	if err != nil {
+		state.recordElementHistory(instance, element, act, loop, false, startedAt, err)
+		return []command{errorCommand{
+			err:         err,
+			elementId:   (*element).GetId(),
+			elementName: (*element).GetName(),
+		}}
+	}
+	nextCommands := createNextCommands(instance, element, loop)
+	state.recordElementHistory(instance, element, act, loop, true, startedAt, nil)
+	return nextCommands
+}
+
+// continueLoop returns the command for the next iteration, after the current iteration completed,
+// or completes the loop, so that the flow continues
+func continueLoop(instance *processInstanceInfo, loop *loopActivity, iteration activity) ([]command, error) {
+	again, err := isLoopContinued(instance, loop)
+	if err != nil {
+		loop.SetState(Failed)
+		return nil, err
+	}
+	if !again {
+		loop.SetState(Completed)
+		return nil, nil
+	}
+	loop.loopCounter++
+	return []command{activityCommand{
+		sourceId:       (*loop.Element()).GetId(),
+		originActivity: iteration,
+		element:        loop.Element(),
+	}}, nil
+}
+
+// isLoopContinued returns true, when the loop runs another iteration, i.e. the loop maximum isn't reached
+// and the loop condition is true; the condition sees the number of completed iterations as loopCounter
+func isLoopContinued(instance *processInstanceInfo, loop *loopActivity) (bool, error) {
+	loopCharacteristics := standardLoopOf(*loop.Element())
+	if loopCharacteristics.LoopMaximum != nil && loop.loopCounter >= *loopCharacteristics.LoopMaximum {
+		return false, nil
+	}
+	if !loopCharacteristics.HasLoopCondition() {
+		return true, nil
+	}
+	variableHolder := NewVarHolder(&instance.VariableHolder, nil)
+	variableHolder.SetVariable(loopCounterVariable, loop.loopCounter)
+	out, err := instance.ProcessInfo.graph.expressions.evaluate(loopCharacteristics.GetLoopConditionLanguage(), loopCharacteristics.GetLoopCondition(), variableHolder.Variables())
+	if err != nil {
+		return false, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating loop condition of activity id='%s' name='%s'", (*loop.Element()).GetId(), (*loop.Element()).GetName()),
+			Err: err,
+		}
+	}
+	return out == true, nil
+}
+
+// loopVariables returns the local variables of the current iteration, i.e. the loopCounter of the looping activity,
+// or of the innermost looping sub-process, which runs the activity; returns nil, when there's no loop
+func loopVariables(act activity, instance *processInstanceInfo, loop *loopActivity) map[string]interface{} {
+	for a := act; loop == nil && a != nil; a = parentActivityOf(a) {
+		loop = instance.findActiveLoop((*a.Element()).GetId())
+	}
+	if loop == nil {
+		return nil
+	}
+	return map[string]interface{}{loopCounterVariable: loop.loopCounter}
+}
+
+// standardLoopOf returns the standard loop characteristics of tasks and sub-processes, or nil, when they don't loop
+func standardLoopOf(element BPMN20.BaseElement) *BPMN20.TStandardLoopCharacteristics {
+	if activityElement, ok := element.(BPMN20.ActivityElement); ok {
+		return activityElement.GetStandardLoopCharacteristics()
+	}
+	return nil
+}
+
+// findActiveLoop returns the loop of the activity with the given ID, or nil, when it doesn't loop right now
+func (pii *processInstanceInfo) findActiveLoop(elementId string) *loopActivity {
+	for _, a := range pii.activities {
+		if loop, ok := a.(*loopActivity); ok && loop.State() == Active && (*loop.Element()).GetId() == elementId {
+			return loop
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package bpmn_engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_looping_task_runs_until_its_loop_condition_is_false(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	var loopCounters []interface{}
+	bpmnEngine.NewTaskHandler().Type("count").Handler(func(job ActivatedJob) {
+		loopCounters = append(loopCounters, job.Variable("loopCounter"))
+		job.SetVariable("counter", job.Variable("loopCounter"))
+		job.Complete()
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"limit": 3, "succeeded": true})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, loopCounters, is.EqualTo([]interface{}{1, 2, 3}))
+	then.AssertThat(t, instance.GetVariable("counter"), is.EqualTo(3))
+	then.AssertThat(t, instance.GetVariable("loopCounter"), is.Nil())
+}
+
+func Test_every_iteration_of_a_looping_task_has_its_own_job(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("count").Handler(func(job ActivatedJob) {
+		job.SetVariable("counter", job.Variable("loopCounter"))
+		job.Complete()
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"limit": 3, "succeeded": true})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	jobs := bpmnEngine.jobs.ofElement(instance.InstanceKey, "count")
+	then.AssertThat(t, jobs, has.Length(3))
+	then.AssertThat(t, jobs[0].JobKey, is.Not(is.EqualTo(jobs[1].JobKey)))
+	then.AssertThat(t, jobs[1].JobKey, is.Not(is.EqualTo(jobs[2].JobKey)))
+	then.AssertThat(t, jobs[2].ElementInstanceKey, is.Not(is.EqualTo(jobs[0].ElementInstanceKey)))
+}
+
+func Test_looping_task_runs_once_before_testing_its_loop_condition(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("count").Handler(func(job ActivatedJob) {
+		job.SetVariable("counter", job.Variable("loopCounter"))
+		cp.TaskHandler(job)
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"limit": 0, "succeeded": true})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("count"))
+}
+
+func Test_looping_task_tests_before_every_iteration_and_stops_at_its_loop_maximum(t *testing.T) {
+	tests := []struct {
+		name      string
+		succeeded bool
+		callPath  string
+	}{
+		{"condition is false before the first iteration", true, "count"},
+		{"loop maximum is reached", false, "count,retry,retry"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			bpmnEngine.NewTaskHandler().Type("count").Handler(func(job ActivatedJob) {
+				job.SetVariable("counter", job.Variable("loopCounter"))
+				cp.TaskHandler(job)
+			})
+			bpmnEngine.NewTaskHandler().Type("retry").Handler(cp.TaskHandler)
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"limit": 1, "succeeded": test.succeeded})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_looping_task_continues_with_the_next_iteration_after_its_job_was_completed_later(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		var loopCounters []interface{}
+		countHandler := func(job ActivatedJob) {
+			loopCounters = append(loopCounters, job.Variable("loopCounter"))
+			job.SetVariable("counter", job.Variable("loopCounter"))
+			job.Complete()
+		}
+		bpmnEngine.NewTaskHandler().Type("count").Handler(func(job ActivatedJob) {
+			if job.Variable("loopCounter") == 1 {
+				countHandler(job)
+			}
+		})
+
+		// given
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"limit": 3, "succeeded": true})
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+		restored.NewTaskHandler().Type("count").Handler(countHandler)
+
+		// when
+		_, err = restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey).GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, loopCounters, is.EqualTo([]interface{}{1, 2, 3}))
+		then.AssertThat(t, restored.jobs.ofElement(instance.InstanceKey, "count"), has.Length(3))
+	})
+}
+
+func Test_looping_sub_process_runs_up_to_its_loop_maximum(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/standard-loop-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	var loopCounters []interface{}
+	bpmnEngine.NewTaskHandler().Type("process-item").Handler(func(job ActivatedJob) {
+		loopCounters = append(loopCounters, job.Variable("loopCounter"))
+		job.Complete()
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, loopCounters, is.EqualTo([]interface{}{1, 2, 3}))
+	then.AssertThat(t, bpmnEngine.jobs.ofElement(instance.InstanceKey, "process-item"), has.Length(3))
+}
+
+func Test_loading_fails_on_loop_without_loop_condition_and_loop_maximum(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	data, err := os.ReadFile("../../test-cases/standard-loop-sub-process.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData := strings.Replace(string(data), `<bpmn:standardLoopCharacteristics loopMaximum="3" />`, `<bpmn:standardLoopCharacteristics />`, 1)
+
+	// when
+	_, err = bpmnEngine.LoadFromBytes([]byte(xmlData))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("loop of activity id=batch has neither loop condition nor loop maximum"))
+	then.AssertThat(t, bpmnEngine.processes, has.Length(0))
+}
@@ -0,0 +1,148 @@
+package bpmn_engine
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// KeyProvider supplies the AES keys for WithEncryption.
+// To rotate keys, return a new current key, but keep supplying the older keys by their ID,
+// so that data encrypted before the rotation can still be decrypted.
+type KeyProvider interface {
+	// CurrentKey returns the key used for encryption and its ID, which is stored (unencrypted) along with the data.
+	// The key must have 16, 24 or 32 bytes, to select AES-128, AES-192 or AES-256.
+	CurrentKey() (keyId string, key []byte, err error)
+
+	// Key returns the key with the given ID, used for decryption
+	Key(keyId string) ([]byte, error)
+}
+
+// StaticKeyProvider is a simple KeyProvider with a fixed set of keys
+type StaticKeyProvider struct {
+	CurrentKeyId string
+	Keys         map[string][]byte
+}
+
+func (p StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.CurrentKeyId)
+	return p.CurrentKeyId, key, err
+}
+
+func (p StaticKeyProvider) Key(keyId string) ([]byte, error) {
+	key, found := p.Keys[keyId]
+	if !found {
+		return nil, fmt.Errorf("no key with id=%s", keyId)
+	}
+	return key, nil
+}
+
+// WithEncryption encrypts the marshalled data with AES-GCM, which also protects it from being tampered with.
+// When unmarshalling, the same option is required; then only encrypted data is accepted,
+// and a BpmnEngineUnmarshallingError is returned, if the data was tampered with or can't be decrypted.
+// Since AES-GCM authenticates the data as a whole, MarshalTo and UnmarshalFrom hold the whole data in memory.
+func WithEncryption(keyProvider KeyProvider) MarshalOption {
+	return func(options *marshalOptions) {
+		options.keyProvider = keyProvider
+	}
+}
+
+// encryptedDataMarker starts encrypted data, which can't be the first bytes of JSON, nor protobuf encoded data.
+// It's followed by the key ID's length (1 byte), the key ID, the nonce and the encrypted data.
+var encryptedDataMarker = []byte{0, 'B', 'P', 'M', 'N', 'E', 1}
+
+const maxKeyIdLength = 255
+
+// isEncrypted peeks at the first bytes, without consuming them
+func isEncrypted(r *bufio.Reader) bool {
+	first, err := r.Peek(len(encryptedDataMarker))
+	return err == nil && bytes.Equal(first, encryptedDataMarker)
+}
+
+// marshalEncrypted writes the data of marshalFunc encrypted to the writer
+func marshalEncrypted(w io.Writer, keyProvider KeyProvider, marshalFunc func(w io.Writer) error) error {
+	plain := bytes.Buffer{}
+	if err := marshalFunc(&plain); err != nil {
+		return err
+	}
+	keyId, key, err := keyProvider.CurrentKey()
+	if err != nil {
+		return fmt.Errorf("can't get current encryption key: %w", err)
+	}
+	if len(keyId) > maxKeyIdLength {
+		return fmt.Errorf("key id is longer than %d bytes", maxKeyIdLength)
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return err
+	}
+	header := append([]byte{}, encryptedDataMarker...)
+	header = append(header, byte(len(keyId)))
+	header = append(header, keyId...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header = append(header, nonce...)
+	// the header is authenticated as well, so that the key ID can't be tampered with;
+	// Seal must not append to the header itself, since dst must not overlap the additional data
+	data := append(make([]byte, 0, len(header)+plain.Len()+aead.Overhead()), header...)
+	data = aead.Seal(data, nonce, plain.Bytes(), header)
+	_, err = w.Write(data)
+	return err
+}
+
+// unmarshalEncrypted reads all data and returns the decrypted data
+func unmarshalEncrypted(r io.Reader, keyProvider KeyProvider) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &BpmnEngineUnmarshallingError{Msg: "can't read encrypted data", Err: err}
+	}
+	keyIdOffset := len(encryptedDataMarker) + 1
+	if len(data) < keyIdOffset {
+		return nil, tamperedDataError(io.ErrUnexpectedEOF)
+	}
+	keyIdLength := int(data[keyIdOffset-1])
+	if len(data) < keyIdOffset+keyIdLength {
+		return nil, tamperedDataError(io.ErrUnexpectedEOF)
+	}
+	keyId := string(data[keyIdOffset : keyIdOffset+keyIdLength])
+	key, err := keyProvider.Key(keyId)
+	if err != nil {
+		return nil, &BpmnEngineUnmarshallingError{Msg: fmt.Sprintf("can't get decryption key with id=%s", keyId), Err: err}
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, &BpmnEngineUnmarshallingError{Msg: fmt.Sprintf("invalid decryption key with id=%s", keyId), Err: err}
+	}
+	nonceOffset := keyIdOffset + keyIdLength
+	if len(data) < nonceOffset+aead.NonceSize() {
+		return nil, tamperedDataError(io.ErrUnexpectedEOF)
+	}
+	header := data[:nonceOffset+aead.NonceSize()]
+	nonce := header[nonceOffset:]
+	plain, err := aead.Open(nil, nonce, data[len(header):], header)
+	if err != nil {
+		return nil, tamperedDataError(err)
+	}
+	return plain, nil
+}
+
+func tamperedDataError(err error) error {
+	return &BpmnEngineUnmarshallingError{
+		Msg: "can't decrypt data, it was likely tampered with or encrypted with another key",
+		Err: err,
+	}
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
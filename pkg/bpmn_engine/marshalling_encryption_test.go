@@ -0,0 +1,154 @@
+package bpmn_engine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+var testKeys = StaticKeyProvider{
+	CurrentKeyId: "key-1",
+	Keys: map[string][]byte{
+		"key-1": []byte("0123456789abcdef0123456789abcdef"),
+		"key-2": []byte("fedcba9876543210fedcba9876543210"),
+	},
+}
+
+func newEngineWithPersonalData(t *testing.T) (BpmnEngineState, *processInstanceInfo) {
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"email": "jane@example.com"})
+	then.AssertThat(t, err, is.Nil())
+	return bpmnEngine, instance
+}
+
+func Test_encrypted_data_can_be_unmarshalled(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine, instance := newEngineWithPersonalData(t)
+
+		// when
+		data := bpmnEngine.Marshal(append(options, WithEncryption(testKeys))...)
+		restored, err := Unmarshal(data, WithEncryption(testKeys))
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, bytes.Contains(data, []byte("jane@example.com")), is.False())
+		then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey).GetVariable("email"), is.EqualTo("jane@example.com"))
+	})
+}
+
+func Test_encrypted_data_can_be_unmarshalled_after_key_rotation(t *testing.T) {
+	// setup
+	bpmnEngine, instance := newEngineWithPersonalData(t)
+	data := bpmnEngine.Marshal(WithEncryption(testKeys))
+	rotatedKeys := StaticKeyProvider{CurrentKeyId: "key-2", Keys: testKeys.Keys}
+
+	// when
+	restored, err := Unmarshal(data, WithEncryption(rotatedKeys))
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey).GetVariable("email"), is.EqualTo("jane@example.com"))
+	then.AssertThat(t, bytes.Contains(restored.Marshal(WithEncryption(rotatedKeys)), []byte("key-2")), is.True())
+}
+
+func Test_tampered_encrypted_data_is_rejected(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+	data := bpmnEngine.Marshal(WithEncryption(testKeys))
+	keyIdPosition := len(encryptedDataMarker) + 1
+
+	tests := []struct {
+		name     string
+		position int
+	}{
+		{"marker", 1},
+		{"key id", keyIdPosition + 4},
+		{"nonce", keyIdPosition + len("key-1")},
+		{"encrypted data", len(data) / 2},
+		{"authentication tag", len(data) - 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tampered := bytes.Clone(data)
+			tampered[test.position] ^= 1
+
+			// when
+			_, err := Unmarshal(tampered, WithEncryption(testKeys))
+
+			// then
+			var unmarshallingError *BpmnEngineUnmarshallingError
+			then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+		})
+	}
+}
+
+func Test_truncated_encrypted_data_is_rejected(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+	data := bpmnEngine.Marshal(WithEncryption(testKeys))
+
+	for _, length := range []int{len(encryptedDataMarker), len(encryptedDataMarker) + 3, len(data) - 1} {
+		// when
+		_, err := Unmarshal(data[:length], WithEncryption(testKeys))
+
+		// then
+		var unmarshallingError *BpmnEngineUnmarshallingError
+		then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+	}
+}
+
+func Test_unencrypted_data_is_rejected_when_key_provider_is_given(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+
+	// when
+	_, err := Unmarshal(bpmnEngine.Marshal(), WithEncryption(testKeys))
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+}
+
+func Test_encrypted_data_is_rejected_without_key_provider(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+
+	// when
+	_, err := Unmarshal(bpmnEngine.Marshal(WithEncryption(testKeys)))
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+}
+
+func Test_encrypted_data_with_unknown_key_is_rejected(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+	data := bpmnEngine.Marshal(WithEncryption(testKeys))
+	otherKeys := StaticKeyProvider{CurrentKeyId: "key-2", Keys: map[string][]byte{"key-2": testKeys.Keys["key-2"]}}
+
+	// when
+	_, err := Unmarshal(data, WithEncryption(otherKeys))
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+}
+
+func Test_MarshalTo_returns_error_for_invalid_key(t *testing.T) {
+	// setup
+	bpmnEngine, _ := newEngineWithPersonalData(t)
+	invalidKeys := StaticKeyProvider{CurrentKeyId: "short", Keys: map[string][]byte{"short": []byte("too short")}}
+
+	// when
+	err := bpmnEngine.MarshalTo(&bytes.Buffer{}, WithEncryption(invalidKeys))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
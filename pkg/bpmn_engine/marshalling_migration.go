@@ -0,0 +1,161 @@
+package bpmn_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// serializerMigration upgrades a single serialized record from one serializer version to the next one.
+// Records are the elements of the arrays in serializedBpmnEngine, identified by the JSON field name,
+// e.g. "pi" for process instances. This way, migrating older data is still done record by record,
+// without reading the whole data into memory.
+type serializerMigration struct {
+	fromVersion int
+	// upgrade returns the migrated record; records of fields without changes are returned as is
+	upgrade func(field string, data json.RawMessage) (json.RawMessage, error)
+}
+
+// serializerMigrations must contain exactly one migration per version,
+// starting with version 1 up to CurrentSerializerVersion-1, in ascending order.
+var serializerMigrations = []serializerMigration{
+	{fromVersion: 1, upgrade: migrateV1ToV2},
+	{fromVersion: 2, upgrade: migrateV2ToV3},
+	{fromVersion: 3, upgrade: migrateV3ToV4},
+}
+
+// checkSerializerVersion returns an error for unknown versions, especially newer ones,
+// which were created by a newer version of this library
+func checkSerializerVersion(version int) error {
+	if version > CurrentSerializerVersion {
+		return fmt.Errorf("serializer version %d is newer than the supported version %d; "+
+			"the data was likely marshalled by a newer version of lib-bpmn-engine", version, CurrentSerializerVersion)
+	}
+	if version < 1 {
+		return fmt.Errorf("unknown serializer version %d", version)
+	}
+	return nil
+}
+
+// migrateRecord runs all migrations in a chain, to upgrade the record from the given version to CurrentSerializerVersion
+func migrateRecord(version int, field string, data json.RawMessage) (json.RawMessage, error) {
+	for _, migration := range serializerMigrations {
+		if migration.fromVersion < version {
+			continue
+		}
+		var err error
+		data, err = migration.upgrade(field, data)
+		if err != nil {
+			return nil, fmt.Errorf("can't migrate serialized data from version %d to %d: %w", migration.fromVersion, migration.fromVersion+1, err)
+		}
+	}
+	return data, nil
+}
+
+// migrateV1ToV2 removes the caught events from process instances, because in version 1,
+// those were always marshalled as empty objects, and thus can't be restored
+func migrateV1ToV2(field string, data json.RawMessage) (json.RawMessage, error) {
+	if field != "pi" {
+		return data, nil
+	}
+	var instance map[string]json.RawMessage
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return nil, err
+	}
+	if _, found := instance["ce"]; !found {
+		return data, nil
+	}
+	delete(instance, "ce")
+	return json.Marshal(instance)
+}
+
+// migrateV2ToV3 keeps integral numbers in variables as floats, because up to version 2,
+// all numbers were unmarshalled as float64, whereas since version 3, numbers without fraction become int
+func migrateV2ToV3(field string, data json.RawMessage) (json.RawMessage, error) {
+	if field != "pi" {
+		return data, nil
+	}
+	var instance map[string]json.RawMessage
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"vh", "ce"} { // the variable holder and the variables of caught events
+		raw, found := instance[key]
+		if !found {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		migrated, err := json.Marshal(numbersAsFloats(value))
+		if err != nil {
+			return nil, err
+		}
+		instance[key] = migrated
+	}
+	return json.Marshal(instance)
+}
+
+func numbersAsFloats(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			return json.Number(string(v) + ".0")
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = numbersAsFloats(element)
+		}
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = numbersAsFloats(element)
+		}
+	}
+	return value
+}
+
+// migrateV3ToV4 escapes maps in variables, which have keys starting with "$", but are no time or duration,
+// because since version 4, only single "$time", "$duration" or "$map" fields are tags, and "$map" wraps such maps;
+// version 4 also changed the protobuf encoding of variables, which older versions of this library can't read
+func migrateV3ToV4(field string, data json.RawMessage) (json.RawMessage, error) {
+	if field != "pi" || !bytes.Contains(data, []byte(`"$`)) {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var instance interface{}
+	if err := dec.Decode(&instance); err != nil {
+		return nil, err
+	}
+	return json.Marshal(escapeDollarMaps(instance))
+}
+
+func escapeDollarMaps(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, element := range v {
+			v[i] = escapeDollarMaps(element)
+		}
+	case map[string]interface{}:
+		if len(v) == 1 {
+			_, isTime := v[jsonTimeField].(string)
+			_, isDuration := v[jsonDurationField].(string)
+			if isTime || isDuration {
+				return v
+			}
+		}
+		escape := false
+		for key, element := range v {
+			v[key] = escapeDollarMaps(element)
+			escape = escape || strings.HasPrefix(key, "$")
+		}
+		if escape {
+			return map[string]interface{}{jsonMapField: v}
+		}
+	}
+	return value
+}
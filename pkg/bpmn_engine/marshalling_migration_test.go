@@ -0,0 +1,99 @@
+package bpmn_engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_serializer_migrations_cover_all_versions(t *testing.T) {
+	then.AssertThat(t, serializerMigrations, has.Length(CurrentSerializerVersion-1))
+	for i, migration := range serializerMigrations {
+		then.AssertThat(t, migration.fromVersion, is.EqualTo(i+1))
+	}
+}
+
+func Test_migrate_v1_removes_empty_caught_events(t *testing.T) {
+	// given
+	v1Instance := json.RawMessage(`{"pk":1,"ik":2,"s":"READY","ce":[{},{}]}`)
+
+	// when
+	migrated, err := migrateRecord(1, "pi", v1Instance)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(migrated), is.EqualTo(`{"ik":2,"pk":1,"s":"READY"}`))
+}
+
+func Test_migrate_current_version_keeps_record(t *testing.T) {
+	// given
+	instance := json.RawMessage(`{"pk":1,"ik":2,"s":"READY","ce":[{"n":"msg"}]}`)
+
+	// when
+	migrated, err := migrateRecord(CurrentSerializerVersion, "pi", instance)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(migrated), is.EqualTo(string(instance)))
+}
+
+func Test_Unmarshal_migrates_records_before_the_version_field(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	instance, _ := bpmnEngine.CreateInstance(process.ProcessKey, nil)
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(bpmnEngine.Marshal(), &fields)
+	fields["v"] = json.RawMessage(`1`)
+	fields["pi"] = json.RawMessage(`[{"pk":` + string(mustMarshal(process.ProcessKey)) + `,"ik":` + string(mustMarshal(instance.InstanceKey)) + `,"s":"READY","ce":[{}]}]`)
+
+	// when
+	restored, err := Unmarshal(mustMarshal(fields)) // hint: json.Marshal sorts fields alphabetically, so "v" is the last one
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey).CaughtEvents, has.Length(0))
+}
+
+func Test_Unmarshal_rejects_missing_version(t *testing.T) {
+	// when
+	_, err := Unmarshal([]byte(`{"n":"name"}`))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func Test_migrate_v2_keeps_integral_numbers_of_variables_as_floats(t *testing.T) {
+	// given
+	v2Instance := json.RawMessage(`{"pk":1,"ik":2,"s":"READY","vh":{"v":{"count":42,"items":[1,2.5]}},"ce":[{"n":"msg","v":{"id":7}}]}`)
+
+	// when
+	migrated, err := migrateRecord(2, "pi", v2Instance)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(migrated), is.EqualTo(`{"ce":[{"n":"msg","v":{"id":7.0}}],"ik":2,"pk":1,"s":"READY","vh":{"v":{"count":42.0,"items":[1.0,2.5]}}}`))
+}
+
+func Test_migrate_v3_escapes_maps_with_dollar_keys(t *testing.T) {
+	// given
+	v3Instance := json.RawMessage(`{"pk":1,"ik":2,"s":"READY","vh":{"v":{"start":{"$time":"2024-03-01T12:00:00Z"},"price":{"$map":{"a":1}},"query":{"$gt":5,"$lt":9}}}}`)
+
+	// when
+	migrated, err := migrateRecord(3, "pi", v3Instance)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, string(migrated), is.EqualTo(`{"ik":2,"pk":1,"s":"READY","vh":{"v":{"price":{"$map":{"$map":{"a":1}}},"query":{"$map":{"$gt":5,"$lt":9}},"start":{"$time":"2024-03-01T12:00:00Z"}}}}`))
+}
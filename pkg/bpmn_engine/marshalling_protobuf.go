@@ -0,0 +1,795 @@
+package bpmn_engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/ascii85"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/internal/statepb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The protobuf encoding follows the schema in internal/statepb/marshalling.proto, whose Go code is generated.
+// The engine's types have unexported fields and custom recovery logic, so they're converted from and to the
+// generated messages here. The EngineState message is streamed: each record is written as its own repeated field,
+// which is the same as marshalling the whole message at once, and read one by one, like the JSON encoding is.
+
+// protobufEncodingMarker is the first byte of the protobuf encoding (the tag of EngineState.version),
+// which can't be the first byte of a JSON document
+var protobufEncodingMarker = byte(protowire.EncodeTag(1, protowire.VarintType))
+
+// isProtobufEncoded peeks at the first byte, without consuming it
+func isProtobufEncoded(r *bufio.Reader) bool {
+	first, err := r.Peek(1)
+	return err == nil && first[0] == protobufEncodingMarker
+}
+
+// ----------------------------------------------------------------------------
+
+// protoVariableKeys interns the keys of all variables of a process instance,
+// so that each key is stored only once, no matter how many (nested) variables use it
+type protoVariableKeys struct {
+	keys []string
+	refs map[string]uint32
+}
+
+// ref returns the position of the key in keys, starting with 1
+func (vk *protoVariableKeys) ref(key string) uint32 {
+	if ref, found := vk.refs[key]; found {
+		return ref
+	}
+	if vk.refs == nil {
+		vk.refs = map[string]uint32{}
+	}
+	vk.keys = append(vk.keys, key)
+	vk.refs[key] = uint32(len(vk.keys))
+	return vk.refs[key]
+}
+
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return &timestamppb.Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
+
+// toProtoVariables converts the variables; keys are sorted, to have a deterministic output like JSON
+func toProtoVariables(variables map[string]interface{}, vk *protoVariableKeys) (*statepb.Variables, error) {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := &statepb.Variables{Entries: make([]*statepb.VariableEntry, len(keys))}
+	for i, key := range keys {
+		entry := &statepb.VariableEntry{KeyRef: vk.ref(key)}
+		if err := setProtoEntryValue(entry, variables[key], vk); err != nil {
+			return nil, err
+		}
+		result.Entries[i] = entry
+	}
+	return result, nil
+}
+
+// protoValueKind and protoEntryValue are the oneofs of Value and VariableEntry, whose fields have the same numbers,
+// so that both are converted by toProtoValue and fromProtoValue, see copyProtoOneof
+var (
+	protoValueKind  = (&statepb.Value{}).ProtoReflect().Descriptor().Oneofs().ByName("kind")
+	protoEntryValue = (&statepb.VariableEntry{}).ProtoReflect().Descriptor().Oneofs().ByName("value")
+)
+
+// copyProtoOneof sets the field of dst, which has the same number as the field set in the oneof of src
+func copyProtoOneof(dst protoreflect.Message, src protoreflect.Message, srcOneof protoreflect.OneofDescriptor) {
+	if field := src.WhichOneof(srcOneof); field != nil {
+		dst.Set(dst.Descriptor().Fields().ByNumber(field.Number()), src.Get(field))
+	}
+}
+
+// setProtoEntryValue sets the oneof value of the entry, see toProtoValue
+func setProtoEntryValue(entry *statepb.VariableEntry, v interface{}, vk *protoVariableKeys) error {
+	value, err := toProtoValue(v, vk)
+	if err != nil {
+		return err
+	}
+	copyProtoOneof(entry.ProtoReflect(), value.ProtoReflect(), protoValueKind)
+	return nil
+}
+
+// toProtoValue converts a variable; the value is normalized first (see normalizeVariable),
+// so that it's restored with the same type
+func toProtoValue(v interface{}, vk *protoVariableKeys) (result *statepb.Value, err error) {
+	if v, err = normalizeVariable(v); err != nil {
+		return nil, err
+	}
+	result = &statepb.Value{}
+	switch value := v.(type) {
+	case nil:
+		result.Kind = &statepb.Value_NullValue{NullValue: true}
+	case int:
+		result.Kind = &statepb.Value_IntValue{IntValue: int64(value)}
+	case float64:
+		switch kind, err := protoNumberKind(value); {
+		case err != nil:
+			return nil, err
+		case kind == protoIntegerNumber:
+			result.Kind = &statepb.Value_IntegerValue{IntegerValue: int64(value)}
+		case kind == protoFloatNumber:
+			result.Kind = &statepb.Value_FloatValue{FloatValue: float32(value)}
+		default:
+			result.Kind = &statepb.Value_NumberValue{NumberValue: value}
+		}
+	case string:
+		result.Kind = &statepb.Value_StringValue{StringValue: value}
+	case bool:
+		result.Kind = &statepb.Value_BoolValue{BoolValue: value}
+	case map[string]interface{}:
+		m, err := toProtoVariables(value, vk)
+		result.Kind = &statepb.Value_MapValue{MapValue: m}
+		return result, err
+	case []interface{}:
+		list, err := toProtoValueList(value, vk)
+		result.Kind = &statepb.Value_ListValue{ListValue: list}
+		return result, err
+	case time.Time:
+		result.Kind = &statepb.Value_TimeValue{TimeValue: value.Format(time.RFC3339Nano)}
+	case time.Duration:
+		result.Kind = &statepb.Value_DurationValue{DurationValue: int64(value)}
+	}
+	return result, nil
+}
+
+func toProtoValueList(values []interface{}, vk *protoVariableKeys) (*statepb.ValueList, error) {
+	list := &statepb.ValueList{Values: make([]*statepb.Value, len(values))}
+	for i, item := range values {
+		value, err := toProtoValue(item, vk)
+		if err != nil {
+			return nil, err
+		}
+		list.Values[i] = value
+	}
+	return list, nil
+}
+
+type protoNumber int
+
+const (
+	protoDoubleNumber  protoNumber = iota
+	protoIntegerNumber             // integral floats are varint encoded, which is way smaller than a double for most numbers
+	protoFloatNumber               // floats, which are exactly representable with 32 bits, take half the size of a double
+)
+
+func protoNumberKind(v float64) (protoNumber, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		// same as JSON, which would fail with an UnsupportedValueError
+		return protoDoubleNumber, fmt.Errorf("unsupported variable value: %v", v)
+	}
+	if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 && !(v == 0 && math.Signbit(v)) {
+		return protoIntegerNumber, nil
+	}
+	if float64(float32(v)) == v {
+		return protoFloatNumber, nil
+	}
+	return protoDoubleNumber, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// compressedData returns the flate compressed data, which is stored ascii85 encoded in the engine
+func compressedData(encoded string) ([]byte, error) {
+	return io.ReadAll(ascii85.NewDecoder(bytes.NewBufferString(encoded)))
+}
+
+func toProtoProcessReference(process *ProcessInfo) (*statepb.ProcessReference, error) {
+	compressed, err := compressedData(process.bpmnData)
+	return &statepb.ProcessReference{
+		BpmnProcessId:    process.BpmnProcessId,
+		ProcessKey:       process.ProcessKey,
+		BpmnData:         compressed,
+		BpmnResourceName: process.bpmnResourceName,
+	}, err
+}
+
+func toProtoDecisionResource(resource *decisionResource) (*statepb.DecisionResource, error) {
+	compressed, err := compressedData(resource.dmnData)
+	result := &statepb.DecisionResource{
+		DmnData:         compressed,
+		DmnResourceName: resource.resourceName,
+	}
+	for _, decision := range resource.decisions {
+		result.DecisionKeys = append(result.DecisionKeys, decision.DecisionKey)
+	}
+	return result, err
+}
+
+func toProtoProcessInstance(pi *processInstanceInfo) (result *statepb.ProcessInstance, err error) {
+	vk := &protoVariableKeys{}
+	result = &statepb.ProcessInstance{
+		ProcessKey:       pi.ProcessInfo.ProcessKey,
+		InstanceKey:      pi.InstanceKey,
+		CreatedAt:        toProtoTime(pi.CreatedAt),
+		State:            string(pi.ActivityState),
+		CompletedAt:      toProtoTime(pi.CompletedAt),
+		MessageSenderKey: pi.MessageSenderKey,
+	}
+	if result.VariableHolder, err = toProtoVariableHolder(&pi.VariableHolder, vk); err != nil {
+		return nil, err
+	}
+	for _, event := range pi.CaughtEvents {
+		ce := &statepb.CatchEvent{
+			Name:       event.Name,
+			CaughtAt:   toProtoTime(event.CaughtAt),
+			IsConsumed: event.IsConsumed,
+		}
+		if len(event.Variables) > 0 {
+			if ce.Variables, err = toProtoVariables(event.Variables, vk); err != nil {
+				return nil, err
+			}
+		}
+		result.CaughtEvents = append(result.CaughtEvents, ce)
+	}
+	adapters, err := createActivityAdapters(pi)
+	if err != nil {
+		return nil, err
+	}
+	for _, aa := range adapters {
+		result.Activities = append(result.Activities, &statepb.Activity{
+			Type:                      int32(aa.Type),
+			Key:                       aa.Key,
+			State:                     string(aa.State),
+			ElementId:                 aa.ElementReference,
+			Parallel:                  aa.Parallel,
+			InboundFlowIdsCompleted:   aa.InboundFlowIdsCompleted,
+			OutboundActivityCompleted: aa.OutboundActivityCompleted,
+			Satisfied:                 aa.Satisfied,
+			LoopCounter:               int64(aa.LoopCounter),
+		})
+	}
+	for _, ca := range pi.CompensableActivities {
+		pca := &statepb.CompensableActivity{
+			ElementId: ca.ElementId,
+			Key:       ca.Key,
+			State:     string(ca.State),
+			ThrowerId: ca.ThrowerId,
+		}
+		if len(ca.Variables) > 0 {
+			if pca.Variables, err = toProtoVariables(ca.Variables, vk); err != nil {
+				return nil, err
+			}
+		}
+		result.CompensableActivities = append(result.CompensableActivities, pca)
+	}
+	result.VariableKeys = vk.keys
+	return result, nil
+}
+
+func toProtoVariableHolder(vh *VariableHolder, vk *protoVariableKeys) (result *statepb.VariableHolder, err error) {
+	result = &statepb.VariableHolder{}
+	if vh.parent != nil {
+		if result.Parent, err = toProtoVariableHolder(vh.parent, vk); err != nil {
+			return nil, err
+		}
+	}
+	if len(vh.variables) > 0 {
+		if result.Variables, err = toProtoVariables(vh.variables, vk); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func toProtoActivitySurrogate(a activity) *statepb.ActivitySurrogate {
+	surrogate := createActivitySurrogate(a)
+	if surrogate.ElementReferenceId == "" {
+		return nil
+	}
+	return &statepb.ActivitySurrogate{
+		Key:       surrogate.ActivityKey,
+		State:     string(surrogate.ActivityState),
+		ElementId: surrogate.ElementReferenceId,
+	}
+}
+
+func toProtoMessageSubscription(ms *MessageSubscription) (*statepb.MessageSubscription, error) {
+	return &statepb.MessageSubscription{
+		ElementId:          ms.ElementId,
+		ElementInstanceKey: ms.ElementInstanceKey,
+		ProcessKey:         ms.ProcessKey,
+		ProcessInstanceKey: ms.ProcessInstanceKey,
+		Name:               ms.Name,
+		State:              string(ms.MessageState),
+		CreatedAt:          toProtoTime(ms.CreatedAt),
+		OriginActivity:     toProtoActivitySurrogate(ms.originActivity),
+	}, nil
+}
+
+func toProtoTimer(t *Timer) (*statepb.Timer, error) {
+	return &statepb.Timer{
+		ElementId:          t.ElementId,
+		ElementInstanceKey: t.ElementInstanceKey,
+		ProcessKey:         t.ProcessKey,
+		ProcessInstanceKey: t.ProcessInstanceKey,
+		State:              string(t.TimerState),
+		CreatedAt:          toProtoTime(t.CreatedAt),
+		DueAt:              toProtoTime(t.DueAt),
+		Duration:           int64(t.Duration),
+		OriginActivity:     toProtoActivitySurrogate(t.originActivity),
+	}, nil
+}
+
+func toProtoJob(j *job) (*statepb.Job, error) {
+	return &statepb.Job{
+		ElementId:          j.ElementId,
+		ElementInstanceKey: j.ElementInstanceKey,
+		ProcessInstanceKey: j.ProcessInstanceKey,
+		JobKey:             j.JobKey,
+		State:              string(j.JobState),
+		CreatedAt:          toProtoTime(j.CreatedAt),
+	}, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// protoStreamWriter writes the EngineState message record by record;
+// the first error is remembered and all further writes are skipped
+type protoStreamWriter struct {
+	w   io.Writer
+	err error
+	buf []byte
+}
+
+func (sw *protoStreamWriter) write(state *statepb.EngineState) {
+	if sw.err != nil {
+		return
+	}
+	if sw.buf, sw.err = (proto.MarshalOptions{}).MarshalAppend(sw.buf[:0], state); sw.err != nil {
+		return
+	}
+	_, sw.err = sw.w.Write(sw.buf)
+}
+
+// writeProtoRecords writes each item as an EngineState message, which has just this item in its repeated field;
+// concatenated, these messages are the same as the whole EngineState message
+func writeProtoRecords[T any, M proto.Message](sw *protoStreamWriter, items []T, toProto func(item T) (M, error), record func(m M) *statepb.EngineState) {
+	for _, item := range items {
+		if sw.err != nil {
+			return
+		}
+		m, err := toProto(item)
+		if err != nil {
+			sw.err = err
+			return
+		}
+		sw.write(record(m))
+	}
+}
+
+func (state *BpmnEngineState) marshalProtobufTo(w io.Writer) error {
+	sw := protoStreamWriter{w: w}
+	sw.write(&statepb.EngineState{Version: CurrentSerializerVersion, Name: state.name})
+	writeProtoRecords(&sw, state.processes, toProtoProcessReference, func(m *statepb.ProcessReference) *statepb.EngineState {
+		return &statepb.EngineState{Processes: []*statepb.ProcessReference{m}}
+	})
+	writeProtoRecords(&sw, state.instances(), toProtoProcessInstance, func(m *statepb.ProcessInstance) *statepb.EngineState {
+		return &statepb.EngineState{ProcessInstances: []*statepb.ProcessInstance{m}}
+	})
+	writeProtoRecords(&sw, state.messageSubscriptions.records(), toProtoMessageSubscription, func(m *statepb.MessageSubscription) *statepb.EngineState {
+		return &statepb.EngineState{MessageSubscriptions: []*statepb.MessageSubscription{m}}
+	})
+	writeProtoRecords(&sw, state.timers.records(), toProtoTimer, func(m *statepb.Timer) *statepb.EngineState {
+		return &statepb.EngineState{Timers: []*statepb.Timer{m}}
+	})
+	writeProtoRecords(&sw, state.jobs.records(), toProtoJob, func(m *statepb.Job) *statepb.EngineState {
+		return &statepb.EngineState{Jobs: []*statepb.Job{m}}
+	})
+	writeProtoRecords(&sw, state.decisionResources, toProtoDecisionResource, func(m *statepb.DecisionResource) *statepb.EngineState {
+		return &statepb.EngineState{DecisionResources: []*statepb.DecisionResource{m}}
+	})
+	return sw.err
+}
+
+// ----------------------------------------------------------------------------
+
+func fromProtoTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Unix(t.GetSeconds(), int64(t.GetNanos()))
+}
+
+// asciiData returns the compressed data ascii85 encoded, like it's stored in the engine
+func asciiData(compressed []byte) string {
+	buffer := bytes.Buffer{}
+	ascii85Writer := ascii85.NewEncoder(&buffer)
+	_, _ = ascii85Writer.Write(compressed)
+	_ = ascii85Writer.Close()
+	return buffer.String()
+}
+
+func fromProtoVariables(variables *statepb.Variables, keys []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(variables.GetEntries()))
+	for _, entry := range variables.GetEntries() {
+		key := entry.GetKey()
+		if ref := entry.GetKeyRef(); ref > 0 {
+			if int(ref) > len(keys) {
+				return nil, fmt.Errorf("variable key reference %d is out of range, there are %d keys", ref, len(keys))
+			}
+			key = keys[ref-1]
+		}
+		protoValue := &statepb.Value{}
+		copyProtoOneof(protoValue.ProtoReflect(), entry.ProtoReflect(), protoEntryValue)
+		value, err := fromProtoValue(protoValue, keys)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// fromProtoValue converts a variable, see toProtoValue
+func fromProtoValue(value *statepb.Value, keys []string) (interface{}, error) {
+	switch kind := value.GetKind().(type) {
+	case *statepb.Value_IntegerValue:
+		return float64(kind.IntegerValue), nil
+	case *statepb.Value_NumberValue:
+		return kind.NumberValue, nil
+	case *statepb.Value_FloatValue:
+		return float64(kind.FloatValue), nil
+	case *statepb.Value_StringValue:
+		return kind.StringValue, nil
+	case *statepb.Value_BoolValue:
+		return kind.BoolValue, nil
+	case *statepb.Value_MapValue:
+		return fromProtoVariables(kind.MapValue, keys)
+	case *statepb.Value_ListValue:
+		return fromProtoValueList(kind.ListValue, keys)
+	case *statepb.Value_IntValue:
+		return int(kind.IntValue), nil
+	case *statepb.Value_TimeValue:
+		return time.Parse(time.RFC3339Nano, kind.TimeValue)
+	case *statepb.Value_DurationValue:
+		return time.Duration(kind.DurationValue), nil
+	}
+	// null values, as well as unknown ones of newer data
+	return nil, nil
+}
+
+func fromProtoValueList(list *statepb.ValueList, keys []string) ([]interface{}, error) {
+	result := make([]interface{}, len(list.GetValues()))
+	for i, item := range list.GetValues() {
+		value, err := fromProtoValue(item, keys)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func fromProtoProcessReference(m *statepb.ProcessReference) processInfoReference {
+	return processInfoReference{
+		BpmnProcessId:    m.GetBpmnProcessId(),
+		ProcessKey:       m.GetProcessKey(),
+		BpmnData:         asciiData(m.GetBpmnData()),
+		BpmnResourceName: m.GetBpmnResourceName(),
+	}
+}
+
+func fromProtoDecisionResource(m *statepb.DecisionResource) decisionResourceReference {
+	return decisionResourceReference{
+		DecisionKeys:    m.GetDecisionKeys(),
+		DmnData:         asciiData(m.GetDmnData()),
+		DmnResourceName: m.GetDmnResourceName(),
+	}
+}
+
+func fromProtoProcessInstance(m *statepb.ProcessInstance) (pi *processInstanceInfo, err error) {
+	keys := m.GetVariableKeys()
+	pi = &processInstanceInfo{
+		ProcessInfo:      &ProcessInfo{ProcessKey: m.GetProcessKey()},
+		InstanceKey:      m.GetInstanceKey(),
+		VariableHolder:   VariableHolder{variables: map[string]interface{}{}},
+		CreatedAt:        fromProtoTime(m.GetCreatedAt()),
+		ActivityState:    ActivityState(m.GetState()),
+		CompletedAt:      fromProtoTime(m.GetCompletedAt()),
+		MessageSenderKey: m.GetMessageSenderKey(),
+	}
+	if m.GetVariableHolder() != nil {
+		if err = fromProtoVariableHolder(m.GetVariableHolder(), &pi.VariableHolder, keys); err != nil {
+			return pi, err
+		}
+	}
+	for _, ce := range m.GetCaughtEvents() {
+		event := catchEvent{
+			Name:       ce.GetName(),
+			CaughtAt:   fromProtoTime(ce.GetCaughtAt()),
+			IsConsumed: ce.GetIsConsumed(),
+		}
+		if ce.GetVariables() != nil {
+			if event.Variables, err = fromProtoVariables(ce.GetVariables(), keys); err != nil {
+				return pi, err
+			}
+		}
+		pi.CaughtEvents = append(pi.CaughtEvents, event)
+	}
+	adapter := &processInstanceInfoAdapter{}
+	for _, a := range m.GetActivities() {
+		adapter.ActivityAdapters = append(adapter.ActivityAdapters, &activityAdapter{
+			Type:                      activityAdapterType(a.GetType()),
+			Key:                       a.GetKey(),
+			State:                     ActivityState(a.GetState()),
+			ElementReference:          a.GetElementId(),
+			Parallel:                  a.GetParallel(),
+			InboundFlowIdsCompleted:   a.GetInboundFlowIdsCompleted(),
+			OutboundActivityCompleted: a.GetOutboundActivityCompleted(),
+			Satisfied:                 a.GetSatisfied(),
+			LoopCounter:               int(a.GetLoopCounter()),
+		})
+	}
+	for _, pca := range m.GetCompensableActivities() {
+		ca := compensableActivity{
+			ElementId: pca.GetElementId(),
+			Key:       pca.GetKey(),
+			State:     ActivityState(pca.GetState()),
+			ThrowerId: pca.GetThrowerId(),
+		}
+		if pca.GetVariables() != nil {
+			if ca.Variables, err = fromProtoVariables(pca.GetVariables(), keys); err != nil {
+				return pi, err
+			}
+		}
+		pi.CompensableActivities = append(pi.CompensableActivities, ca)
+	}
+	return pi, recoverProcessInstanceActivitiesPart1(pi, adapter)
+}
+
+func fromProtoVariableHolder(m *statepb.VariableHolder, vh *VariableHolder, keys []string) (err error) {
+	if m.GetParent() != nil {
+		vh.parent = &VariableHolder{}
+		if err = fromProtoVariableHolder(m.GetParent(), vh.parent, keys); err != nil {
+			return err
+		}
+	}
+	vh.variables = map[string]interface{}{}
+	if m.GetVariables() != nil {
+		vh.variables, err = fromProtoVariables(m.GetVariables(), keys)
+	}
+	return err
+}
+
+func fromProtoActivitySurrogate(m *statepb.ActivitySurrogate) activitySurrogate {
+	return activitySurrogate{
+		ActivityKey:        m.GetKey(),
+		ActivityState:      ActivityState(m.GetState()),
+		ElementReferenceId: m.GetElementId(),
+	}
+}
+
+func fromProtoMessageSubscription(m *statepb.MessageSubscription) *MessageSubscription {
+	return &MessageSubscription{
+		ElementId:          m.GetElementId(),
+		ElementInstanceKey: m.GetElementInstanceKey(),
+		ProcessKey:         m.GetProcessKey(),
+		ProcessInstanceKey: m.GetProcessInstanceKey(),
+		Name:               m.GetName(),
+		MessageState:       ActivityState(m.GetState()),
+		CreatedAt:          fromProtoTime(m.GetCreatedAt()),
+		originActivity:     fromProtoActivitySurrogate(m.GetOriginActivity()),
+	}
+}
+
+func fromProtoTimer(m *statepb.Timer) *Timer {
+	return &Timer{
+		ElementId:          m.GetElementId(),
+		ElementInstanceKey: m.GetElementInstanceKey(),
+		ProcessKey:         m.GetProcessKey(),
+		ProcessInstanceKey: m.GetProcessInstanceKey(),
+		TimerState:         TimerState(m.GetState()),
+		CreatedAt:          fromProtoTime(m.GetCreatedAt()),
+		DueAt:              fromProtoTime(m.GetDueAt()),
+		Duration:           time.Duration(m.GetDuration()),
+		originActivity:     fromProtoActivitySurrogate(m.GetOriginActivity()),
+	}
+}
+
+func fromProtoJob(m *statepb.Job) *job {
+	return &job{
+		ElementId:          m.GetElementId(),
+		ElementInstanceKey: m.GetElementInstanceKey(),
+		ProcessInstanceKey: m.GetProcessInstanceKey(),
+		JobKey:             m.GetJobKey(),
+		JobState:           ActivityState(m.GetState()),
+		CreatedAt:          fromProtoTime(m.GetCreatedAt()),
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// protoRecord is a single top level field of the EngineState message
+type protoRecord struct {
+	num    protowire.Number
+	number uint64
+	data   []byte
+	offset int64
+}
+
+// protoStreamReader reads the EngineState message field by field
+// and counts the bytes read, to report the offset of corrupt data
+type protoStreamReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+func (sr *protoStreamReader) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err == nil {
+		sr.offset++
+	}
+	return b, err
+}
+
+// readRecord returns io.EOF, when there are no more fields
+func (sr *protoStreamReader) readRecord() (protoRecord, error) {
+	record := protoRecord{offset: sr.offset}
+	tag, err := binary.ReadUvarint(sr)
+	if err == io.EOF {
+		return record, err
+	}
+	if err != nil {
+		return record, unmarshallingErrorAt(sr.offset, err)
+	}
+	num, typ := protowire.DecodeTag(tag)
+	if num < protowire.MinValidNumber {
+		return record, unmarshallingErrorAt(sr.offset, fmt.Errorf("invalid field number %d", num))
+	}
+	record.num = num
+	switch typ {
+	case protowire.VarintType:
+		record.number, err = binary.ReadUvarint(sr)
+	case protowire.BytesType:
+		var length uint64
+		length, err = binary.ReadUvarint(sr)
+		if err == nil && length > math.MaxInt64 {
+			err = fmt.Errorf("invalid length %d of field %d", length, num)
+		}
+		if err == nil {
+			// copying instead of allocating the whole length at once, which could be arbitrary large in corrupt data
+			buffer := bytes.Buffer{}
+			var n int64
+			n, err = io.CopyN(&buffer, sr.r, int64(length))
+			sr.offset += n
+			record.data = buffer.Bytes()
+		}
+	default:
+		err = fmt.Errorf("unexpected wire type %d of field %d", typ, num)
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return record, unmarshallingErrorAt(sr.offset, err)
+	}
+	return record, nil
+}
+
+func unmarshalProtobufFrom(r *bufio.Reader, engineOptions []EngineOption) (BpmnEngineState, error) {
+	state := New(engineOptions...)
+	sr := protoStreamReader{r: r}
+	ru := protoRecordUnmarshaller{state: &state}
+	var pendingRecords []protoRecord // records, read before the version was known
+	for {
+		record, err := sr.readRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return state, err
+		}
+		switch record.num {
+		case 1:
+			ru.version = int(record.number)
+			if err := checkSerializerVersion(ru.version); err != nil {
+				return state, unmarshallingErrorAt(record.offset, err)
+			}
+			if ru.version < firstProtobufSerializerVersion {
+				return state, unmarshallingErrorAt(record.offset, fmt.Errorf(
+					"the protobuf encoding doesn't exist in serializer version %d", ru.version))
+			}
+			for _, pending := range pendingRecords {
+				if err := ru.unmarshal(pending); err != nil {
+					return state, err
+				}
+			}
+			pendingRecords = nil
+		case 2:
+			state.name = string(record.data)
+		default:
+			if ru.version == 0 {
+				pendingRecords = append(pendingRecords, record)
+				continue
+			}
+			if err := ru.unmarshal(record); err != nil {
+				return state, err
+			}
+		}
+	}
+	if ru.version == 0 {
+		return state, &BpmnEngineUnmarshallingError{Msg: "missing serializer version, the data is likely not a marshalled BPMN engine"}
+	}
+	return state, recoverState(&state, ru.offsets)
+}
+
+// firstProtobufSerializerVersion is the serializer version, which introduced the protobuf encoding;
+// later versions are compatible, because they only added fields to the schema
+const firstProtobufSerializerVersion = 2
+
+// protoRecordUnmarshaller decodes protobuf records into the engine's state, like recordUnmarshaller does for JSON
+type protoRecordUnmarshaller struct {
+	state   *BpmnEngineState
+	version int
+	offsets recordOffsets
+}
+
+func (ru *protoRecordUnmarshaller) unmarshal(record protoRecord) error {
+	var err error
+	switch record.num {
+	case 3:
+		m := &statepb.ProcessReference{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		return recoverProcess(ru.state, fromProtoProcessReference(m), record.offset)
+	case 4:
+		m := &statepb.ProcessInstance{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		var pi *processInstanceInfo
+		if pi, err = fromProtoProcessInstance(m); err != nil {
+			break
+		}
+		ru.offsets.processInstances = append(ru.offsets.processInstances, record.offset)
+		ru.state.addProcessInstance(pi)
+	case 5:
+		m := &statepb.MessageSubscription{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		ru.offsets.messageSubscriptions = append(ru.offsets.messageSubscriptions, record.offset)
+		ru.state.messageSubscriptions.add(fromProtoMessageSubscription(m))
+	case 6:
+		m := &statepb.Timer{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		ru.offsets.timers = append(ru.offsets.timers, record.offset)
+		ru.state.timers.add(fromProtoTimer(m))
+	case 7:
+		m := &statepb.Job{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		ru.offsets.jobs = append(ru.offsets.jobs, record.offset)
+		ru.state.jobs.add(fromProtoJob(m))
+	case 8:
+		m := &statepb.DecisionResource{}
+		if err = proto.Unmarshal(record.data, m); err != nil {
+			break
+		}
+		return recoverDecisions(ru.state, fromProtoDecisionResource(m), record.offset)
+	}
+	if err != nil {
+		return unmarshallingErrorAt(record.offset, err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package bpmn_engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/internal/statepb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+type customerVariable struct {
+	Name    string   `json:"name"`
+	Ratings []int    `json:"ratings"`
+	Premium *float32 `json:"premium"`
+}
+
+func Test_protobuf_encoding_restores_the_same_state_as_json(t *testing.T) {
+	tests := []struct {
+		bpmnFile string
+		message  string
+	}{
+		{"simple_task.bpmn", ""},
+		{"message-intermediate-timer-event.bpmn", "message"},
+		{"message-EventBasedGateway.bpmn", ""},
+		{"parallel-gateway-flow.bpmn", ""},
+		{"message-intermediate-catch-event-and-parallel-tasks.bpmn", "event-1"},
+	}
+	for _, test := range tests {
+		t.Run(test.bpmnFile, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/" + test.bpmnFile)
+			then.AssertThat(t, err, is.Nil())
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+				"name":     "world",
+				"count":    42,
+				"price":    float32(0.1),
+				"amount":   1.5,
+				"ratio":    0.1,
+				"approved": true,
+				"nothing":  nil,
+				"items":    []interface{}{1, "two", map[string]interface{}{"three": 3.0, "name": "three"}},
+				"tags":     []string{"a", "b"},
+				"customer": customerVariable{Name: "john", Ratings: []int{1, 2}},
+			})
+			then.AssertThat(t, err, is.Nil())
+			if test.message != "" {
+				err = bpmnEngine.PublishEventForInstance(instance.InstanceKey, test.message, map[string]interface{}{"foo": "bar"})
+				then.AssertThat(t, err, is.Nil())
+			}
+
+			// when
+			fromJson, err := Unmarshal(bpmnEngine.Marshal())
+			then.AssertThat(t, err, is.Nil())
+			fromProtobuf, err := Unmarshal(bpmnEngine.Marshal(WithProtobufEncoding()))
+			then.AssertThat(t, err, is.Nil())
+
+			// then
+			then.AssertThat(t, string(fromProtobuf.Marshal()), is.EqualTo(string(fromJson.Marshal())))
+			then.AssertThat(t, fromProtobuf.FindProcessInstance(instance.InstanceKey).GetVariable("items"),
+				is.EqualTo(fromJson.FindProcessInstance(instance.InstanceKey).GetVariable("items")))
+		})
+	}
+}
+
+func Test_protobuf_encoding_is_smaller_than_json(t *testing.T) {
+	for _, bpmnFile := range []string{"simple_task.bpmn", "message-intermediate-timer-event.bpmn", "parallel-gateway-flow.bpmn"} {
+		t.Run(bpmnFile, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			process, _ := bpmnEngine.LoadFromFile("../../test-cases/" + bpmnFile)
+			_, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+				"orderId":  123456789,
+				"customer": "Jane <jane@example.com>",
+			})
+			then.AssertThat(t, err, is.Nil())
+
+			// when
+			jsonData := bpmnEngine.Marshal()
+			protobufData := bpmnEngine.Marshal(WithProtobufEncoding())
+
+			// then
+			then.AssertThat(t, len(protobufData), is.LessThan(len(jsonData)*3/4))
+		})
+	}
+}
+
+func Test_protobuf_encoding_is_smaller_than_json_for_large_variable_maps(t *testing.T) {
+	// setup
+	bpmnEngine := newEngineWithLargeVariableMap(t, 1000)
+
+	// when
+	jsonData := bpmnEngine.Marshal()
+	protobufData := bpmnEngine.Marshal(WithProtobufEncoding())
+
+	// then
+	// the keys of variables are stored once per instance, and 1.5 etc. as float instead of double
+	then.AssertThat(t, len(protobufData), is.LessThan(len(jsonData)*4/5))
+}
+
+func Test_protobuf_unmarshal_rejects_unknown_variable_key_references(t *testing.T) {
+	// setup
+	data, err := proto.Marshal(&statepb.EngineState{
+		Version: CurrentSerializerVersion,
+		ProcessInstances: []*statepb.ProcessInstance{{
+			VariableKeys: []string{"name"},
+			VariableHolder: &statepb.VariableHolder{Variables: &statepb.Variables{Entries: []*statepb.VariableEntry{
+				{KeyRef: 2, Value: &statepb.VariableEntry_StringValue{StringValue: "world"}},
+			}}},
+		}},
+	})
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	_, err = Unmarshal(data)
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+	then.AssertThat(t, unmarshallingError.Offset, is.EqualTo(int64(2)))
+	then.AssertThat(t, err.Error(), is.ValueContaining("variable key reference 2 is out of range"))
+}
+
+func Test_protobuf_MarshalTo_writes_same_data_as_Marshal(t *testing.T) {
+	// setup
+	bpmnEngine := newEngineWithLargeVariableMap(t, 10)
+
+	// when
+	buffer := bytes.Buffer{}
+	err := bpmnEngine.MarshalTo(&buffer, WithProtobufEncoding())
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, buffer.Bytes(), is.EqualTo(bpmnEngine.Marshal(WithProtobufEncoding())))
+}
+
+func Test_protobuf_unmarshal_reports_position_of_corrupt_data(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	data := bpmnEngine.Marshal(WithProtobufEncoding())
+
+	// when
+	_, err := Unmarshal(data[:len(data)-3])
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+	then.AssertThat(t, unmarshallingError.Offset, is.EqualTo(int64(len(data)-3)))
+}
+
+func Test_protobuf_unmarshal_rejects_newer_serializer_version(t *testing.T) {
+	// setup
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, CurrentSerializerVersion+1)
+
+	// when
+	_, err := Unmarshal(data)
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+	then.AssertThat(t, err.Error(), has.Prefix("corrupt data at offset 0: serializer version"))
+}
+
+func newEngineWithLargeVariableMap(t testing.TB, size int) BpmnEngineState {
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	variables := map[string]interface{}{}
+	for i := 0; i < size; i++ {
+		variables[fmt.Sprintf("variable-%d", i)] = map[string]interface{}{
+			"id":     i,
+			"amount": float64(i) * 1.5,
+			"active": i%2 == 0,
+			"label":  fmt.Sprintf("label %d", i),
+		}
+	}
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, variables)
+	then.AssertThat(t, err, is.Nil())
+	return bpmnEngine
+}
+
+func Benchmark_Marshal_json(b *testing.B) {
+	bpmnEngine := newEngineWithLargeVariableMap(b, 10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bpmnEngine.Marshal()
+	}
+}
+
+func Benchmark_Marshal_protobuf(b *testing.B) {
+	bpmnEngine := newEngineWithLargeVariableMap(b, 10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bpmnEngine.Marshal(WithProtobufEncoding())
+	}
+}
+
+func Benchmark_Unmarshal_json(b *testing.B) {
+	bpmnEngine := newEngineWithLargeVariableMap(b, 10_000)
+	data := bpmnEngine.Marshal()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Unmarshal(data)
+	}
+}
+
+func Benchmark_Unmarshal_protobuf(b *testing.B) {
+	bpmnEngine := newEngineWithLargeVariableMap(b, 10_000)
+	data := bpmnEngine.Marshal(WithProtobufEncoding())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Unmarshal(data)
+	}
+}
+
+func Test_protobuf_variable_entry_values_have_the_same_field_numbers_as_values(t *testing.T) {
+	// given
+	kindFields := protoValueKind.Fields()
+	entryFields := protoEntryValue.Fields()
+
+	// then
+	then.AssertThat(t, entryFields.Len(), is.EqualTo(kindFields.Len()))
+	for i := 0; i < kindFields.Len(); i++ {
+		entryField := entryFields.ByNumber(kindFields.Get(i).Number())
+		then.AssertThat(t, entryField, is.Not(is.Nil()))
+		then.AssertThat(t, entryField.Name(), is.EqualTo(kindFields.Get(i).Name()))
+	}
+}
@@ -0,0 +1,199 @@
+package bpmn_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonStreamWriter writes a JSON document piece by piece;
+// the first error is remembered and all further writes are skipped
+type jsonStreamWriter struct {
+	w          io.Writer
+	err        error
+	fieldCount int
+}
+
+func (sw *jsonStreamWriter) write(s string) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = io.WriteString(sw.w, s)
+}
+
+func (sw *jsonStreamWriter) writeValue(v interface{}) {
+	if sw.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		sw.err = err
+		return
+	}
+	_, sw.err = sw.w.Write(data)
+}
+
+func (sw *jsonStreamWriter) writeFieldName(name string) {
+	if sw.fieldCount > 0 {
+		sw.write(",")
+	}
+	sw.fieldCount++
+	sw.writeValue(name)
+	sw.write(":")
+}
+
+func (sw *jsonStreamWriter) writeField(name string, v interface{}) {
+	sw.writeFieldName(name)
+	sw.writeValue(v)
+}
+
+// writeArrayField writes each item on its own; empty arrays are omitted (like 'omitempty' does)
+func writeArrayField[T any](sw *jsonStreamWriter, name string, items []T) {
+	if len(items) == 0 {
+		return
+	}
+	sw.writeFieldName(name)
+	sw.write("[")
+	for i, item := range items {
+		if i > 0 {
+			sw.write(",")
+		}
+		sw.writeValue(item)
+	}
+	sw.write("]")
+}
+
+// ----------------------------------------------------------------------------
+
+// jsonStreamReader reads a JSON document piece by piece
+// and converts all errors into BpmnEngineUnmarshallingError, incl. the byte offset of the corrupt data
+type jsonStreamReader struct {
+	dec *json.Decoder
+}
+
+// readObject reads a JSON object and calls fieldFunc for each field name,
+// which is responsible to read (or skip) the field's value
+func (sr *jsonStreamReader) readObject(fieldFunc func(name string) error) error {
+	if err := sr.expectDelim('{'); err != nil {
+		return err
+	}
+	for sr.dec.More() {
+		token, err := sr.dec.Token()
+		if err != nil {
+			return sr.tokenError(err)
+		}
+		name, ok := token.(string)
+		if !ok {
+			return unmarshallingErrorAt(sr.dec.InputOffset(), fmt.Errorf("expected field name, but found %v", token))
+		}
+		if err := fieldFunc(name); err != nil {
+			return err
+		}
+	}
+	return sr.expectDelim('}')
+}
+
+// readArray reads a JSON array and calls elementFunc for each element,
+// which is responsible to decode the element
+func (sr *jsonStreamReader) readArray(elementFunc func() error) error {
+	if err := sr.expectDelim('['); err != nil {
+		return err
+	}
+	for sr.dec.More() {
+		if err := elementFunc(); err != nil {
+			return err
+		}
+	}
+	return sr.expectDelim(']')
+}
+
+// decode reads the next value and returns the byte offset, where the value starts
+func (sr *jsonStreamReader) decode(v interface{}) (int64, error) {
+	data, offset, err := sr.readRaw()
+	if err != nil {
+		return offset, err
+	}
+	return offset, unmarshalRecordData(data, offset, true, v)
+}
+
+// readRaw reads the next value without decoding it and returns the byte offset, where the value starts
+func (sr *jsonStreamReader) readRaw() (json.RawMessage, int64, error) {
+	var data json.RawMessage
+	if err := sr.dec.Decode(&data); err != nil {
+		return nil, sr.dec.InputOffset(), unmarshallingErrorAt(sr.errorOffset(err), err)
+	}
+	return data, sr.dec.InputOffset() - int64(len(data)), nil
+}
+
+// unmarshalRecordData decodes the data, which was read from the given offset;
+// with exactOffsets=false, errors refer to the offset only (e.g. when the data was modified after reading)
+func unmarshalRecordData(data json.RawMessage, offset int64, exactOffsets bool, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeError *json.UnmarshalTypeError
+		if exactOffsets && errors.As(err, &typeError) {
+			return unmarshallingErrorAt(offset+typeError.Offset, err)
+		}
+		return unmarshallingErrorAt(offset, err)
+	}
+	return nil
+}
+
+func (sr *jsonStreamReader) skip() error {
+	var ignored json.RawMessage
+	_, err := sr.decode(&ignored)
+	return err
+}
+
+func (sr *jsonStreamReader) expectDelim(delim json.Delim) error {
+	token, err := sr.dec.Token()
+	if err != nil {
+		return sr.tokenError(err)
+	}
+	if token != delim {
+		return unmarshallingErrorAt(sr.dec.InputOffset(), fmt.Errorf("expected '%s', but found %v", delim, token))
+	}
+	return nil
+}
+
+func (sr *jsonStreamReader) tokenError(err error) error {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		// tokens point to the invalid character, instead of behind it
+		return unmarshallingErrorAt(syntaxError.Offset+1, err)
+	}
+	return unmarshallingErrorAt(sr.errorOffset(err), err)
+}
+
+// errorOffset calculates the absolute byte offset of a decoding error,
+// because the json.Decoder reports offsets of syntax errors relative to the value.
+// Like json.SyntaxError, the offset is the number of bytes read, before the error was detected.
+func (sr *jsonStreamReader) errorOffset(err error) int64 {
+	var syntaxError *json.SyntaxError
+	valueStart := sr.dec.InputOffset()
+	buffered, _ := io.ReadAll(sr.dec.Buffered())
+	if errors.As(err, &syntaxError) {
+		// the decoder did not consume the broken value, so it's still buffered and can be scanned again
+		var ignored json.RawMessage
+		if err := json.Unmarshal(buffered, &ignored); errors.As(err, &syntaxError) {
+			return valueStart + syntaxError.Offset
+		}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return valueStart + int64(len(buffered))
+	}
+	return valueStart
+}
+
+// unmarshallingErrorAt wraps the error into a BpmnEngineUnmarshallingError with the given offset
+func unmarshallingErrorAt(offset int64, err error) error {
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	if errors.As(err, &unmarshallingError) && unmarshallingError.Offset > 0 {
+		return err
+	}
+	return &BpmnEngineUnmarshallingError{
+		Msg:    fmt.Sprintf("corrupt data at offset %d", offset),
+		Err:    err,
+		Offset: offset,
+	}
+}
@@ -1,8 +1,11 @@
 package bpmn_engine
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/corbym/gocrest/is"
+	"strings"
 	"testing"
 
 	"github.com/corbym/gocrest/then"
@@ -32,3 +35,147 @@ func Test_MarshallEngine(t *testing.T) {
 	then.AssertThat(t, vars.GetVariable("john"), is.EqualTo("doe"))
 	then.AssertThat(t, vars.GetVariable("valueFromHandler"), is.EqualTo(true))
 }
+
+func Test_MarshalTo_writes_same_data_as_Marshal(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/message-intermediate-timer-event.bpmn")
+	_, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"hello": "world"})
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	buffer := bytes.Buffer{}
+	err = bpmnEngine.MarshalTo(&buffer)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, buffer.String(), is.EqualTo(string(bpmnEngine.Marshal())))
+}
+
+func Test_MarshalTo_returns_writer_errors(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	_, _ = bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+
+	// when
+	err := bpmnEngine.MarshalTo(failingWriter{})
+
+	// then
+	then.AssertThat(t, err, is.EqualTo(errDiskFull))
+}
+
+func Test_UnmarshalFrom_reader_restores_instances(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"hello": "world"})
+	buffer := bytes.Buffer{}
+	_ = bpmnEngine.MarshalTo(&buffer)
+
+	// when
+	newEngine, err := UnmarshalFrom(&buffer)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	restored := newEngine.FindProcessInstance(instance.InstanceKey)
+	then.AssertThat(t, restored, is.Not(is.Nil()))
+	then.AssertThat(t, restored.GetVariable("hello"), is.EqualTo("world"))
+	then.AssertThat(t, restored.GetState(), is.EqualTo(Active))
+}
+
+func Test_Unmarshal_reports_position_of_corrupt_data(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	data := string(bpmnEngine.Marshal())
+	jobStateIndex := strings.Index(data, `"s":"ACTIVE"`)
+
+	tests := []struct {
+		name           string
+		data           string
+		expectedOffset int
+	}{
+		{"syntax error", data[:jobStateIndex] + `"s"::` + data[jobStateIndex+4:], jobStateIndex + len(`"s"::`)},
+		{"wrong type", data[:jobStateIndex] + `"s":123456` + data[jobStateIndex+12:], jobStateIndex + len(`"s":123456`)},
+		{"truncated", data[:jobStateIndex], jobStateIndex},
+		{"no object", `[]`, 1},
+		{"invalid field name", `{"n":"name",]`, len(`{"n":"name",]`)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			_, err := Unmarshal([]byte(test.data))
+
+			// then
+			var unmarshallingError *BpmnEngineUnmarshallingError
+			then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+			then.AssertThat(t, unmarshallingError.Offset, is.EqualTo(int64(test.expectedOffset)))
+		})
+	}
+}
+
+func Test_Unmarshal_rejects_null_records(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	_, _ = bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	data := string(bpmnEngine.Marshal())
+
+	for _, field := range []string{"pi", "j"} {
+		t.Run(field, func(t *testing.T) {
+			recordIndex := strings.Index(data, `"`+field+`":[`) + len(`"`+field+`":[`)
+
+			// when
+			_, err := Unmarshal([]byte(data[:recordIndex] + `null,` + data[recordIndex:]))
+
+			// then
+			var unmarshallingError *BpmnEngineUnmarshallingError
+			then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+			then.AssertThat(t, unmarshallingError.Offset, is.EqualTo(int64(recordIndex)))
+		})
+	}
+}
+
+func Test_Unmarshal_reports_position_of_instance_with_unknown_process(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	_, _ = bpmnEngine.CreateInstance(process.ProcessKey, nil)
+	data := string(bpmnEngine.Marshal())
+	processKey := fmt.Sprintf(`"pk":%d,`, process.ProcessKey)
+	instanceIndex := strings.Index(data, `"pi":[`) + len(`"pi":[`)
+	data = data[:instanceIndex] + strings.Replace(data[instanceIndex:], processKey, `"pk":1,`, 1)
+
+	// when
+	_, err := Unmarshal([]byte(data))
+
+	// then
+	var unmarshallingError *BpmnEngineUnmarshallingError
+	then.AssertThat(t, errors.As(err, &unmarshallingError), is.True())
+	then.AssertThat(t, unmarshallingError.Offset, is.EqualTo(int64(instanceIndex)))
+}
+
+var errDiskFull = errors.New("disk full")
+
+type failingWriter struct{}
+
+func (f failingWriter) Write(p []byte) (n int, err error) {
+	return 0, errDiskFull
+}
+
+// forEachEncoding runs the test as sub-test once per encoding, i.e. with the marshal options of JSON and protobuf
+func forEachEncoding(t *testing.T, test func(t *testing.T, options ...MarshalOption)) {
+	encodings := []struct {
+		name    string
+		options []MarshalOption
+	}{
+		{"json", nil},
+		{"protobuf", []MarshalOption{WithProtobufEncoding()}},
+	}
+	for _, encoding := range encodings {
+		t.Run(encoding.name, func(t *testing.T) {
+			test(t, encoding.options...)
+		})
+	}
+}
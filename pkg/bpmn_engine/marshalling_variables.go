@@ -0,0 +1,239 @@
+package bpmn_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variables are marshalled type preserving, for the types, which are also used by expressions (see fromFeelValue):
+// int and float64 (also integral ones, e.g. 2.0), time.Time, time.Duration, []interface{} and map[string]interface{}.
+// Other ints and floats become int and float64, typed slices and maps []interface{} and map[string]interface{},
+// and all other values, e.g. structs, are converted like encoding/json does.
+//
+// In JSON, floats always have a fraction or exponent, and times and durations are written as objects,
+// with a single "$time" (RFC 3339) or "$duration" (see time.Duration.String) field.
+// Maps with keys starting with "$" are wrapped in an object with a single "$map" field,
+// so that they can't be mistaken for times or durations.
+
+const (
+	jsonTimeField     = "$time"
+	jsonDurationField = "$duration"
+	jsonMapField      = "$map"
+)
+
+// normalizeVariable converts any variable value into one of the types, which can be marshalled type preserving
+func normalizeVariable(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, int, float64, time.Time, time.Duration:
+		return v, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, element := range v {
+			var err error
+			if list[i], err = normalizeVariable(element); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case map[string]interface{}:
+		return normalizeVariables(v)
+	case []byte:
+		return normalizeByJSON(v)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < math.MinInt || rv.Int() > math.MaxInt {
+			return float64(rv.Int()), nil
+		}
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt {
+			return float64(rv.Uint()), nil
+		}
+		return int(rv.Uint()), nil
+	case reflect.Float32:
+		// like encoding/json, use the shortest decimal representation, e.g. 0.1 and not 0.10000000149011612
+		return strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			var err error
+			if list[i], err = normalizeVariable(rv.Index(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			if rv.IsNil() {
+				return nil, nil
+			}
+			m := make(map[string]interface{}, rv.Len())
+			for _, key := range rv.MapKeys() {
+				var err error
+				if m[key.String()], err = normalizeVariable(rv.MapIndex(key).Interface()); err != nil {
+					return nil, err
+				}
+			}
+			return m, nil
+		}
+	}
+	return normalizeByJSON(value)
+}
+
+func normalizeVariables(variables map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(variables))
+	for key, value := range variables {
+		var err error
+		if result[key], err = normalizeVariable(value); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// normalizeByJSON converts the value like encoding/json does, e.g. structs become maps
+func normalizeByJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONValue(data, false)
+}
+
+// jsonVariables is a variables map, which is marshalled type preserving
+type jsonVariables map[string]interface{}
+
+func (v jsonVariables) MarshalJSON() ([]byte, error) {
+	variables, err := normalizeVariables(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(toJSONVariable(variables))
+}
+
+func (v *jsonVariables) UnmarshalJSON(data []byte) error {
+	value, err := decodeJSONValue(data, true)
+	if err != nil {
+		return err
+	}
+	variables, ok := value.(map[string]interface{})
+	if value != nil && !ok {
+		return fmt.Errorf("variables must be a JSON object, but is %T", value)
+	}
+	*v = variables
+	return nil
+}
+
+// jsonFloat always has a fraction or exponent, to be distinguished from an int, when unmarshalled
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return nil, fmt.Errorf("unsupported variable value: %v", float64(f))
+	}
+	s := strconv.FormatFloat(float64(f), 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return []byte(s), nil
+}
+
+// toJSONVariable converts a normalized variable value, so that encoding/json marshals it type preserving
+func toJSONVariable(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return jsonFloat(v)
+	case time.Time:
+		return map[string]string{jsonTimeField: v.Format(time.RFC3339Nano)}
+	case time.Duration:
+		return map[string]string{jsonDurationField: v.String()}
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, element := range v {
+			list[i] = toJSONVariable(element)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		escape := false
+		for key, element := range v {
+			m[key] = toJSONVariable(element)
+			escape = escape || strings.HasPrefix(key, "$")
+		}
+		if escape {
+			return map[string]interface{}{jsonMapField: m}
+		}
+		return m
+	}
+	return value
+}
+
+// decodeJSONValue decodes numbers type preserving, and times, durations and escaped maps, when tagged is true;
+// values, which were not marshalled via toJSONVariable, e.g. structs, are decoded without tags
+func decodeJSONValue(data []byte, tagged bool) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return fromJSONVariable(value, tagged)
+}
+
+// fromJSONVariable converts a value, decoded by encoding/json with UseNumber, into the marshalled types
+func fromJSONVariable(value interface{}, tagged bool) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			if i, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+				return int(i), nil
+			}
+		}
+		return v.Float64()
+	case []interface{}:
+		for i, element := range v {
+			var err error
+			if v[i], err = fromJSONVariable(element, tagged); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case map[string]interface{}:
+		if tagged && len(v) == 1 {
+			if s, ok := v[jsonTimeField].(string); ok {
+				return time.Parse(time.RFC3339Nano, s)
+			}
+			if s, ok := v[jsonDurationField].(string); ok {
+				return time.ParseDuration(s)
+			}
+			if m, ok := v[jsonMapField].(map[string]interface{}); ok {
+				v = m
+			}
+		}
+		for key, element := range v {
+			var err error
+			if v[key], err = fromJSONVariable(element, tagged); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	}
+	return value, nil
+}
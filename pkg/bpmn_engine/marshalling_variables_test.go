@@ -0,0 +1,115 @@
+package bpmn_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_variables_keep_their_types_when_marshalled(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 30, 0, 123, time.FixedZone("", 2*60*60))
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+		instance, err := bpmnEngine.CreateInstance(process.ProcessKey, map[string]interface{}{
+			"int":      42,
+			"float":    2.0,
+			"fraction": 0.5,
+			"start":    start,
+			"timeout":  90 * time.Minute,
+			"list":     []interface{}{1, 1.0, "one"},
+			"context":  map[string]interface{}{"int": 1, "float": 1.0, "start": start},
+			"int64":    int64(7),
+			"ints":     []int{1, 2},
+		})
+		then.AssertThat(t, err, is.Nil())
+
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+
+		// then
+		vh := restored.FindProcessInstance(instance.InstanceKey).VariableHolder
+		then.AssertThat(t, vh.GetVariable("int"), is.EqualTo(42))
+		then.AssertThat(t, vh.GetVariable("float"), is.EqualTo(2.0))
+		then.AssertThat(t, vh.GetVariable("fraction"), is.EqualTo(0.5))
+		then.AssertThat(t, vh.GetVariable("start").(time.Time).Equal(start), is.True())
+		then.AssertThat(t, vh.GetVariable("timeout"), is.EqualTo(90*time.Minute))
+		then.AssertThat(t, vh.GetVariable("list"), is.EqualTo([]interface{}{1, 1.0, "one"}))
+		context := vh.GetVariable("context").(map[string]interface{})
+		then.AssertThat(t, context["int"], is.EqualTo(1))
+		then.AssertThat(t, context["float"], is.EqualTo(1.0))
+		then.AssertThat(t, context["start"].(time.Time).Equal(start), is.True())
+		then.AssertThat(t, vh.GetVariable("int64"), is.EqualTo(7))
+		then.AssertThat(t, vh.GetVariable("ints"), is.EqualTo([]interface{}{1, 2}))
+	})
+}
+
+func Test_maps_looking_like_tagged_values_are_restored_as_maps(t *testing.T) {
+	userMaps := map[string]interface{}{
+		"time":     map[string]interface{}{"$time": "2024-03-01T12:00:00Z"},
+		"duration": map[string]interface{}{"$duration": "1h"},
+		"map":      map[string]interface{}{"$map": map[string]interface{}{"$time": "2024-03-01T12:00:00Z"}},
+		"query":    map[string]interface{}{"$gt": 5, "name": "john"},
+		"struct": struct {
+			Time string `json:"$time"`
+		}{"2024-03-01T12:00:00Z"},
+	}
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+		instance, err := bpmnEngine.CreateInstance(process.ProcessKey, userMaps)
+		then.AssertThat(t, err, is.Nil())
+
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+
+		// then
+		vh := restored.FindProcessInstance(instance.InstanceKey).VariableHolder
+		then.AssertThat(t, vh.GetVariable("time"), is.EqualTo(userMaps["time"]))
+		then.AssertThat(t, vh.GetVariable("duration"), is.EqualTo(userMaps["duration"]))
+		then.AssertThat(t, vh.GetVariable("map"), is.EqualTo(userMaps["map"]))
+		then.AssertThat(t, vh.GetVariable("query"), is.EqualTo(userMaps["query"]))
+		then.AssertThat(t, vh.GetVariable("struct"), is.EqualTo(userMaps["time"]))
+	})
+}
+
+func Test_expression_results_keep_their_types_when_marshalled(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+		variables := map[string]interface{}{}
+		for variable, expression := range map[string]string{
+			"sum":      "1 + 2",
+			"half":     "1 / 2",
+			"deadline": `date and time("2024-03-01T12:00:00+00:00") + duration("PT2H")`,
+			"timeout":  `duration("PT2H")`,
+			"items":    `[1, "two", {"three": 3.5}]`,
+		} {
+			result, err := evaluateExpression(expression, nil)
+			then.AssertThat(t, err, is.Nil())
+			variables[variable] = result
+		}
+		instance, _ := bpmnEngine.CreateInstance(process.ProcessKey, variables)
+
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+
+		// then
+		vh := restored.FindProcessInstance(instance.InstanceKey).VariableHolder
+		for variable, expected := range variables {
+			if expectedTime, ok := expected.(time.Time); ok {
+				then.AssertThat(t, vh.GetVariable(variable).(time.Time).Equal(expectedTime), is.True())
+				continue
+			}
+			then.AssertThat(t, vh.GetVariable(variable), is.EqualTo(expected))
+		}
+	})
+}
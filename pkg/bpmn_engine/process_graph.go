@@ -0,0 +1,647 @@
+package bpmn_engine
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20/extensions"
+)
+
+// processGraph is the execution graph of a process definition, indexed by element and flow IDs.
+// It's built once, when the process is loaded, and must not be modified afterward.
+type processGraph struct {
+	processId       string
+	elements        map[string]*BPMN20.BaseElement
+	scopes          map[string]BPMN20.ProcessElement // the (sub) process, which contains the element, by element ID
+	flows           map[string]*graphFlow
+	outgoing        map[string][]*graphFlow // by source element ID, in order of the definition
+	incoming        map[string][]*graphFlow // by target element ID, in order of the definition
+	startEvents     map[string][]*BPMN20.BaseElement
+	linkCatchEvents map[string]map[string]*BPMN20.BaseElement // by scope ID and link name
+	messageNames    map[string]string                         // by message ID
+	errorCodes      map[string]string                         // by error ID
+	signalNames     map[string]string                         // by signal ID
+	escalationCodes map[string]string                         // by escalation ID
+	// eventSubProcesses are the sub-processes, which are triggered by their start event, by the ID of their parent scope
+	eventSubProcesses map[string][]*BPMN20.BaseElement
+	boundaryEvents    map[string][]*BPMN20.BaseElement // by the ID of the activity, they're attached to
+	// compensationHandlers are the activities, which compensate an activity, by the ID of the compensated activity
+	compensationHandlers map[string]*BPMN20.BaseElement
+	// compensatedActivities are the IDs of the compensated activities, by the ID of their compensation handler
+	compensatedActivities map[string]string
+	// defaultFlows are the IDs of the default flows, by the ID of the gateway or activity
+	defaultFlows map[string]string
+	// joinSources are the IDs of the elements in the same scope, from which a token can arrive at
+	// the converging inclusive or complex gateway, by the gateway's ID
+	joinSources map[string]map[string]bool
+	// messageFlows are the message flows to other processes of the collaboration, by the ID of their source element
+	messageFlows map[string][]*graphMessageFlow
+	expressions  compiledExpressions
+}
+
+// graphFlow is a sequence flow, with its condition expression prepared for evaluation
+type graphFlow struct {
+	BPMN20.TSequenceFlow
+	condition         string // empty, when the flow has no condition
+	conditionLanguage string // empty, when the condition is in the default language of the process
+}
+
+// newProcessGraph returns an ExpressionSyntaxError, when any expression can't be parsed;
+// the expressions are compiled by the given engine's evaluators, by their language
+func newProcessGraph(definitions BPMN20.TDefinitions, expressionEvaluators map[string]ExpressionEvaluator) (*processGraph, error) {
+	var process BPMN20.ProcessElement = definitions.Process
+	g := &processGraph{
+		processId:             process.GetId(),
+		elements:              map[string]*BPMN20.BaseElement{},
+		scopes:                map[string]BPMN20.ProcessElement{},
+		flows:                 map[string]*graphFlow{},
+		outgoing:              map[string][]*graphFlow{},
+		incoming:              map[string][]*graphFlow{},
+		startEvents:           map[string][]*BPMN20.BaseElement{},
+		linkCatchEvents:       map[string]map[string]*BPMN20.BaseElement{},
+		messageNames:          map[string]string{},
+		errorCodes:            map[string]string{},
+		signalNames:           map[string]string{},
+		escalationCodes:       map[string]string{},
+		eventSubProcesses:     map[string][]*BPMN20.BaseElement{},
+		boundaryEvents:        map[string][]*BPMN20.BaseElement{},
+		compensationHandlers:  map[string]*BPMN20.BaseElement{},
+		compensatedActivities: map[string]string{},
+		defaultFlows:          map[string]string{},
+		joinSources:           map[string]map[string]bool{},
+		messageFlows:          map[string][]*graphMessageFlow{},
+		expressions:           newCompiledExpressions(expressionEvaluators, definitions.ExpressionLanguage),
+	}
+	for _, message := range definitions.Messages {
+		if _, exists := g.messageNames[message.Id]; !exists {
+			g.messageNames[message.Id] = message.Name
+		}
+	}
+	for _, e := range definitions.Errors {
+		if _, exists := g.errorCodes[e.Id]; !exists {
+			g.errorCodes[e.Id] = e.ErrorCode
+		}
+	}
+	for _, signal := range definitions.Signals {
+		if _, exists := g.signalNames[signal.Id]; !exists {
+			g.signalNames[signal.Id] = signal.Name
+		}
+	}
+	for _, escalation := range definitions.Escalations {
+		if _, exists := g.escalationCodes[escalation.Id]; !exists {
+			g.escalationCodes[escalation.Id] = escalation.EscalationCode
+		}
+	}
+	g.addElement(nil, process)
+	flowOrder := map[string]int{}
+	g.addScope(process, flowOrder)
+	g.addCompensationHandlers(process)
+	for id, element := range g.elements {
+		g.outgoing[id] = g.flowsByIds((*element).GetOutgoingAssociation(), flowOrder)
+		g.incoming[id] = g.flowsByIds((*element).GetIncomingAssociation(), flowOrder)
+	}
+	if err := g.addDefaultFlows(); err != nil {
+		return nil, err
+	}
+	for id, element := range g.elements {
+		isJoin := (*element).GetType() == BPMN20.InclusiveGateway || (*element).GetType() == BPMN20.ComplexGateway
+		if isJoin && len(g.incoming[id]) > 1 {
+			g.joinSources[id] = g.upstreamOf(id)
+		}
+	}
+	if err := g.compileExpressions(process); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *processGraph) addScope(scope BPMN20.ProcessElement, flowOrder map[string]int) {
+	for _, flow := range scope.GetSequenceFlows() {
+		if _, exists := g.flows[flow.Id]; exists {
+			continue
+		}
+		gf := &graphFlow{TSequenceFlow: flow}
+		if flow.HasConditionExpression() {
+			gf.condition = flow.GetConditionExpression()
+			gf.conditionLanguage = flow.GetConditionExpressionLanguage()
+		}
+		flowOrder[flow.Id] = len(flowOrder)
+		g.flows[flow.Id] = gf
+	}
+	for _, startEvent := range scope.GetStartEvents() {
+		element := g.addElement(scope, startEvent)
+		g.startEvents[scope.GetId()] = append(g.startEvents[scope.GetId()], element)
+	}
+	for _, endEvent := range scope.GetEndEvents() {
+		g.addElement(scope, endEvent)
+	}
+	for _, task := range scope.GetServiceTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetUserTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetBusinessRuleTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetScriptTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetSendTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetReceiveTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetManualTasks() {
+		g.addElement(scope, task)
+	}
+	for _, task := range scope.GetTasks() {
+		g.addElement(scope, task)
+	}
+	for _, parallelGateway := range scope.GetParallelGateway() {
+		g.addElement(scope, parallelGateway)
+	}
+	for _, exclusiveGateway := range scope.GetExclusiveGateway() {
+		g.addElement(scope, exclusiveGateway)
+	}
+	for _, eventBasedGateway := range scope.GetEventBasedGateway() {
+		g.addElement(scope, eventBasedGateway)
+	}
+	for _, intermediateCatchEvent := range scope.GetIntermediateCatchEvent() {
+		element := g.addElement(scope, intermediateCatchEvent)
+		if intermediateCatchEvent.LinkEventDefinition.Id != "" {
+			g.addLinkCatchEvent(scope, intermediateCatchEvent.LinkEventDefinition.Name, element)
+		}
+	}
+	for _, intermediateThrowEvent := range scope.GetIntermediateTrowEvent() {
+		g.addElement(scope, intermediateThrowEvent)
+	}
+	for _, inclusiveGateway := range scope.GetInclusiveGateway() {
+		g.addElement(scope, inclusiveGateway)
+	}
+	for _, complexGateway := range scope.GetComplexGateway() {
+		g.addElement(scope, complexGateway)
+	}
+	for _, boundaryEvent := range scope.GetBoundaryEvents() {
+		element := g.addElement(scope, boundaryEvent)
+		g.boundaryEvents[boundaryEvent.AttachedToRef] = append(g.boundaryEvents[boundaryEvent.AttachedToRef], element)
+	}
+	for _, subProcess := range scope.GetSubProcess() {
+		element := g.addElement(scope, subProcess)
+		if subProcess.TriggeredByEvent {
+			g.eventSubProcesses[scope.GetId()] = append(g.eventSubProcesses[scope.GetId()], element)
+		}
+		g.addScope(&subProcess, flowOrder)
+	}
+}
+
+// addCompensationHandlers resolves the associations from compensation boundary events to their handlers;
+// an activity keeps its first compensation handler
+func (g *processGraph) addCompensationHandlers(scope BPMN20.ProcessElement) {
+	for _, association := range scope.GetAssociations() {
+		source, handler := g.element(association.SourceRef), g.element(association.TargetRef)
+		if source == nil || handler == nil {
+			continue
+		}
+		boundaryEvent, isBoundaryEvent := (*source).(BPMN20.TBoundaryEvent)
+		if !isBoundaryEvent || boundaryEvent.CompensateEventDefinition.Id == "" {
+			continue
+		}
+		if _, exists := g.compensationHandlers[boundaryEvent.AttachedToRef]; !exists {
+			g.compensationHandlers[boundaryEvent.AttachedToRef] = handler
+			g.compensatedActivities[(*handler).GetId()] = boundaryEvent.AttachedToRef
+		}
+	}
+	for _, subProcess := range scope.GetSubProcess() {
+		g.addCompensationHandlers(&subProcess)
+	}
+}
+
+// addDefaultFlows resolves the default flows of the gateways and activities. A diverging exclusive gateway
+// may have one outgoing flow, which is either its default flow or has no condition, and which is its default flow,
+// when the gateway has no `default` attribute. Flows without condition of inclusive and complex gateways
+// are always taken, see inclusivelyFilterByConditionExpression.
+func (g *processGraph) addDefaultFlows() error {
+	ids := make([]string, 0, len(g.elements))
+	for id := range g.elements {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		defaultFlowId := defaultFlowOf(*g.elements[id])
+		if defaultFlowId != "" {
+			if !slices.ContainsFunc(g.outgoing[id], func(flow *graphFlow) bool { return flow.Id == defaultFlowId }) {
+				return newEngineErrorf("default flow id=%s of element id=%s is not one of its outgoing sequence flows", defaultFlowId, id)
+			}
+			g.defaultFlows[id] = defaultFlowId
+		}
+		if (*g.elements[id]).GetType() != BPMN20.ExclusiveGateway {
+			continue
+		}
+		var unconditionedFlowIds []string
+		for _, flow := range g.outgoing[id] {
+			if flow.condition == "" || flow.Id == defaultFlowId {
+				unconditionedFlowIds = append(unconditionedFlowIds, flow.Id)
+			}
+		}
+		if len(unconditionedFlowIds) > 1 {
+			return newEngineErrorf("gateway id=%s has more than one outgoing sequence flow without condition, flow ids=%s", id, strings.Join(unconditionedFlowIds, ","))
+		}
+		if defaultFlowId == "" && len(unconditionedFlowIds) == 1 {
+			g.defaultFlows[id] = unconditionedFlowIds[0]
+		}
+	}
+	return nil
+}
+
+// defaultFlowOf returns the `default` attribute of gateways and activities, or an empty string, when there's none
+func defaultFlowOf(element BPMN20.BaseElement) string {
+	switch e := element.(type) {
+	case BPMN20.TExclusiveGateway:
+		return e.Default
+	case BPMN20.TInclusiveGateway:
+		return e.Default
+	case BPMN20.TComplexGateway:
+		return e.Default
+	case BPMN20.TServiceTask:
+		return e.Default
+	case BPMN20.TUserTask:
+		return e.Default
+	case BPMN20.TBusinessRuleTask:
+		return e.Default
+	case BPMN20.TScriptTask:
+		return e.Default
+	case BPMN20.TSendTask:
+		return e.Default
+	case BPMN20.TReceiveTask:
+		return e.Default
+	case BPMN20.TManualTask:
+		return e.Default
+	case BPMN20.TTask:
+		return e.Default
+	case BPMN20.TSubProcess:
+		return e.Default
+	}
+	return ""
+}
+
+// compileExpressions compiles the expressions in the order of the definition, so that the first invalid one is reported
+func (g *processGraph) compileExpressions(scope BPMN20.ProcessElement) error {
+	for _, flow := range scope.GetSequenceFlows() {
+		if gf := g.flows[flow.Id]; gf.condition != "" {
+			if err := g.expressions.compile(flow.Id, "conditionExpression", gf.conditionLanguage, gf.condition); err != nil {
+				return err
+			}
+		}
+	}
+	for _, startEvent := range scope.GetStartEvents() {
+		if err := g.compileMappingsOf(startEvent.Id, "zeebe:output", startEvent.Output); err != nil {
+			return err
+		}
+		if timeDuration := startEvent.TimerEventDefinition.TimeDuration; strings.HasPrefix(timeDuration.XMLText, "=") {
+			if err := g.expressions.compile(startEvent.Id, "timeDuration", timeDuration.Language, timerDurationExpression(timeDuration)); err != nil {
+				return err
+			}
+		}
+		if err := g.compileCondition(startEvent.Id, startEvent.ConditionalEventDefinition); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetServiceTasks() {
+		if err := g.compileMappings(task); err != nil {
+			return err
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetUserTasks() {
+		if err := g.compileMappings(task); err != nil {
+			return err
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetBusinessRuleTasks() {
+		if err := g.compileMappings(task); err != nil {
+			return err
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetScriptTasks() {
+		if err := g.compileMappings(task); err != nil {
+			return err
+		}
+		if task.Script.Expression != "" {
+			if err := g.expressions.compile(task.Id, "zeebe:script", ExpressionLanguageFEEL, task.Script.Expression); err != nil {
+				return err
+			}
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetSendTasks() {
+		if err := g.compileMappings(task); err != nil {
+			return err
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetReceiveTasks() {
+		if err := g.compileMappingsOf(task.Id, "zeebe:output", task.Output); err != nil {
+			return err
+		}
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, ice := range scope.GetIntermediateCatchEvent() {
+		if err := g.compileMappingsOf(ice.Id, "zeebe:output", ice.Output); err != nil {
+			return err
+		}
+		if timeDuration := ice.TimerEventDefinition.TimeDuration; strings.HasPrefix(timeDuration.XMLText, "=") {
+			if err := g.expressions.compile(ice.Id, "timeDuration", timeDuration.Language, timerDurationExpression(timeDuration)); err != nil {
+				return err
+			}
+		}
+		if err := g.compileCondition(ice.Id, ice.ConditionalEventDefinition); err != nil {
+			return err
+		}
+	}
+	for _, ite := range scope.GetIntermediateTrowEvent() {
+		if err := g.compileMappingsOf(ite.Id, "zeebe:output", ite.Output); err != nil {
+			return err
+		}
+	}
+	for _, complexGateway := range scope.GetComplexGateway() {
+		if complexGateway.HasActivationCondition() {
+			if err := g.expressions.compile(complexGateway.Id, "activationCondition", complexGateway.GetActivationConditionLanguage(), complexGateway.GetActivationCondition()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, boundaryEvent := range scope.GetBoundaryEvents() {
+		if err := g.compileCondition(boundaryEvent.Id, boundaryEvent.ConditionalEventDefinition); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetManualTasks() {
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, task := range scope.GetTasks() {
+		if err := g.compileLoop(task); err != nil {
+			return err
+		}
+	}
+	for _, subProcess := range scope.GetSubProcess() {
+		if err := g.compileLoop(subProcess); err != nil {
+			return err
+		}
+		if err := g.compileExpressions(&subProcess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileCondition compiles the condition of a conditional event; a conditional event without condition is invalid
+func (g *processGraph) compileCondition(elementId string, definition BPMN20.TConditionalEventDefinition) error {
+	if definition.Id == "" {
+		return nil
+	}
+	if !definition.HasCondition() {
+		return newEngineErrorf("conditional event id=%s has no condition", elementId)
+	}
+	return g.expressions.compile(elementId, "condition", definition.GetConditionLanguage(), definition.GetCondition())
+}
+
+// compileLoop compiles the loop condition of a looping activity; a loop without condition and maximum is invalid,
+// because it would never end, and so are looping receive tasks, which would share their message subscription
+func (g *processGraph) compileLoop(activity BPMN20.ActivityElement) error {
+	loop := activity.GetStandardLoopCharacteristics()
+	if loop == nil {
+		return nil
+	}
+	if activity.GetType() == BPMN20.ReceiveTask {
+		return newEngineErrorf("receive task id=%s can't loop", activity.GetId())
+	}
+	if !loop.HasLoopCondition() {
+		if loop.LoopMaximum == nil {
+			return newEngineErrorf("loop of activity id=%s has neither loop condition nor loop maximum", activity.GetId())
+		}
+		return nil
+	}
+	return g.expressions.compile(activity.GetId(), "loopCondition", loop.GetLoopConditionLanguage(), loop.GetLoopCondition())
+}
+
+func (g *processGraph) compileMappings(task BPMN20.TaskElement) error {
+	if err := g.compileMappingsOf(task.GetId(), "zeebe:input", task.GetInputMapping()); err != nil {
+		return err
+	}
+	return g.compileMappingsOf(task.GetId(), "zeebe:output", task.GetOutputMapping())
+}
+
+func (g *processGraph) compileMappingsOf(elementId string, attribute string, mappings []extensions.TIoMapping) error {
+	for _, mapping := range mappings {
+		if err := g.expressions.compile(elementId, attribute, "", mapping.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addElement keeps the first element with a given ID, like BPMN20.FindBaseElementsById does
+func (g *processGraph) addElement(scope BPMN20.ProcessElement, be BPMN20.BaseElement) *BPMN20.BaseElement {
+	if element, exists := g.elements[be.GetId()]; exists {
+		return element
+	}
+	element := &be
+	g.elements[be.GetId()] = element
+	g.scopes[be.GetId()] = scope
+	return element
+}
+
+func (g *processGraph) addLinkCatchEvent(scope BPMN20.ProcessElement, linkName string, element *BPMN20.BaseElement) {
+	byName := g.linkCatchEvents[scope.GetId()]
+	if byName == nil {
+		byName = map[string]*BPMN20.BaseElement{}
+		g.linkCatchEvents[scope.GetId()] = byName
+	}
+	if _, exists := byName[linkName]; !exists {
+		byName[linkName] = element
+	}
+}
+
+func (g *processGraph) flowsByIds(ids []string, flowOrder map[string]int) (result []*graphFlow) {
+	for _, id := range ids {
+		if flow, found := g.flows[id]; found {
+			result = append(result, flow)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return flowOrder[result[i].Id] < flowOrder[result[j].Id]
+	})
+	return result
+}
+
+// element returns the element with the given ID, or nil when there's none
+func (g *processGraph) element(id string) *BPMN20.BaseElement {
+	return g.elements[id]
+}
+
+// incomingFlowFrom returns the first flow from the source to the target element, or nil when there's none
+func (g *processGraph) incomingFlowFrom(sourceId string, targetId string) *graphFlow {
+	for _, flow := range g.incoming[targetId] {
+		if flow.SourceRef == sourceId {
+			return flow
+		}
+	}
+	return nil
+}
+
+// upstreamOf returns the IDs of the elements, from which a token can arrive at the target element,
+// without passing the target; boundary events are reached from the activity, they're attached to,
+// and link catch events from the link throw events with the same name in the same scope
+func (g *processGraph) upstreamOf(targetId string) map[string]bool {
+	upstream := map[string]bool{}
+	pending := []string{targetId}
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		var sourceIds []string
+		for _, flow := range g.incoming[id] {
+			sourceIds = append(sourceIds, flow.SourceRef)
+		}
+		switch element := (*g.elements[id]).(type) {
+		case BPMN20.TBoundaryEvent:
+			sourceIds = append(sourceIds, element.AttachedToRef)
+		case BPMN20.TIntermediateCatchEvent:
+			if element.LinkEventDefinition.Id != "" {
+				sourceIds = append(sourceIds, g.linkThrowEvents(g.scopes[id].GetId(), element.LinkEventDefinition.Name)...)
+			}
+		}
+		for _, sourceId := range sourceIds {
+			if sourceId == targetId || upstream[sourceId] || g.elements[sourceId] == nil {
+				continue
+			}
+			upstream[sourceId] = true
+			pending = append(pending, sourceId)
+		}
+	}
+	return upstream
+}
+
+// linkThrowEvents returns the IDs of the link throw events of the scope, with the given link name
+func (g *processGraph) linkThrowEvents(scopeId string, linkName string) (ids []string) {
+	for id, element := range g.elements {
+		throwEvent, ok := (*element).(BPMN20.TIntermediateThrowEvent)
+		if ok && throwEvent.LinkEventDefinition.Id != "" && throwEvent.LinkEventDefinition.Name == linkName && g.scopes[id].GetId() == scopeId {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// canReachJoin returns true, when a token at the element can still arrive at the converging inclusive or complex gateway;
+// a token within a sub-process can arrive, when the sub-process can
+func (g *processGraph) canReachJoin(elementId string, gatewayId string) bool {
+	gatewayScopeId := g.scopes[gatewayId].GetId()
+	for id := elementId; g.scopes[id] != nil; id = g.scopes[id].GetId() {
+		if g.scopes[id].GetId() == gatewayScopeId {
+			return g.joinSources[gatewayId][id]
+		}
+	}
+	return false
+}
+
+// isInScope returns true, when the element is contained in the (sub) process with the given ID, at any depth
+func (g *processGraph) isInScope(elementId string, scopeId string) bool {
+	for scope := g.scopes[elementId]; scope != nil; scope = g.scopes[scope.GetId()] {
+		if scope.GetId() == scopeId {
+			return true
+		}
+	}
+	return false
+}
+
+// isLooping returns true, when the element, or any sub-process, which contains it, has standard loop characteristics
+func (g *processGraph) isLooping(elementId string) bool {
+	if element := g.element(elementId); element != nil && standardLoopOf(*element) != nil {
+		return true
+	}
+	for scope := g.scopes[elementId]; scope != nil; scope = g.scopes[scope.GetId()] {
+		if standardLoopOf(scope) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// eventSubProcessOf returns the event sub-process, which directly contains the element, or nil when there's none
+func (g *processGraph) eventSubProcessOf(elementId string) *BPMN20.TSubProcess {
+	if subProcess, ok := g.scopes[elementId].(*BPMN20.TSubProcess); ok && subProcess.TriggeredByEvent {
+		return subProcess
+	}
+	return nil
+}
+
+// isInterrupting returns true, when the event sub-process's start event interrupts the parent scope;
+// error start events are always interrupting
+func (g *processGraph) isInterrupting(eventSubProcess *BPMN20.TSubProcess) bool {
+	for _, startEvent := range eventSubProcess.StartEvents {
+		if startEvent.ErrorEventDefinition.Id != "" || startEvent.Interrupting() {
+			return true
+		}
+	}
+	return false
+}
+
+// catchingEventSubProcessIn searches the event sub-processes of the scope, for the first start event, which matches;
+// an exact match is preferred over a catch-all one, and event sub-processes, which contain the thrower, are skipped.
+// Returns nil, when there's no match.
+func (g *processGraph) catchingEventSubProcessIn(scopeId string, throwerId string, matches eventMatcher) (eventSubProcess *BPMN20.BaseElement, startEvent *BPMN20.BaseElement) {
+	for _, esp := range g.eventSubProcesses[scopeId] {
+		if g.isInScope(throwerId, (*esp).GetId()) {
+			continue
+		}
+		for _, se := range g.startEvents[(*esp).GetId()] {
+			matched, exact := matches(*se)
+			if matched && exact {
+				return esp, se
+			}
+			if matched && eventSubProcess == nil {
+				eventSubProcess, startEvent = esp, se
+			}
+		}
+	}
+	return eventSubProcess, startEvent
+}
+
+// catchingBoundaryEvent returns the first boundary event attached to the activity, which matches;
+// an exact match is preferred over a catch-all one. Returns nil, when there's no match.
+func (g *processGraph) catchingBoundaryEvent(activityId string, matches eventMatcher) (boundaryEvent *BPMN20.BaseElement) {
+	for _, be := range g.boundaryEvents[activityId] {
+		matched, exact := matches(*be)
+		if matched && exact {
+			return be
+		}
+		if matched && boundaryEvent == nil {
+			boundaryEvent = be
+		}
+	}
+	return boundaryEvent
+}
+
+// eventMatcher tells, whether a catch event catches a thrown error or escalation;
+// a match is exact, when the catch event references it, and not exact, when the catch event catches all
+type eventMatcher func(catchEvent BPMN20.BaseElement) (matched bool, exact bool)
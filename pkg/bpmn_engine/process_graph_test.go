@@ -0,0 +1,169 @@
+package bpmn_engine
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+func Test_process_graph_indexes_elements_of_sub_processes(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/subprocess.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	graph := process.graph
+	then.AssertThat(t, (*graph.element("task-in-sub-a")).GetType(), is.EqualTo(BPMN20.ServiceTask))
+	then.AssertThat(t, graph.element("not-existing"), is.Nil())
+	then.AssertThat(t, graph.scopes["task-in-sub-a"].GetId(), is.EqualTo("sub-process-a"))
+	then.AssertThat(t, graph.scopes["sub-process-a"].GetId(), is.EqualTo("Process_0gjrx3e"))
+	then.AssertThat(t, graph.startEvents["Process_0gjrx3e"], has.Length(1))
+	then.AssertThat(t, (*graph.startEvents["sub-process-a"][0]).GetId(), is.EqualTo("startEvent_sub"))
+}
+
+func Test_process_graph_indexes_event_sub_processes_by_parent_scope(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/event-sub-process-error.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	graph := process.graph
+	then.AssertThat(t, graph.eventSubProcesses["event-sub-process-error"], has.Length(1))
+	then.AssertThat(t, (*graph.eventSubProcesses["event-sub-process-error"][0]).GetId(), is.EqualTo("error-handling"))
+	then.AssertThat(t, (*graph.eventSubProcesses["payment"][0]).GetId(), is.EqualTo("declined-handling"))
+	then.AssertThat(t, graph.errorCodes["Error_fraud"], is.EqualTo("FRAUD"))
+	then.AssertThat(t, graph.isInScope("notify-customer", "event-sub-process-error"), is.True())
+	then.AssertThat(t, graph.isInScope("ship", "payment"), is.False())
+	then.AssertThat(t, graph.eventSubProcessOf("payment-declined").Id, is.EqualTo("declined-handling"))
+	then.AssertThat(t, graph.eventSubProcessOf("charge"), is.Nil())
+}
+
+func Test_process_graph_links_compensated_activities_to_their_handlers(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/compensation-booking.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	graph := process.graph
+	then.AssertThat(t, graph.compensationHandlers, has.Length(2))
+	then.AssertThat(t, (*graph.compensationHandlers["book-hotel"]).GetId(), is.EqualTo("cancel-hotel"))
+	then.AssertThat(t, (*graph.compensationHandlers["book-flight"]).GetId(), is.EqualTo("cancel-flight"))
+	then.AssertThat(t, graph.compensatedActivities["cancel-hotel"], is.EqualTo("book-hotel"))
+	then.AssertThat(t, graph.boundaryEvents["book-hotel"], has.Length(1))
+}
+
+func Test_process_graph_knows_the_sources_of_converging_inclusive_gateways(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/inclusive-gateway-join.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	graph := process.graph
+	then.AssertThat(t, graph.joinSources, has.Length(2))
+	then.AssertThat(t, graph.joinSources, has.Key("review-join"))
+	then.AssertThat(t, graph.canReachJoin("task-b", "join"), is.True())
+	then.AssertThat(t, graph.canReachJoin("start", "join"), is.True())
+	then.AssertThat(t, graph.canReachJoin("b-skipped", "join"), is.False())
+	then.AssertThat(t, graph.canReachJoin("task-after", "join"), is.False())
+	then.AssertThat(t, graph.canReachJoin("task-y", "review-join"), is.True())
+	then.AssertThat(t, graph.canReachJoin("task-a", "review-join"), is.False())
+}
+
+func Test_process_graph_indexes_flows_with_conditions(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-condition-and-default.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	outgoing := process.graph.outgoing["Gateway_01wr5g0"]
+	then.AssertThat(t, outgoing, has.Length(2))
+	then.AssertThat(t, outgoing[0].Id, is.EqualTo("price-gt-zero"))
+	then.AssertThat(t, outgoing[0].condition, is.EqualTo("price > 0"))
+	then.AssertThat(t, outgoing[1].Id, is.EqualTo("default"))
+	then.AssertThat(t, outgoing[1].condition, is.EqualTo(""))
+	then.AssertThat(t, process.graph.incomingFlowFrom("Gateway_01wr5g0", "task-b").Id, is.EqualTo("default"))
+	then.AssertThat(t, process.graph.incomingFlowFrom("task-a", "task-b"), is.Nil())
+}
+
+func Test_process_graph_indexes_link_catch_events_by_scope(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple-link-events.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	linkCatchEvents := process.graph.linkCatchEvents[process.BpmnProcessId]
+	then.AssertThat(t, len(linkCatchEvents) > 0, is.True())
+	for name, element := range linkCatchEvents {
+		catchEvent := (*element).(BPMN20.TIntermediateCatchEvent)
+		then.AssertThat(t, catchEvent.LinkEventDefinition.Name, is.EqualTo(name))
+	}
+}
+
+func Test_process_graph_compiles_expressions_at_load_time(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+
+	// when
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/exclusive-gateway-with-condition-and-default.bpmn")
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, process.graph.expressions.compiled, has.Key(expressionKey{language: ExpressionLanguageFEEL, expression: "price > 0"}))
+}
+
+func Test_loading_fails_on_invalid_expression_syntax(t *testing.T) {
+	tests := []struct {
+		file       string
+		expression string
+		invalid    string
+		elementId  string
+		attribute  string
+	}{
+		{"exclusive-gateway-with-condition-and-default.bpmn", "price &gt; 0", "price &gt;", "price-gt-zero", "conditionExpression"},
+		{"message-intermediate-timer-event-expression.bpmn", "=timeoutValue", "=timeoutValue(", "timer1", "timeDuration"},
+		{"complex-gateway.bpmn", "=_activationCount &gt;= 2", "=_activationCount &gt;=", "two-of-three", "activationCondition"},
+	}
+	for _, test := range tests {
+		t.Run(test.attribute, func(t *testing.T) {
+			// setup
+			xmlData, err := os.ReadFile("../../test-cases/" + test.file)
+			then.AssertThat(t, err, is.Nil())
+			xmlData = []byte(strings.Replace(string(xmlData), test.expression, test.invalid, 1))
+			bpmnEngine := New()
+
+			// when
+			_, err = bpmnEngine.LoadFromBytes(xmlData)
+
+			// then
+			var syntaxError *ExpressionSyntaxError
+			then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+			then.AssertThat(t, syntaxError.ElementId, is.EqualTo(test.elementId))
+			then.AssertThat(t, syntaxError.Attribute, is.EqualTo(test.attribute))
+			then.AssertThat(t, err.Error(), has.Prefix("invalid FEEL expression"))
+		})
+	}
+}
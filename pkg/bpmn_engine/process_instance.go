@@ -11,9 +11,23 @@ type processInstanceInfo struct {
 	InstanceKey    int64          `json:"ik"`
 	VariableHolder VariableHolder `json:"vh,omitempty"`
 	CreatedAt      time.Time      `json:"c"`
+	CompletedAt    time.Time      `json:"-"` // zero, as long as the instance is not completed
 	ActivityState  ActivityState  `json:"s"`
 	CaughtEvents   []catchEvent   `json:"ce,omitempty"`
-	activities     []activity
+	// CompensableActivities are the completed activity instances with a compensation handler, in order of completion
+	CompensableActivities []compensableActivity `json:"co,omitempty"`
+	// MessageSenderKey is the key of the instance of another process, whose message started this instance, or zero
+	MessageSenderKey int64 `json:"mk,omitempty"`
+	// messageReceiverKeys are the keys of the instances, which messages of this instance started; see MessageSenderKey
+	messageReceiverKeys []int64
+	activities          []activity
+	// interruptedScopes are the scopes, which interrupting event sub-processes interrupted during the current run
+	interruptedScopes []scopeInterruption
+	// running is true during a run, so that messages from other instances are caught by the run itself
+	running bool
+	// waitingSubProcesses are the embedded sub-processes by element id, which wait for a job, message or timer
+	// after their run; they aren't marshalled
+	waitingSubProcesses map[string]*subProcessInfo
 }
 
 type ProcessInstance interface {
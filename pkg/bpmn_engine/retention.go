@@ -0,0 +1,96 @@
+package bpmn_engine
+
+import (
+	"sort"
+	"time"
+)
+
+// InstanceRetention limits how long completed process instances are kept in the engine; zero values mean unlimited.
+// Active and failed instances are never removed automatically.
+type InstanceRetention struct {
+	// TimeToLive removes completed instances, which completed longer ago
+	TimeToLive time.Duration
+	// MaxCompletedInstances keeps only the latest completed instances
+	MaxCompletedInstances int
+}
+
+// SetInstanceRetention configures the automatic removal of completed process instances,
+// which is applied whenever a process instance completes
+func (state *BpmnEngineState) SetInstanceRetention(retention InstanceRetention) {
+	state.instanceRetention = retention
+	state.applyInstanceRetention()
+}
+
+// PurgeCompleted removes all process instances, which completed before the given time,
+// together with their jobs, timers and message subscriptions.
+// For each removed instance, exporters implementing exporter.DeletionEventExporter are notified.
+// Instances without completion time, e.g. unmarshalled from data before serializer version 3, are kept,
+// because it's unknown, when they completed.
+// Returns the number of removed process instances.
+func (state *BpmnEngineState) PurgeCompleted(before time.Time) int {
+	queue := state.completedInstances
+	// the instances without completion time are at the head of the queue
+	from := sort.Search(len(queue), func(i int) bool { return !queue[i].CompletedAt.IsZero() })
+	to := sort.Search(len(queue), func(i int) bool { return !queue[i].CompletedAt.Before(before) })
+	if to <= from {
+		return 0
+	}
+	removed := append([]*processInstanceInfo(nil), queue[from:to]...)
+	copy(queue[to-from:to], queue[:from])
+	clear(queue[:to-from]) // the queue's array doesn't keep removed instances
+	state.completedInstances = queue[to-from:]
+	state.removeProcessInstances(removed)
+	return len(removed)
+}
+
+func (state *BpmnEngineState) applyInstanceRetention() {
+	if state.instanceRetention.TimeToLive > 0 {
+		state.PurgeCompleted(time.Now().Add(-state.instanceRetention.TimeToLive))
+	}
+	if state.instanceRetention.MaxCompletedInstances > 0 {
+		if excess := len(state.completedInstances) - state.instanceRetention.MaxCompletedInstances; excess > 0 {
+			removed := state.completedInstances[:excess]
+			state.completedInstances = state.completedInstances[excess:]
+			state.removeProcessInstances(removed)
+			clear(removed)
+		}
+	}
+}
+
+// addCompletedInstance adds the instance to the queue of completed instances, which is ordered by completion time,
+// so that the retention removes instances from its head; instances without completion time are the oldest ones
+func (state *BpmnEngineState) addCompletedInstance(instance *processInstanceInfo) {
+	queue := state.completedInstances
+	if len(queue) == 0 || !queue[len(queue)-1].CompletedAt.After(instance.CompletedAt) {
+		state.completedInstances = append(queue, instance)
+		return
+	}
+	i := sort.Search(len(queue), func(i int) bool { return queue[i].CompletedAt.After(instance.CompletedAt) })
+	queue = append(queue, nil)
+	copy(queue[i+1:], queue[i:])
+	queue[i] = instance
+	state.completedInstances = queue
+}
+
+// removeProcessInstances removes the completed instances, and all their dependent records;
+// processInstances is compacted, when the removed instances are at least half of all instances (see instances)
+func (state *BpmnEngineState) removeProcessInstances(removed []*processInstanceInfo) {
+	removedKeys := make(map[int64]bool, len(removed))
+	for _, instance := range removed {
+		removedKeys[instance.InstanceKey] = true
+		delete(state.processInstanceIndex, instance.InstanceKey)
+		if state.history != nil {
+			delete(state.history.lastVariables, instance.InstanceKey)
+		}
+	}
+	state.jobs.removeInstances(removedKeys)
+	state.timers.removeInstances(removedKeys)
+	state.messageSubscriptions.removeInstances(removedKeys)
+	state.removedInstances += len(removed)
+	if state.removedInstances*2 >= len(state.processInstances) {
+		state.instances()
+	}
+	for _, instance := range removed {
+		state.exportDeleteProcessInstanceEvent(*instance)
+	}
+}
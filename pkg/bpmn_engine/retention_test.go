@@ -0,0 +1,189 @@
+package bpmn_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+)
+
+type deletionRecordingExporter struct {
+	deletedInstanceKeys []int64
+}
+
+func (e *deletionRecordingExporter) NewProcessEvent(*exporter.ProcessEvent)                 {}
+func (e *deletionRecordingExporter) EndProcessEvent(*exporter.ProcessInstanceEvent)         {}
+func (e *deletionRecordingExporter) NewProcessInstanceEvent(*exporter.ProcessInstanceEvent) {}
+func (e *deletionRecordingExporter) NewElementEvent(*exporter.ProcessInstanceEvent, *exporter.ElementInfo) {
+}
+func (e *deletionRecordingExporter) DeleteProcessInstanceEvent(event *exporter.ProcessInstanceEvent) {
+	e.deletedInstanceKeys = append(e.deletedInstanceKeys, event.ProcessInstanceKey)
+}
+
+func newEngineWithCompletingTask(t *testing.T) (BpmnEngineState, *ProcessInfo) {
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+	return bpmnEngine, process
+}
+
+func Test_completed_instances_are_kept_by_default(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+
+	// when
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, instance.CompletedAt.IsZero(), is.False())
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(1))
+}
+
+func Test_PurgeCompleted_removes_instance_and_dependent_records(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	deletionExporter := &deletionRecordingExporter{}
+	bpmnEngine.AddEventExporter(deletionExporter)
+	active, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	completed, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		if job.ProcessInstanceKey() == completed.InstanceKey {
+			job.Complete()
+		}
+	})
+	_, _ = bpmnEngine.RunOrContinueInstance(completed.InstanceKey)
+
+	// when
+	purged := bpmnEngine.PurgeCompleted(time.Now().Add(time.Second))
+
+	// then
+	then.AssertThat(t, purged, is.EqualTo(1))
+	then.AssertThat(t, bpmnEngine.FindProcessInstance(completed.InstanceKey), is.Nil())
+	then.AssertThat(t, bpmnEngine.FindProcessInstance(active.InstanceKey), is.Not(is.Nil()))
+	then.AssertThat(t, bpmnEngine.jobs.records(), has.Length(1))
+	then.AssertThat(t, bpmnEngine.jobs.records()[0].ProcessInstanceKey, is.EqualTo(active.InstanceKey))
+	then.AssertThat(t, deletionExporter.deletedInstanceKeys, is.EqualTo([]int64{completed.InstanceKey}))
+}
+
+func Test_PurgeCompleted_keeps_instances_completed_later(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// when
+	purged := bpmnEngine.PurgeCompleted(instance.CompletedAt)
+
+	// then
+	then.AssertThat(t, purged, is.EqualTo(0))
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(1))
+}
+
+func Test_PurgeCompleted_keeps_instances_without_completion_time(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+	instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// given
+	// like instances unmarshalled from data before serializer version 3
+	instance.CompletedAt = time.Time{}
+
+	// when
+	purged := bpmnEngine.PurgeCompleted(time.Now())
+
+	// then
+	then.AssertThat(t, purged, is.EqualTo(0))
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(1))
+}
+
+func Test_instance_retention_removes_instances_after_time_to_live(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+	first, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	first.CompletedAt = first.CompletedAt.Add(-time.Hour)
+
+	// when
+	bpmnEngine.SetInstanceRetention(InstanceRetention{TimeToLive: time.Minute})
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(1))
+	then.AssertThat(t, bpmnEngine.ProcessInstances()[0].InstanceKey, is.EqualTo(second.InstanceKey))
+}
+
+func Test_instance_retention_keeps_max_completed_instances(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+	bpmnEngine.SetInstanceRetention(InstanceRetention{MaxCompletedInstances: 2})
+
+	// when
+	var instances []*processInstanceInfo
+	for i := 0; i < 3; i++ {
+		instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+		instances = append(instances, instance)
+	}
+
+	// then
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(2))
+	then.AssertThat(t, bpmnEngine.FindProcessInstance(instances[0].InstanceKey), is.Nil())
+}
+
+func Test_instance_retention_removes_instances_in_the_order_of_their_completion(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, _ := bpmnEngine.LoadFromFile("../../test-cases/simple_task.bpmn")
+	first, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	bpmnEngine.SetInstanceRetention(InstanceRetention{MaxCompletedInstances: 1})
+	bpmnEngine.NewTaskHandler().Id("id").Handler(func(job ActivatedJob) {
+		job.Complete()
+	})
+
+	// when
+	_, _ = bpmnEngine.RunOrContinueInstance(second.InstanceKey)
+	_, _ = bpmnEngine.RunOrContinueInstance(first.InstanceKey)
+
+	// then
+	then.AssertThat(t, bpmnEngine.ProcessInstances(), has.Length(1))
+	then.AssertThat(t, bpmnEngine.FindProcessInstance(first.InstanceKey), is.Not(is.Nil()))
+	then.AssertThat(t, bpmnEngine.FindProcessInstance(second.InstanceKey), is.Nil())
+}
+
+func Test_instance_retention_removes_unmarshalled_instances(t *testing.T) {
+	// setup
+	bpmnEngine, process := newEngineWithCompletingTask(t)
+	first, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	second, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	first.CompletedAt = second.CompletedAt.Add(time.Second)
+	restored, err := Unmarshal(bpmnEngine.Marshal())
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	restored.SetInstanceRetention(InstanceRetention{MaxCompletedInstances: 1})
+
+	// then
+	then.AssertThat(t, restored.ProcessInstances(), has.Length(1))
+	then.AssertThat(t, restored.FindProcessInstance(first.InstanceKey), is.Not(is.Nil()))
+}
+
+func Test_completion_time_survives_marshalling(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine, process := newEngineWithCompletingTask(t)
+		instance, _ := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+		// when
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restored.FindProcessInstance(instance.InstanceKey).CompletedAt.Equal(instance.CompletedAt), is.True())
+	})
+}
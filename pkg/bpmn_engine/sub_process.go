@@ -14,6 +14,9 @@ type subProcessInfo struct {
 	processState    ActivityState
 	variableHolder  VariableHolder
 	baseElement     *BPMN20.BaseElement
+	parentActivity  activity // the activity of the (sub) process, which runs this sub-process
+	// boundaryEventCommands continue the flow at the triggered boundary events, when the sub-process returns
+	boundaryEventCommands []command
 }
 
 func (sb *subProcessInfo) Key() int64 {
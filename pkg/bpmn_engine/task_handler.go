@@ -116,10 +116,10 @@ func (thc newTaskHandlerCommand) CandidateGroups(groups ...string) NewTaskHandle
 
 func (state *BpmnEngineState) findTaskHandler(element *BPMN20.TaskElement) func(job ActivatedJob) {
 	searchOrder := []taskHandlerType{taskHandlerForId}
-	if (*element).GetType() == BPMN20.ServiceTask {
+	switch (*element).GetType() {
+	case BPMN20.ServiceTask, BPMN20.BusinessRuleTask, BPMN20.ScriptTask, BPMN20.SendTask:
 		searchOrder = append(searchOrder, taskHandlerForType)
-	}
-	if (*element).GetType() == BPMN20.UserTask {
+	case BPMN20.UserTask:
 		searchOrder = append(searchOrder, taskHandlerForAssignee, taskHandlerForCandidateGroups)
 	}
 	for _, handlerType := range searchOrder {
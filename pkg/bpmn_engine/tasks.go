@@ -1,17 +1,33 @@
 package bpmn_engine
 
-import "github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+import (
+	"fmt"
+	"reflect"
 
-func (state *BpmnEngineState) handleServiceTask(process BPMN20.ProcessElement, instance *processInstanceInfo, element *BPMN20.TaskElement) (bool, *job) {
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// handleServiceTask calls the task handler of the element's job; the given variables are local to the job, e.g. the loopCounter
+func (state *BpmnEngineState) handleServiceTask(process BPMN20.ProcessElement, instance *processInstanceInfo, element *BPMN20.TaskElement, variables map[string]interface{}) (bool, *job) {
+	return state.handleJob(instance, element, variables)
+}
+
+// handleJob calls the task handler of the element's job, when there's one;
+// the given variables take precedence over the process instance's variables,
+// and are not propagated back to the process instance, unless the handler changed them
+func (state *BpmnEngineState) handleJob(instance *processInstanceInfo, element *BPMN20.TaskElement, variables map[string]interface{}) (bool, *job) {
 	job := findOrCreateJob(&state.jobs, element, instance, state.generateKey)
 
 	handler := state.findTaskHandler(element)
 	if handler != nil {
 		job.JobState = Active
 		variableHolder := NewVarHolder(&instance.VariableHolder, nil)
+		for k, v := range variables {
+			variableHolder.SetVariable(k, v)
+		}
 		activatedJob := &activatedJob{
 			processInstanceInfo:      instance,
-			failHandler:              func(reason string) { job.JobState = Failed },
+			failHandler:              func(reason string) { job.JobState = Failed; job.failReason = reason },
 			completeHandler:          func() { job.JobState = Completed },
 			key:                      state.generateKey(),
 			processInstanceKey:       instance.InstanceKey,
@@ -22,15 +38,22 @@ func (state *BpmnEngineState) handleServiceTask(process BPMN20.ProcessElement, i
 			createdAt:                job.CreatedAt,
 			variableHolder:           variableHolder,
 		}
-		if err := evaluateLocalVariables(&variableHolder, (*element).GetInputMapping()); err != nil {
+		if err := evaluateLocalVariables(instance.ProcessInfo.graph.expressions, &variableHolder, (*element).GetInputMapping()); err != nil {
 			job.JobState = Failed
+			job.failReason = err.Error()
 			instance.ActivityState = Failed
 			return false, job
 		}
 		handler(activatedJob)
 		if job.JobState == Completed {
-			if err := propagateProcessInstanceVariables(&variableHolder, (*element).GetOutputMapping()); err != nil {
+			for k, v := range variables {
+				if len((*element).GetOutputMapping()) == 0 && reflect.DeepEqual(variableHolder.GetVariable(k), v) {
+					delete(variableHolder.variables, k)
+				}
+			}
+			if err := propagateProcessInstanceVariables(instance.ProcessInfo.graph.expressions, &variableHolder, (*element).GetOutputMapping()); err != nil {
 				job.JobState = Failed
+				job.failReason = err.Error()
 				instance.ActivityState = Failed
 			}
 		}
@@ -39,8 +62,86 @@ func (state *BpmnEngineState) handleServiceTask(process BPMN20.ProcessElement, i
 	return job.JobState == Completed, job
 }
 
-func (state *BpmnEngineState) handleUserTask(process BPMN20.ProcessElement, instance *processInstanceInfo, element *BPMN20.TaskElement) *job {
+func (state *BpmnEngineState) handleUserTask(process BPMN20.ProcessElement, instance *processInstanceInfo, element *BPMN20.TaskElement, variables map[string]interface{}) *job {
 	// TODO consider different handlers, since Service Tasks are different in their definition than user tasks
-	_, j := state.handleServiceTask(process, instance, element)
+	_, j := state.handleServiceTask(process, instance, element, variables)
 	return j
 }
+
+// handleBusinessRuleTask evaluates the called decision (see LoadDecisionFromBytes) synchronously,
+// and stores the result in the result variable, which is propagated to the process instance by the output mappings;
+// business rule tasks without a called decision are handled like service tasks
+func (state *BpmnEngineState) handleBusinessRuleTask(instance *processInstanceInfo, element *BPMN20.BaseElement, task BPMN20.TBusinessRuleTask) (activity, error) {
+	activity := &elementActivity{
+		key:     state.generateKey(),
+		state:   Failed,
+		element: element,
+	}
+	decision := state.findLatestDecision(task.CalledDecision.DecisionId)
+	if decision == nil {
+		return activity, newEngineErrorf("no decision found with id=%s, called by business rule task id=%s",
+			task.CalledDecision.DecisionId, task.Id)
+	}
+	variableHolder := NewVarHolder(&instance.VariableHolder, nil)
+	if err := evaluateLocalVariables(instance.ProcessInfo.graph.expressions, &variableHolder, task.Input); err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating input mapping in business rule task id='%s' name='%s'", task.Id, task.Name),
+			Err: err,
+		}
+	}
+	result, err := decision.table.evaluate(variableHolder.Variables())
+	if err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating decision id=%s version=%d in business rule task id=%s", decision.DecisionId, decision.Version, task.Id),
+			Err: err,
+		}
+	}
+	if task.CalledDecision.ResultVariable != "" {
+		variableHolder.SetVariable(task.CalledDecision.ResultVariable, result)
+	}
+	if err := propagateProcessInstanceVariables(instance.ProcessInfo.graph.expressions, &variableHolder, task.Output); err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating output mapping in business rule task id='%s' name='%s'", task.Id, task.Name),
+			Err: err,
+		}
+	}
+	activity.state = Completed
+	return activity, nil
+}
+
+// handleScriptTask evaluates the FEEL expression of the script synchronously,
+// and stores the result in the result variable, which is propagated to the process instance by the output mappings;
+// script tasks without a script expression are handled like service tasks
+func (state *BpmnEngineState) handleScriptTask(instance *processInstanceInfo, element *BPMN20.BaseElement, task BPMN20.TScriptTask) (activity, error) {
+	activity := &elementActivity{
+		key:     state.generateKey(),
+		state:   Failed,
+		element: element,
+	}
+	expressions := instance.ProcessInfo.graph.expressions
+	variableHolder := NewVarHolder(&instance.VariableHolder, nil)
+	if err := evaluateLocalVariables(expressions, &variableHolder, task.Input); err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating input mapping in script task id='%s' name='%s'", task.Id, task.Name),
+			Err: err,
+		}
+	}
+	result, err := expressions.evaluate(ExpressionLanguageFEEL, task.Script.Expression, variableHolder.Variables())
+	if err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating script expression in script task id=%s", task.Id),
+			Err: err,
+		}
+	}
+	if task.Script.ResultVariable != "" {
+		variableHolder.SetVariable(task.Script.ResultVariable, result)
+	}
+	if err := propagateProcessInstanceVariables(expressions, &variableHolder, task.Output); err != nil {
+		return activity, &ExpressionEvaluationError{
+			Msg: fmt.Sprintf("Error evaluating output mapping in script task id='%s' name='%s'", task.Id, task.Name),
+			Err: err,
+		}
+	}
+	activity.state = Completed
+	return activity, nil
+}
@@ -1,12 +1,29 @@
 package bpmn_engine
 
 import (
+	"errors"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/corbym/gocrest/has"
 	"github.com/corbym/gocrest/is"
 	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
 )
 
+type elementRecordingExporter struct {
+	elements []string
+}
+
+func (e *elementRecordingExporter) NewProcessEvent(*exporter.ProcessEvent)                    {}
+func (e *elementRecordingExporter) EndProcessEvent(*exporter.ProcessInstanceEvent)            {}
+func (e *elementRecordingExporter) NewProcessInstanceEvent(*exporter.ProcessInstanceEvent)    {}
+func (e *elementRecordingExporter) DeleteProcessInstanceEvent(*exporter.ProcessInstanceEvent) {}
+func (e *elementRecordingExporter) NewElementEvent(_ *exporter.ProcessInstanceEvent, info *exporter.ElementInfo) {
+	e.elements = append(e.elements, info.BpmnElementType+":"+info.ElementId+":"+info.Intent)
+}
+
 func Test_user_tasks_can_be_handled(t *testing.T) {
 	// setup
 	bpmnEngine := New()
@@ -47,3 +64,210 @@ func Test_user_tasks_can_be_continue(t *testing.T) {
 	then.AssertThat(t, instance.ActivityState, is.EqualTo(Completed))
 	then.AssertThat(t, cp.CallPath, is.EqualTo("user-task"))
 }
+
+func Test_script_task_stores_expression_result_in_result_variable(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/script-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	var total interface{}
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(func(job ActivatedJob) {
+		total = job.Variable("total")
+		job.Complete()
+	})
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+		"prices":   []int{10, 20, 30},
+		"discount": 0.5,
+	})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, total, is.EqualTo(30))
+	then.AssertThat(t, instance.GetVariable("total"), is.EqualTo(30))
+}
+
+func Test_script_task_fails_instance_when_evaluation_fails(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/script-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	cp := CallPath{}
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+		"prices":   []int{10, 20, 30},
+		"discount": "none",
+	})
+
+	// then
+	var evaluationError *ExpressionEvaluationError
+	then.AssertThat(t, errors.As(err, &evaluationError), is.True())
+	then.AssertThat(t, err.Error(), is.ValueContaining("script task id=calculate-total"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo(""))
+}
+
+func Test_script_task_fails_instance_when_mapping_fails(t *testing.T) {
+	tests := []struct {
+		mapping string
+		message string
+	}{
+		{`<zeebe:input source="=1 - rebate" target="factor" />`, "input mapping"},
+		{`<zeebe:output source="=total - rebate" target="shipping" />`, "output mapping"},
+	}
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			xmlData, err := os.ReadFile("../../test-cases/script-task.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			xmlData = []byte(strings.Replace(string(xmlData), `resultVariable="total" />`, `resultVariable="total" /><zeebe:ioMapping>`+test.mapping+`</zeebe:ioMapping>`, 1))
+			process, err := bpmnEngine.LoadFromBytes(xmlData)
+			then.AssertThat(t, err, is.Nil())
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+				"prices":   []int{10},
+				"discount": 0,
+				"rebate":   "none",
+			})
+
+			// then
+			var evaluationError *ExpressionEvaluationError
+			then.AssertThat(t, errors.As(err, &evaluationError), is.True())
+			then.AssertThat(t, evaluationError.Msg, is.EqualTo("Error evaluating "+test.message+" in script task id='calculate-total' name='Calculate total'"))
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+		})
+	}
+}
+
+func Test_loading_script_task_with_invalid_expression_fails(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	xmlData, err := os.ReadFile("../../test-cases/script-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), `expression="=sum(prices) * (1 - discount)"`, `expression="=sum(prices"`, 1))
+
+	// when
+	_, err = bpmnEngine.LoadFromBytes(xmlData)
+
+	// then
+	var syntaxError *ExpressionSyntaxError
+	then.AssertThat(t, errors.As(err, &syntaxError), is.True())
+	then.AssertThat(t, syntaxError.ElementId, is.EqualTo("calculate-total"))
+	then.AssertThat(t, syntaxError.Attribute, is.EqualTo("zeebe:script"))
+}
+
+func Test_script_task_exports_element_events(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	recorder := &elementRecordingExporter{}
+	bpmnEngine.AddEventExporter(recorder)
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/script-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(func(job ActivatedJob) { job.Complete() })
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{
+		"prices":   []int{10},
+		"discount": 0,
+	})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, recorder.elements, is.ValueContaining(
+		"SCRIPT_TASK:calculate-total:"+string(exporter.ElementActivated),
+		"SCRIPT_TASK:calculate-total:"+string(exporter.ElementCompleted),
+	))
+}
+
+func Test_script_task_without_script_expression_is_handled_like_a_service_task(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	xmlData, err := os.ReadFile("../../test-cases/script-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData),
+		`<zeebe:script expression="=sum(prices) * (1 - discount)" resultVariable="total" />`,
+		`<zeebe:taskDefinition type="calculate" />`, 1))
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	cp := CallPath{}
+	bpmnEngine.NewTaskHandler().Type("calculate").Handler(cp.TaskHandler)
+	bpmnEngine.NewTaskHandler().Type("ship").Handler(cp.TaskHandler)
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("calculate-total,ship"))
+}
+
+func Test_send_receive_manual_and_plain_tasks(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	recorder := &elementRecordingExporter{}
+	bpmnEngine.AddEventExporter(recorder)
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/send-receive-manual-task.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	cp := CallPath{}
+	bpmnEngine.NewTaskHandler().Type("send-invoice").Handler(cp.TaskHandler)
+
+	// given
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Active))
+	subscriptions := bpmnEngine.GetMessageSubscriptions()
+	then.AssertThat(t, subscriptions, has.Length(1))
+	then.AssertThat(t, subscriptions[0].ElementId, is.EqualTo("receive-payment"))
+	then.AssertThat(t, subscriptions[0].State(), is.EqualTo(Active))
+
+	// when
+	err = bpmnEngine.PublishEventForInstance(instance.InstanceKey, "payment", map[string]interface{}{"amount": 42})
+	then.AssertThat(t, err, is.Nil())
+	_, err = bpmnEngine.RunOrContinueInstance(instance.InstanceKey)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("send-invoice"))
+	then.AssertThat(t, instance.GetVariable("paidAmount"), is.EqualTo(42))
+	then.AssertThat(t, bpmnEngine.GetMessageSubscriptions()[0].State(), is.EqualTo(Completed))
+	then.AssertThat(t, recorder.elements, is.ValueContaining(
+		"SEND_TASK:send-invoice:"+string(exporter.ElementCompleted),
+		"RECEIVE_TASK:receive-payment:"+string(exporter.ElementCompleted),
+		"MANUAL_TASK:pack:"+string(exporter.ElementCompleted),
+		"TASK:ship:"+string(exporter.ElementCompleted),
+	))
+}
+
+func Test_receive_task_subscription_survives_marshalling(t *testing.T) {
+	forEachEncoding(t, func(t *testing.T, options ...MarshalOption) {
+		// setup
+		bpmnEngine := New()
+		process, err := bpmnEngine.LoadFromFile("../../test-cases/send-receive-manual-task.bpmn")
+		then.AssertThat(t, err, is.Nil())
+		bpmnEngine.NewTaskHandler().Type("send-invoice").Handler(func(job ActivatedJob) { job.Complete() })
+		instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, nil)
+		then.AssertThat(t, err, is.Nil())
+
+		// given
+		restored, err := Unmarshal(bpmnEngine.Marshal(options...))
+		then.AssertThat(t, err, is.Nil())
+
+		// when
+		err = restored.PublishEventForInstance(instance.InstanceKey, "payment", map[string]interface{}{"amount": 42})
+		then.AssertThat(t, err, is.Nil())
+		restoredInstance, err := restored.RunOrContinueInstance(instance.InstanceKey)
+
+		// then
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, restoredInstance.GetState(), is.EqualTo(Completed))
+		then.AssertThat(t, restoredInstance.GetVariable("paidAmount"), is.EqualTo(42))
+	})
+}
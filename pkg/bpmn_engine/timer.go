@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+	"github.com/pbinitiative/feel"
 	"github.com/senseyeio/duration"
 )
 
@@ -80,7 +81,7 @@ func (state *BpmnEngineState) handleIntermediateTimerCatchEvent(instance *proces
 	}
 
 	if timer == nil {
-		timer, err = state.createTimer(instance, ice, originActivity)
+		timer, err = state.createTimer(instance, ice, ice.TimerEventDefinition, originActivity)
 		if err != nil {
 			evalErr := &ExpressionEvaluationError{
 				Msg: fmt.Sprintf("Error evaluating expression in intermediate timer cacht event element id='%s' name='%s'", ice.Id, ice.Name),
@@ -104,17 +105,18 @@ func (state *BpmnEngineState) handleIntermediateTimerCatchEvent(instance *proces
 	return false, timer, err
 }
 
-func (state *BpmnEngineState) createTimer(instance *processInstanceInfo, ice BPMN20.TIntermediateCatchEvent, originActivity activity) (*Timer, error) {
+// createTimer creates a timer for the element, which is either an intermediate timer catch event,
+// or the start event of an event sub-process
+func (state *BpmnEngineState) createTimer(instance *processInstanceInfo, be BPMN20.BaseElement, timerDefinition BPMN20.TTimerEventDefinition, originActivity activity) (*Timer, error) {
 	variableContext := instance.VariableHolder.Variables()
-	durationVal, err := findDurationValue(ice, variableContext)
+	durationVal, err := findDurationValue(instance.ProcessInfo.graph.expressions, be, timerDefinition.TimeDuration, variableContext)
 	if err != nil {
 		return nil, &BpmnEngineError{Msg: fmt.Sprintf("Error parsing 'timeDuration' value "+
-			"from element with ID=%s. Error:%s", ice.Id, err.Error())}
+			"from element with ID=%s. Error:%s", be.GetId(), err.Error())}
 	}
-	var be BPMN20.BaseElement = ice
 	now := time.Now()
 	t := &Timer{
-		ElementId:          ice.Id,
+		ElementId:          be.GetId(),
 		ElementInstanceKey: state.generateKey(),
 		ProcessKey:         instance.ProcessInfo.ProcessKey,
 		ProcessInstanceKey: instance.InstanceKey,
@@ -125,14 +127,14 @@ func (state *BpmnEngineState) createTimer(instance *processInstanceInfo, ice BPM
 		baseElement:        &be,
 		originActivity:     originActivity,
 	}
-	state.timers = append(state.timers, t)
+	state.timers.add(t)
 	return t, nil
 }
 
 func findExistingTimerNotYetTriggered(state *BpmnEngineState, id string, instance *processInstanceInfo) *Timer {
 	var t *Timer
-	for _, timer := range state.timers {
-		if timer.ElementId == id && timer.ProcessInstanceKey == instance.GetInstanceKey() && timer.TimerState == TimerCreated {
+	for _, timer := range state.timers.ofElement(instance.GetInstanceKey(), id) {
+		if timer.TimerState == TimerCreated {
 			t = timer
 			break
 		}
@@ -140,30 +142,39 @@ func findExistingTimerNotYetTriggered(state *BpmnEngineState, id string, instanc
 	return t
 }
 
-func findDurationValue(ice BPMN20.TIntermediateCatchEvent, variableContext map[string]interface{}) (duration.Duration, error) {
-	durationStr := ice.TimerEventDefinition.TimeDuration.XMLText
+func findDurationValue(expressions compiledExpressions, element BPMN20.BaseElement, timeDuration BPMN20.TTimeDuration, variableContext map[string]interface{}) (duration.Duration, error) {
+	durationStr := timeDuration.XMLText
 
 	// Check if it is expression
 	if strings.HasPrefix(durationStr, "=") {
-		v, err := evaluateExpression(durationStr, variableContext)
+		v, err := expressions.evaluate(timeDuration.Language, timerDurationExpression(timeDuration), variableContext)
 		if err != nil {
 			return duration.Duration{}, &ExpressionEvaluationError{
-				Msg: fmt.Sprintf("Error evaluating expression for timer id='%s' name='%s'", ice.Id, ice.Name),
+				Msg: fmt.Sprintf("Error evaluating expression for timer id='%s' name='%s'", element.GetId(), element.GetName()),
 				Err: err,
 			}
 		}
-		if dur, ok := v.(string); ok {
+		switch dur := v.(type) {
+		case string:
 			durationStr = dur
-		} else {
+		case time.Duration:
+			durationStr = toFeelValue(dur).(*feel.FEELDuration).String()
+		default:
 			return duration.Duration{}, &ExpressionEvaluationError{
-				Msg: fmt.Sprintf("Expression \"%s\" evaluated to a an invalid value for timer id='%s' name='%s'", durationStr, ice.Id, ice.Name),
+				Msg: fmt.Sprintf("Expression \"%s\" evaluated to a an invalid value for timer id='%s' name='%s'", durationStr, element.GetId(), element.GetName()),
 				Err: errors.New("expression evaluated to an invalid type"),
 			}
 		}
 	}
 
 	if len(strings.TrimSpace(durationStr)) == 0 {
-		return duration.Duration{}, newEngineErrorf("Can't find 'timeDuration' value for INTERMEDIATE_CATCH_EVENT with id=%s", ice.Id)
+		return duration.Duration{}, newEngineErrorf("Can't find 'timeDuration' value for %s with id=%s", element.GetType(), element.GetId())
 	}
 	return duration.ParseISO8601(durationStr)
 }
+
+// timerDurationExpression returns the expression of a time duration, which is marked by a leading '=',
+// without this marker, so that it's not passed to expression languages other than FEEL
+func timerDurationExpression(timeDuration BPMN20.TTimeDuration) string {
+	return strings.TrimPrefix(strings.TrimSpace(timeDuration.XMLText), "=")
+}
@@ -0,0 +1,36 @@
+package bpmn_engine
+
+import (
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20"
+)
+
+// cancelTransaction compensates the completed activities of the transaction, which contains the cancel end event,
+// and continues the flow at the transaction's cancel boundary event, which interrupts the transaction;
+// while a compensation handler's job isn't completed, the cancel end event waits
+func (state *BpmnEngineState) cancelTransaction(act activity, instance *processInstanceInfo, cancelEndEvent *BPMN20.BaseElement) []command {
+	graph := instance.ProcessInfo.graph
+	transactionId := graph.scopes[(*cancelEndEvent).GetId()].GetId()
+	transaction := runningSubProcess(act, transactionId)
+	if transaction == nil || (*transaction.Element()).GetType() != BPMN20.Transaction {
+		return []command{errorCommand{
+			err:         newEngineErrorf("cancel end event id=%s is not within a running transaction", (*cancelEndEvent).GetId()),
+			elementId:   (*cancelEndEvent).GetId(),
+			elementName: (*cancelEndEvent).GetName(),
+		}}
+	}
+	boundaryEvent := graph.catchingBoundaryEvent(transactionId, func(catchEvent BPMN20.BaseElement) (bool, bool) {
+		return catchEvent.(BPMN20.TBoundaryEvent).CancelEventDefinition.Id != "", true
+	})
+	if boundaryEvent == nil {
+		return []command{errorCommand{
+			err:         newEngineErrorf("no cancel boundary event is attached to transaction id=%s", transactionId),
+			elementId:   (*cancelEndEvent).GetId(),
+			elementName: (*cancelEndEvent).GetName(),
+		}}
+	}
+	if !state.throwCompensation(instance, cancelEndEvent, "") {
+		return nil
+	}
+	state.triggerBoundaryEvent(instance, transaction, boundaryEvent)
+	return nil
+}
@@ -0,0 +1,89 @@
+package bpmn_engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine/exporter"
+)
+
+func Test_transaction_outcomes(t *testing.T) {
+	tests := []struct {
+		outcome  string
+		payment  string
+		callPath string
+	}{
+		{"successful", "ok", "book-hotel,charge-card,confirm"},
+		{"cancelled", "declined", "book-hotel,charge-card,cancel-hotel,notify-customer"},
+		{"hazard", "fraud", "book-hotel,charge-card,block-customer"},
+	}
+	for _, test := range tests {
+		t.Run(test.outcome, func(t *testing.T) {
+			// setup
+			bpmnEngine := New()
+			cp := CallPath{}
+			process, err := bpmnEngine.LoadFromFile("../../test-cases/transaction-booking.bpmn")
+			then.AssertThat(t, err, is.Nil())
+			for _, taskType := range []string{"book-hotel", "charge-card", "cancel-hotel", "confirm", "notify-customer", "block-customer"} {
+				bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+			}
+
+			// when
+			instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": test.payment})
+
+			// then
+			then.AssertThat(t, err, is.Nil())
+			then.AssertThat(t, instance.GetState(), is.EqualTo(Completed))
+			then.AssertThat(t, cp.CallPath, is.EqualTo(test.callPath))
+		})
+	}
+}
+
+func Test_transaction_is_exported_as_transaction_element(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	recorder := &elementRecordingExporter{}
+	bpmnEngine.AddEventExporter(recorder)
+	cp := CallPath{}
+	process, err := bpmnEngine.LoadFromFile("../../test-cases/transaction-booking.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	for _, taskType := range []string{"book-hotel", "charge-card", "confirm"} {
+		bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+	}
+
+	// when
+	_, err = bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": "ok"})
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, recorder.elements, is.ValueContaining(
+		"TRANSACTION:booking:"+string(exporter.ElementActivated),
+		"TRANSACTION:booking:"+string(exporter.ElementCompleted),
+	))
+}
+
+func Test_cancel_end_event_without_cancel_boundary_event_fails_the_instance(t *testing.T) {
+	// setup
+	bpmnEngine := New()
+	cp := CallPath{}
+	xmlData, err := os.ReadFile("../../test-cases/transaction-booking.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	xmlData = []byte(strings.Replace(string(xmlData), `<bpmn:cancelEventDefinition id="CancelEventDefinition_2" />`, "", 1))
+	process, err := bpmnEngine.LoadFromBytes(xmlData)
+	then.AssertThat(t, err, is.Nil())
+	for _, taskType := range []string{"book-hotel", "charge-card", "cancel-hotel"} {
+		bpmnEngine.NewTaskHandler().Type(taskType).Handler(cp.TaskHandler)
+	}
+
+	// when
+	instance, err := bpmnEngine.CreateAndRunInstance(process.ProcessKey, map[string]interface{}{"payment": "declined"})
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.EqualTo("no cancel boundary event is attached to transaction id=booking"))
+	then.AssertThat(t, instance.GetState(), is.EqualTo(Failed))
+	then.AssertThat(t, cp.CallPath, is.EqualTo("book-hotel,charge-card"))
+}
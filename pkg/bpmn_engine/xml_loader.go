@@ -29,19 +29,74 @@ func (state *BpmnEngineState) LoadFromBytes(xmlData []byte) (*ProcessInfo, error
 	return state.load(xmlData, "")
 }
 
+// LoadAllFromFile loads a given BPMN file by filename into the engine
+// and returns ProcessInfo details for every deployed workflow, e.g. for every pool of a collaboration
+func (state *BpmnEngineState) LoadAllFromFile(filename string) ([]*ProcessInfo, error) {
+	xmlData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return state.loadProcesses(xmlData, filename)
+}
+
+// LoadAllFromBytes loads a given BPMN file by xmlData byte array into the engine
+// and returns ProcessInfo details for every deployed workflow, e.g. for every pool of a collaboration
+func (state *BpmnEngineState) LoadAllFromBytes(xmlData []byte) ([]*ProcessInfo, error) {
+	return state.loadProcesses(xmlData, "")
+}
+
+// load deploys every process of the definitions, e.g. the pools of a collaboration, as its own ProcessInfo,
+// and returns the first one; LoadAllFromFile and LoadAllFromBytes return all of them
 func (state *BpmnEngineState) load(xmlData []byte, resourceName string) (*ProcessInfo, error) {
+	processes, err := state.loadProcesses(xmlData, resourceName)
+	if err != nil {
+		return nil, err
+	}
+	return processes[0], nil
+}
+
+// loadProcesses deploys the processes of the definitions, in order of the definitions;
+// none of them is deployed, when any of them is invalid
+func (state *BpmnEngineState) loadProcesses(xmlData []byte, resourceName string) ([]*ProcessInfo, error) {
 	md5sum := md5.Sum(xmlData)
 	var definitions BPMN20.TDefinitions
 	err := xml.Unmarshal(xmlData, &definitions)
 	if err != nil {
 		return nil, err
 	}
+	if len(definitions.Processes) == 0 {
+		return nil, newEngineErrorf("no process found in the BPMN definitions")
+	}
+
+	processDefinitions := make([]BPMN20.TDefinitions, len(definitions.Processes))
+	graphs := make([]*processGraph, len(definitions.Processes))
+	for i, process := range definitions.Processes {
+		processDefinitions[i] = definitions
+		processDefinitions[i].Process = process
+		if graphs[i], err = newProcessGraph(processDefinitions[i], state.expressionEvaluators); err != nil {
+			return nil, err
+		}
+	}
+	if err = addMessageFlows(definitions, graphs); err != nil {
+		return nil, err
+	}
+
+	var result []*ProcessInfo
+	for i := range processDefinitions {
+		result = append(result, state.deployProcess(xmlData, resourceName, md5sum, processDefinitions[i], graphs[i]))
+	}
+	return result, nil
+}
 
+// deployProcess returns the already deployed process, when the definitions are unchanged,
+// or deploys it as a new version otherwise
+func (state *BpmnEngineState) deployProcess(xmlData []byte, resourceName string, md5sum [16]byte, definitions BPMN20.TDefinitions, graph *processGraph) *ProcessInfo {
 	processInfo := ProcessInfo{
 		Version:          1,
 		BpmnProcessId:    definitions.Process.Id,
 		ProcessKey:       state.generateKey(),
 		definitions:      definitions,
+		graph:            graph,
 		bpmnData:         compressAndEncode(xmlData),
 		bpmnResourceName: resourceName,
 		bpmnChecksum:     md5sum,
@@ -49,7 +104,7 @@ func (state *BpmnEngineState) load(xmlData []byte, resourceName string) (*Proces
 	for _, process := range state.processes {
 		if process.BpmnProcessId == definitions.Process.Id {
 			if areEqual(process.bpmnChecksum, md5sum) {
-				return process, nil
+				return process
 			} else {
 				processInfo.Version = process.Version + 1
 			}
@@ -58,7 +113,7 @@ func (state *BpmnEngineState) load(xmlData []byte, resourceName string) (*Proces
 	state.processes = append(state.processes, &processInfo)
 
 	state.exportNewProcessEvent(processInfo, xmlData, resourceName, hex.EncodeToString(md5sum[:]))
-	return &processInfo, nil
+	return &processInfo
 }
 
 func compressAndEncode(data []byte) string {
@@ -1,17 +1,61 @@
 package BPMN20
 
-import "github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20/extensions"
+import (
+	"encoding/xml"
+
+	"github.com/nitram509/lib-bpmn-engine/pkg/spec/BPMN20/extensions"
+)
 
 type TDefinitions struct {
-	Id                 string     `xml:"id,attr"`
-	Name               string     `xml:"name,attr"`
-	TargetNamespace    string     `xml:"targetNamespace,attr"`
-	ExpressionLanguage string     `xml:"expressionLanguage,attr"`
-	TypeLanguage       string     `xml:"typeLanguage,attr"`
-	Exporter           string     `xml:"exporter,attr"`
-	ExporterVersion    string     `xml:"exporterVersion,attr"`
-	Process            TProcess   `xml:"process"`
-	Messages           []TMessage `xml:"message"`
+	Id                 string           `xml:"id,attr"`
+	Name               string           `xml:"name,attr"`
+	TargetNamespace    string           `xml:"targetNamespace,attr"`
+	ExpressionLanguage string           `xml:"expressionLanguage,attr"`
+	TypeLanguage       string           `xml:"typeLanguage,attr"`
+	Exporter           string           `xml:"exporter,attr"`
+	ExporterVersion    string           `xml:"exporterVersion,attr"`
+	Process            TProcess         `xml:"-"` // the first of the Processes, or the process of a deployed ProcessInfo
+	Processes          []TProcess       `xml:"process"`
+	Collaborations     []TCollaboration `xml:"collaboration"`
+	Messages           []TMessage       `xml:"message"`
+	Errors             []TError         `xml:"error"`
+	Signals            []TSignal        `xml:"signal"`
+	Escalations        []TEscalation    `xml:"escalation"`
+}
+
+// UnmarshalXML decodes the definitions and sets Process to the first of its processes
+func (definitions *TDefinitions) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plainDefinitions TDefinitions
+	if err := d.DecodeElement((*plainDefinitions)(definitions), &start); err != nil {
+		return err
+	}
+	if len(definitions.Processes) > 0 {
+		definitions.Process = definitions.Processes[0]
+	}
+	return nil
+}
+
+// TCollaboration connects the processes of its participants (the pools) by message flows
+type TCollaboration struct {
+	TRootElement
+	Name         string         `xml:"name,attr"`
+	Participants []TParticipant `xml:"participant"`
+	MessageFlows []TMessageFlow `xml:"messageFlow"`
+}
+
+type TParticipant struct {
+	TBaseElement
+	Name       string `xml:"name,attr"`
+	ProcessRef string `xml:"processRef,attr"` // empty for a black box pool
+}
+
+// TMessageFlow delivers a message from an element of one process to an element or participant of another process
+type TMessageFlow struct {
+	TBaseElement
+	Name       string `xml:"name,attr"`
+	SourceRef  string `xml:"sourceRef,attr"`
+	TargetRef  string `xml:"targetRef,attr"`
+	MessageRef string `xml:"messageRef,attr"`
 }
 
 type TCallableElement struct {
@@ -30,30 +74,52 @@ type TProcess struct {
 	SequenceFlows                []TSequenceFlow           `xml:"sequenceFlow"`
 	ServiceTasks                 []TServiceTask            `xml:"serviceTask"`
 	UserTasks                    []TUserTask               `xml:"userTask"`
+	BusinessRuleTasks            []TBusinessRuleTask       `xml:"businessRuleTask"`
+	ScriptTasks                  []TScriptTask             `xml:"scriptTask"`
+	SendTasks                    []TSendTask               `xml:"sendTask"`
+	ReceiveTasks                 []TReceiveTask            `xml:"receiveTask"`
+	ManualTasks                  []TManualTask             `xml:"manualTask"`
+	Tasks                        []TTask                   `xml:"task"`
 	SubProcesses                 []TSubProcess             `xml:"subProcess"`
+	Transactions                 []TSubProcess             `xml:"transaction"`
 	ParallelGateway              []TParallelGateway        `xml:"parallelGateway"`
 	ExclusiveGateway             []TExclusiveGateway       `xml:"exclusiveGateway"`
 	IntermediateCatchEvent       []TIntermediateCatchEvent `xml:"intermediateCatchEvent"`
 	IntermediateTrowEvent        []TIntermediateThrowEvent `xml:"intermediateThrowEvent"`
+	BoundaryEvents               []TBoundaryEvent          `xml:"boundaryEvent"`
 	EventBasedGateway            []TEventBasedGateway      `xml:"eventBasedGateway"`
 	InclusiveGateway             []TInclusiveGateway       `xml:"inclusiveGateway"`
+	ComplexGateway               []TComplexGateway         `xml:"complexGateway"`
+	Associations                 []TAssociation            `xml:"association"`
 }
 
 type TSubProcess struct {
 	TActivity
 	TriggeredByEvent       bool                      `xml:"triggeredByEvent,attr"`
+	IsTransaction          bool                      `xml:"-"`           // true for a transaction, see ProcessElement.GetSubProcess
+	Method                 string                    `xml:"method,attr"` // the transaction method
 	StartEvents            []TStartEvent             `xml:"startEvent"`
 	EndEvents              []TEndEvent               `xml:"endEvent"`
 	SequenceFlows          []TSequenceFlow           `xml:"sequenceFlow"`
 	ServiceTasks           []TServiceTask            `xml:"serviceTask"`
 	UserTasks              []TUserTask               `xml:"userTask"`
+	BusinessRuleTasks      []TBusinessRuleTask       `xml:"businessRuleTask"`
+	ScriptTasks            []TScriptTask             `xml:"scriptTask"`
+	SendTasks              []TSendTask               `xml:"sendTask"`
+	ReceiveTasks           []TReceiveTask            `xml:"receiveTask"`
+	ManualTasks            []TManualTask             `xml:"manualTask"`
+	Tasks                  []TTask                   `xml:"task"`
 	SubProcesses           []TSubProcess             `xml:"subProcess"`
+	Transactions           []TSubProcess             `xml:"transaction"`
 	ParallelGateway        []TParallelGateway        `xml:"parallelGateway"`
 	ExclusiveGateway       []TExclusiveGateway       `xml:"exclusiveGateway"`
 	IntermediateCatchEvent []TIntermediateCatchEvent `xml:"intermediateCatchEvent"`
 	IntermediateTrowEvent  []TIntermediateThrowEvent `xml:"intermediateThrowEvent"`
+	BoundaryEvents         []TBoundaryEvent          `xml:"boundaryEvent"`
 	EventBasedGateway      []TEventBasedGateway      `xml:"eventBasedGateway"`
 	InclusiveGateway       []TInclusiveGateway       `xml:"inclusiveGateway"`
+	ComplexGateway         []TComplexGateway         `xml:"complexGateway"`
+	Associations           []TAssociation            `xml:"association"`
 }
 
 // TBaseElement is an "abstract" struct
@@ -105,23 +171,42 @@ type TSequenceFlow struct {
 	ConditionExpression []TExpression `xml:"conditionExpression"`
 }
 
+type TAssociation struct {
+	TBaseElement
+	SourceRef            string `xml:"sourceRef,attr"`
+	TargetRef            string `xml:"targetRef,attr"`
+	AssociationDirection string `xml:"associationDirection,attr"`
+}
+
 type TExpression struct {
-	Text string `xml:",innerxml"`
+	Language string `xml:"language,attr"`
+	Text     string `xml:",innerxml"`
 }
 
 type TStartEvent struct {
 	TCatchEvent
-	IsInterrupting   bool `xml:"isInterrupting,attr"`
-	ParallelMultiple bool `xml:"parallelMultiple,attr"`
+	IsInterrupting             *bool                       `xml:"isInterrupting,attr"` // nil means true, see Interrupting
+	ParallelMultiple           bool                        `xml:"parallelMultiple,attr"`
+	MessageEventDefinition     TMessageEventDefinition     `xml:"messageEventDefinition"`
+	TimerEventDefinition       TTimerEventDefinition       `xml:"timerEventDefinition"`
+	ErrorEventDefinition       TErrorEventDefinition       `xml:"errorEventDefinition"`
+	SignalEventDefinition      TSignalEventDefinition      `xml:"signalEventDefinition"`
+	EscalationEventDefinition  TEscalationEventDefinition  `xml:"escalationEventDefinition"`
+	ConditionalEventDefinition TConditionalEventDefinition `xml:"conditionalEventDefinition"`
+	Output                     []extensions.TIoMapping     `xml:"extensionElements>ioMapping>output"`
 }
 
 type TEndEvent struct {
 	TThrowEvent
+	MessageEventDefinition    TMessageEventDefinition    `xml:"messageEventDefinition"`
+	ErrorEventDefinition      TErrorEventDefinition      `xml:"errorEventDefinition"`
+	EscalationEventDefinition TEscalationEventDefinition `xml:"escalationEventDefinition"`
+	CompensateEventDefinition TCompensateEventDefinition `xml:"compensateEventDefinition"`
+	CancelEventDefinition     TCancelEventDefinition     `xml:"cancelEventDefinition"`
 }
 
 type TServiceTask struct {
 	TTask
-	Default            string                     `xml:"default,attr"`
 	CompletionQuantity int                        `xml:"completionQuantity,attr"`
 	IsForCompensation  bool                       `xml:"isForCompensation,attr"`
 	OperationRef       string                     `xml:"operationRef,attr"`
@@ -134,9 +219,19 @@ type TServiceTask struct {
 // TActivity is an "abstract" struct
 type TActivity struct {
 	TFlowNode
-	IsForCompensation  bool `xml:"isForCompensation,attr"`
-	StartQuantity      int  `xml:"startQuantity,attr" default:"1"`
-	CompletionQuantity int  `xml:"completionQuantity,attr"`
+	IsForCompensation  bool   `xml:"isForCompensation,attr"`
+	StartQuantity      int    `xml:"startQuantity,attr" default:"1"`
+	CompletionQuantity int    `xml:"completionQuantity,attr"`
+	Default            string `xml:"default,attr"` // the ID of the outgoing sequence flow, which is taken, when no condition is true
+	// StandardLoopCharacteristics let the activity loop, see ActivityElement.GetStandardLoopCharacteristics
+	StandardLoopCharacteristics *TStandardLoopCharacteristics `xml:"standardLoopCharacteristics"`
+}
+
+type TStandardLoopCharacteristics struct {
+	TBaseElement
+	TestBefore    bool          `xml:"testBefore,attr"`
+	LoopMaximum   *int          `xml:"loopMaximum,attr"` // nil means unbounded
+	LoopCondition []TExpression `xml:"loopCondition"`
 }
 
 type TTask struct {
@@ -150,21 +245,61 @@ type TUserTask struct {
 	AssignmentDefinition extensions.TAssignmentDefinition `xml:"extensionElements>assignmentDefinition"`
 }
 
+type TBusinessRuleTask struct {
+	TTask
+	Implementation string                     `xml:"implementation,attr"`
+	Input          []extensions.TIoMapping    `xml:"extensionElements>ioMapping>input"`
+	Output         []extensions.TIoMapping    `xml:"extensionElements>ioMapping>output"`
+	CalledDecision extensions.TCalledDecision `xml:"extensionElements>calledDecision"`
+	TaskDefinition extensions.TTaskDefinition `xml:"extensionElements>taskDefinition"`
+}
+
+type TScriptTask struct {
+	TTask
+	ScriptFormat   string                     `xml:"scriptFormat,attr"`
+	Input          []extensions.TIoMapping    `xml:"extensionElements>ioMapping>input"`
+	Output         []extensions.TIoMapping    `xml:"extensionElements>ioMapping>output"`
+	Script         extensions.TScript         `xml:"extensionElements>script"`
+	TaskDefinition extensions.TTaskDefinition `xml:"extensionElements>taskDefinition"`
+}
+
+type TSendTask struct {
+	TTask
+	Implementation string                     `xml:"implementation,attr"`
+	MessageRef     string                     `xml:"messageRef,attr"`
+	Input          []extensions.TIoMapping    `xml:"extensionElements>ioMapping>input"`
+	Output         []extensions.TIoMapping    `xml:"extensionElements>ioMapping>output"`
+	TaskDefinition extensions.TTaskDefinition `xml:"extensionElements>taskDefinition"`
+}
+
+type TReceiveTask struct {
+	TTask
+	Instantiate bool                    `xml:"instantiate,attr"`
+	MessageRef  string                  `xml:"messageRef,attr"`
+	Output      []extensions.TIoMapping `xml:"extensionElements>ioMapping>output"`
+}
+
+type TManualTask struct {
+	TTask
+}
+
 type TParallelGateway struct {
 	TGateway
 }
 
 type TExclusiveGateway struct {
 	TGateway
+	Default string `xml:"default,attr"`
 }
 
 type TIntermediateCatchEvent struct {
 	TCatchEvent
-	MessageEventDefinition TMessageEventDefinition `xml:"messageEventDefinition"`
-	TimerEventDefinition   TTimerEventDefinition   `xml:"timerEventDefinition"`
-	LinkEventDefinition    TLinkEventDefinition    `xml:"linkEventDefinition"`
-	ParallelMultiple       bool                    `xml:"parallelMultiple"`
-	Output                 []extensions.TIoMapping `xml:"extensionElements>ioMapping>output"`
+	MessageEventDefinition     TMessageEventDefinition     `xml:"messageEventDefinition"`
+	TimerEventDefinition       TTimerEventDefinition       `xml:"timerEventDefinition"`
+	LinkEventDefinition        TLinkEventDefinition        `xml:"linkEventDefinition"`
+	ConditionalEventDefinition TConditionalEventDefinition `xml:"conditionalEventDefinition"`
+	ParallelMultiple           bool                        `xml:"parallelMultiple"`
+	Output                     []extensions.TIoMapping     `xml:"extensionElements>ioMapping>output"`
 }
 
 type TThrowEvent struct {
@@ -173,8 +308,23 @@ type TThrowEvent struct {
 
 type TIntermediateThrowEvent struct {
 	TThrowEvent
-	LinkEventDefinition TLinkEventDefinition    `xml:"linkEventDefinition"`
-	Output              []extensions.TIoMapping `xml:"extensionElements>ioMapping>output"`
+	MessageEventDefinition    TMessageEventDefinition    `xml:"messageEventDefinition"`
+	LinkEventDefinition       TLinkEventDefinition       `xml:"linkEventDefinition"`
+	EscalationEventDefinition TEscalationEventDefinition `xml:"escalationEventDefinition"`
+	CompensateEventDefinition TCompensateEventDefinition `xml:"compensateEventDefinition"`
+	Output                    []extensions.TIoMapping    `xml:"extensionElements>ioMapping>output"`
+}
+
+type TBoundaryEvent struct {
+	TCatchEvent
+	AttachedToRef              string                      `xml:"attachedToRef,attr"`
+	CancelActivity             *bool                       `xml:"cancelActivity,attr"` // nil means true, see Interrupting
+	ParallelMultiple           bool                        `xml:"parallelMultiple,attr"`
+	ErrorEventDefinition       TErrorEventDefinition       `xml:"errorEventDefinition"`
+	EscalationEventDefinition  TEscalationEventDefinition  `xml:"escalationEventDefinition"`
+	CompensateEventDefinition  TCompensateEventDefinition  `xml:"compensateEventDefinition"`
+	CancelEventDefinition      TCancelEventDefinition      `xml:"cancelEventDefinition"`
+	ConditionalEventDefinition TConditionalEventDefinition `xml:"conditionalEventDefinition"`
 }
 
 type TEventBasedGateway struct {
@@ -192,7 +342,37 @@ type TTimerEventDefinition struct {
 }
 
 type TTimeDuration struct {
-	XMLText string `xml:",innerxml"`
+	Language string `xml:"language,attr"`
+	XMLText  string `xml:",innerxml"`
+}
+
+type TErrorEventDefinition struct {
+	TEventDefinition
+	ErrorRef string `xml:"errorRef,attr"`
+}
+
+type TSignalEventDefinition struct {
+	TEventDefinition
+	SignalRef string `xml:"signalRef,attr"`
+}
+
+type TEscalationEventDefinition struct {
+	TEventDefinition
+	EscalationRef string `xml:"escalationRef,attr"`
+}
+
+type TCompensateEventDefinition struct {
+	TEventDefinition
+	ActivityRef string `xml:"activityRef,attr"` // empty means all activities of the scope
+}
+
+type TCancelEventDefinition struct {
+	TEventDefinition
+}
+
+type TConditionalEventDefinition struct {
+	TEventDefinition
+	Condition []TExpression `xml:"condition"`
 }
 
 type TLinkEventDefinition struct {
@@ -206,6 +386,30 @@ type TMessage struct {
 	ItemRef string `xml:"itemRef,attr"`
 }
 
+type TError struct {
+	TRootElement
+	Name      string `xml:"name,attr"`
+	ErrorCode string `xml:"errorCode,attr"`
+}
+
+type TSignal struct {
+	TRootElement
+	Name string `xml:"name,attr"`
+}
+
+type TEscalation struct {
+	TRootElement
+	Name           string `xml:"name,attr"`
+	EscalationCode string `xml:"escalationCode,attr"`
+}
+
 type TInclusiveGateway struct {
 	TGateway
+	Default string `xml:"default,attr"`
+}
+
+type TComplexGateway struct {
+	TGateway
+	Default             string        `xml:"default,attr"`
+	ActivationCondition []TExpression `xml:"activationCondition"`
 }
@@ -0,0 +1,27 @@
+package BPMN20
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+)
+
+func Test_unmarshalled_definitions_have_the_first_process(t *testing.T) {
+	// given
+	xmlData, err := os.ReadFile("../../../test-cases/collaboration-message-flows.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	var definitions TDefinitions
+
+	// when
+	err = xml.Unmarshal(xmlData, &definitions)
+
+	// then
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, definitions.Processes, has.Length(2))
+	then.AssertThat(t, definitions.Process.Id, is.EqualTo("order-customer"))
+	then.AssertThat(t, definitions.Processes[1].Id, is.EqualTo("order-shop"))
+}
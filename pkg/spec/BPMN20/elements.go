@@ -8,16 +8,25 @@ type GatewayDirection string
 const (
 	Process                ElementType = "PROCESS"
 	SubProcess             ElementType = "SUB_PROCESS"
+	Transaction            ElementType = "TRANSACTION"
 	StartEvent             ElementType = "START_EVENT"
 	EndEvent               ElementType = "END_EVENT"
 	ServiceTask            ElementType = "SERVICE_TASK"
 	UserTask               ElementType = "USER_TASK"
+	BusinessRuleTask       ElementType = "BUSINESS_RULE_TASK"
+	ScriptTask             ElementType = "SCRIPT_TASK"
+	SendTask               ElementType = "SEND_TASK"
+	ReceiveTask            ElementType = "RECEIVE_TASK"
+	ManualTask             ElementType = "MANUAL_TASK"
+	Task                   ElementType = "TASK"
 	ParallelGateway        ElementType = "PARALLEL_GATEWAY"
 	ExclusiveGateway       ElementType = "EXCLUSIVE_GATEWAY"
 	IntermediateCatchEvent ElementType = "INTERMEDIATE_CATCH_EVENT"
 	IntermediateThrowEvent ElementType = "INTERMEDIATE_THROW_EVENT"
 	EventBasedGateway      ElementType = "EVENT_BASED_GATEWAY"
 	InclusiveGateway       ElementType = "INCLUSIVE_GATEWAY"
+	ComplexGateway         ElementType = "COMPLEX_GATEWAY"
+	BoundaryEvent          ElementType = "BOUNDARY_EVENT"
 
 	SequenceFlow ElementType = "SEQUENCE_FLOW"
 
@@ -44,6 +53,12 @@ type TaskElement interface {
 	GetAssignmentCandidateGroups() []string
 }
 
+// ActivityElement is implemented by all tasks and sub-processes
+type ActivityElement interface {
+	BaseElement
+	GetStandardLoopCharacteristics() *TStandardLoopCharacteristics
+}
+
 type GatewayElement interface {
 	BaseElement
 	IsParallel() bool
@@ -58,6 +73,12 @@ type ProcessElement interface {
 	GetSequenceFlows() []TSequenceFlow
 	GetServiceTasks() []TServiceTask
 	GetUserTasks() []TUserTask
+	GetBusinessRuleTasks() []TBusinessRuleTask
+	GetScriptTasks() []TScriptTask
+	GetSendTasks() []TSendTask
+	GetReceiveTasks() []TReceiveTask
+	GetManualTasks() []TManualTask
+	GetTasks() []TTask
 	GetParallelGateway() []TParallelGateway
 	GetExclusiveGateway() []TExclusiveGateway
 	GetIntermediateCatchEvent() []TIntermediateCatchEvent
@@ -65,6 +86,9 @@ type ProcessElement interface {
 	GetEventBasedGateway() []TEventBasedGateway
 	GetSubProcess() []TSubProcess
 	GetInclusiveGateway() []TInclusiveGateway
+	GetComplexGateway() []TComplexGateway
+	GetBoundaryEvents() []TBoundaryEvent
+	GetAssociations() []TAssociation
 }
 
 func (startEvent TStartEvent) GetId() string {
@@ -187,6 +211,186 @@ func (userTask TUserTask) GetAssignmentCandidateGroups() []string {
 	return userTask.AssignmentDefinition.GetCandidateGroups()
 }
 
+func (businessRuleTask TBusinessRuleTask) GetId() string {
+	return businessRuleTask.Id
+}
+
+func (businessRuleTask TBusinessRuleTask) GetName() string {
+	return businessRuleTask.Name
+}
+
+func (businessRuleTask TBusinessRuleTask) GetIncomingAssociation() []string {
+	return businessRuleTask.IncomingAssociation
+}
+
+func (businessRuleTask TBusinessRuleTask) GetOutgoingAssociation() []string {
+	return businessRuleTask.OutgoingAssociation
+}
+
+func (businessRuleTask TBusinessRuleTask) GetType() ElementType {
+	return BusinessRuleTask
+}
+
+func (businessRuleTask TBusinessRuleTask) GetInputMapping() []extensions.TIoMapping {
+	return businessRuleTask.Input
+}
+
+func (businessRuleTask TBusinessRuleTask) GetOutputMapping() []extensions.TIoMapping {
+	return businessRuleTask.Output
+}
+
+func (businessRuleTask TBusinessRuleTask) GetTaskDefinitionType() string {
+	return businessRuleTask.TaskDefinition.TypeName
+}
+
+func (businessRuleTask TBusinessRuleTask) GetAssignmentAssignee() string {
+	return ""
+}
+
+func (businessRuleTask TBusinessRuleTask) GetAssignmentCandidateGroups() []string {
+	return []string{}
+}
+
+func (scriptTask TScriptTask) GetId() string {
+	return scriptTask.Id
+}
+
+func (scriptTask TScriptTask) GetName() string {
+	return scriptTask.Name
+}
+
+func (scriptTask TScriptTask) GetIncomingAssociation() []string {
+	return scriptTask.IncomingAssociation
+}
+
+func (scriptTask TScriptTask) GetOutgoingAssociation() []string {
+	return scriptTask.OutgoingAssociation
+}
+
+func (scriptTask TScriptTask) GetType() ElementType {
+	return ScriptTask
+}
+
+func (scriptTask TScriptTask) GetInputMapping() []extensions.TIoMapping {
+	return scriptTask.Input
+}
+
+func (scriptTask TScriptTask) GetOutputMapping() []extensions.TIoMapping {
+	return scriptTask.Output
+}
+
+func (scriptTask TScriptTask) GetTaskDefinitionType() string {
+	return scriptTask.TaskDefinition.TypeName
+}
+
+func (scriptTask TScriptTask) GetAssignmentAssignee() string {
+	return ""
+}
+
+func (scriptTask TScriptTask) GetAssignmentCandidateGroups() []string {
+	return []string{}
+}
+
+func (sendTask TSendTask) GetId() string {
+	return sendTask.Id
+}
+
+func (sendTask TSendTask) GetName() string {
+	return sendTask.Name
+}
+
+func (sendTask TSendTask) GetIncomingAssociation() []string {
+	return sendTask.IncomingAssociation
+}
+
+func (sendTask TSendTask) GetOutgoingAssociation() []string {
+	return sendTask.OutgoingAssociation
+}
+
+func (sendTask TSendTask) GetType() ElementType {
+	return SendTask
+}
+
+func (sendTask TSendTask) GetInputMapping() []extensions.TIoMapping {
+	return sendTask.Input
+}
+
+func (sendTask TSendTask) GetOutputMapping() []extensions.TIoMapping {
+	return sendTask.Output
+}
+
+func (sendTask TSendTask) GetTaskDefinitionType() string {
+	return sendTask.TaskDefinition.TypeName
+}
+
+func (sendTask TSendTask) GetAssignmentAssignee() string {
+	return ""
+}
+
+func (sendTask TSendTask) GetAssignmentCandidateGroups() []string {
+	return []string{}
+}
+
+func (receiveTask TReceiveTask) GetId() string {
+	return receiveTask.Id
+}
+
+func (receiveTask TReceiveTask) GetName() string {
+	return receiveTask.Name
+}
+
+func (receiveTask TReceiveTask) GetIncomingAssociation() []string {
+	return receiveTask.IncomingAssociation
+}
+
+func (receiveTask TReceiveTask) GetOutgoingAssociation() []string {
+	return receiveTask.OutgoingAssociation
+}
+
+func (receiveTask TReceiveTask) GetType() ElementType {
+	return ReceiveTask
+}
+
+func (manualTask TManualTask) GetId() string {
+	return manualTask.Id
+}
+
+func (manualTask TManualTask) GetName() string {
+	return manualTask.Name
+}
+
+func (manualTask TManualTask) GetIncomingAssociation() []string {
+	return manualTask.IncomingAssociation
+}
+
+func (manualTask TManualTask) GetOutgoingAssociation() []string {
+	return manualTask.OutgoingAssociation
+}
+
+func (manualTask TManualTask) GetType() ElementType {
+	return ManualTask
+}
+
+func (task TTask) GetId() string {
+	return task.Id
+}
+
+func (task TTask) GetName() string {
+	return task.Name
+}
+
+func (task TTask) GetIncomingAssociation() []string {
+	return task.IncomingAssociation
+}
+
+func (task TTask) GetOutgoingAssociation() []string {
+	return task.OutgoingAssociation
+}
+
+func (task TTask) GetType() ElementType {
+	return Task
+}
+
 func (parallelGateway TParallelGateway) GetId() string {
 	return parallelGateway.Id
 }
@@ -318,14 +522,37 @@ func (intermediateThrowEvent TIntermediateThrowEvent) GetIncomingAssociation() [
 }
 
 func (intermediateThrowEvent TIntermediateThrowEvent) GetOutgoingAssociation() []string {
-	// by specification, not supported
-	return nil
+	// by specification, link throw events have none, but escalation throw events continue the flow
+	return intermediateThrowEvent.OutgoingAssociation
 }
 
 func (intermediateThrowEvent TIntermediateThrowEvent) GetType() ElementType {
 	return IntermediateThrowEvent
 }
 
+// -------------------------------------------------------------------------
+
+func (boundaryEvent TBoundaryEvent) GetId() string {
+	return boundaryEvent.Id
+}
+
+func (boundaryEvent TBoundaryEvent) GetName() string {
+	return boundaryEvent.Name
+}
+
+func (boundaryEvent TBoundaryEvent) GetIncomingAssociation() []string {
+	// by specification, boundary events are triggered by the activity, they're attached to
+	return nil
+}
+
+func (boundaryEvent TBoundaryEvent) GetOutgoingAssociation() []string {
+	return boundaryEvent.OutgoingAssociation
+}
+
+func (boundaryEvent TBoundaryEvent) GetType() ElementType {
+	return BoundaryEvent
+}
+
 func (inclusiveGateway TInclusiveGateway) GetId() string {
 	return inclusiveGateway.Id
 }
@@ -360,6 +587,40 @@ func (inclusiveGateway TInclusiveGateway) IsInclusive() bool {
 
 // -------------------------------------------------------------------------
 
+func (complexGateway TComplexGateway) GetId() string {
+	return complexGateway.Id
+}
+
+func (complexGateway TComplexGateway) GetName() string {
+	return complexGateway.Name
+}
+
+func (complexGateway TComplexGateway) GetIncomingAssociation() []string {
+	return complexGateway.IncomingAssociation
+}
+
+func (complexGateway TComplexGateway) GetOutgoingAssociation() []string {
+	return complexGateway.OutgoingAssociation
+}
+
+func (complexGateway TComplexGateway) GetType() ElementType {
+	return ComplexGateway
+}
+
+func (complexGateway TComplexGateway) IsParallel() bool {
+	return false
+}
+
+func (complexGateway TComplexGateway) IsExclusive() bool {
+	return false
+}
+
+func (complexGateway TComplexGateway) IsInclusive() bool {
+	return false
+}
+
+// -------------------------------------------------------------------------
+
 func (process TProcess) GetId() string {
 	return process.Id
 }
@@ -400,6 +661,30 @@ func (process TProcess) GetUserTasks() []TUserTask {
 	return process.UserTasks
 }
 
+func (process TProcess) GetBusinessRuleTasks() []TBusinessRuleTask {
+	return process.BusinessRuleTasks
+}
+
+func (process TProcess) GetScriptTasks() []TScriptTask {
+	return process.ScriptTasks
+}
+
+func (process TProcess) GetSendTasks() []TSendTask {
+	return process.SendTasks
+}
+
+func (process TProcess) GetReceiveTasks() []TReceiveTask {
+	return process.ReceiveTasks
+}
+
+func (process TProcess) GetManualTasks() []TManualTask {
+	return process.ManualTasks
+}
+
+func (process TProcess) GetTasks() []TTask {
+	return process.Tasks
+}
+
 func (process TProcess) GetParallelGateway() []TParallelGateway {
 	return process.ParallelGateway
 }
@@ -421,13 +706,25 @@ func (process TProcess) GetEventBasedGateway() []TEventBasedGateway {
 }
 
 func (process TProcess) GetSubProcess() []TSubProcess {
-	return process.SubProcesses
+	return appendTransactions(process.SubProcesses, process.Transactions)
 }
 
 func (process TProcess) GetInclusiveGateway() []TInclusiveGateway {
 	return process.InclusiveGateway
 }
 
+func (process TProcess) GetComplexGateway() []TComplexGateway {
+	return process.ComplexGateway
+}
+
+func (process TProcess) GetBoundaryEvents() []TBoundaryEvent {
+	return process.BoundaryEvents
+}
+
+func (process TProcess) GetAssociations() []TAssociation {
+	return process.Associations
+}
+
 func (subProcess TSubProcess) GetId() string {
 	return subProcess.Id
 }
@@ -445,6 +742,9 @@ func (subProcess TSubProcess) GetOutgoingAssociation() []string {
 }
 
 func (subProcess TSubProcess) GetType() ElementType {
+	if subProcess.IsTransaction {
+		return Transaction
+	}
 	return SubProcess
 }
 
@@ -468,6 +768,30 @@ func (subProcess TSubProcess) GetUserTasks() []TUserTask {
 	return subProcess.UserTasks
 }
 
+func (subProcess TSubProcess) GetBusinessRuleTasks() []TBusinessRuleTask {
+	return subProcess.BusinessRuleTasks
+}
+
+func (subProcess TSubProcess) GetScriptTasks() []TScriptTask {
+	return subProcess.ScriptTasks
+}
+
+func (subProcess TSubProcess) GetSendTasks() []TSendTask {
+	return subProcess.SendTasks
+}
+
+func (subProcess TSubProcess) GetReceiveTasks() []TReceiveTask {
+	return subProcess.ReceiveTasks
+}
+
+func (subProcess TSubProcess) GetManualTasks() []TManualTask {
+	return subProcess.ManualTasks
+}
+
+func (subProcess TSubProcess) GetTasks() []TTask {
+	return subProcess.Tasks
+}
+
 func (subProcess TSubProcess) GetParallelGateway() []TParallelGateway {
 	return subProcess.ParallelGateway
 }
@@ -489,9 +813,21 @@ func (subProcess TSubProcess) GetEventBasedGateway() []TEventBasedGateway {
 }
 
 func (subProcess TSubProcess) GetSubProcess() []TSubProcess {
-	return subProcess.SubProcesses
+	return appendTransactions(subProcess.SubProcesses, subProcess.Transactions)
 }
 
 func (subProcess TSubProcess) GetInclusiveGateway() []TInclusiveGateway {
 	return subProcess.InclusiveGateway
 }
+
+func (subProcess TSubProcess) GetComplexGateway() []TComplexGateway {
+	return subProcess.ComplexGateway
+}
+
+func (subProcess TSubProcess) GetBoundaryEvents() []TBoundaryEvent {
+	return subProcess.BoundaryEvents
+}
+
+func (subProcess TSubProcess) GetAssociations() []TAssociation {
+	return subProcess.Associations
+}
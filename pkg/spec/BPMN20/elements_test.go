@@ -7,15 +7,27 @@ import "testing"
 func Test_all_interfaces_implemented(t *testing.T) {
 	var _ TaskElement = &TServiceTask{}
 	var _ TaskElement = &TUserTask{}
+	var _ TaskElement = &TBusinessRuleTask{}
+	var _ TaskElement = &TScriptTask{}
+	var _ TaskElement = &TSendTask{}
 
 	var _ BaseElement = &TStartEvent{}
 	var _ BaseElement = &TEndEvent{}
 	var _ BaseElement = &TServiceTask{}
 	var _ BaseElement = &TUserTask{}
+	var _ BaseElement = &TBusinessRuleTask{}
+	var _ BaseElement = &TScriptTask{}
+	var _ BaseElement = &TSendTask{}
+	var _ BaseElement = &TReceiveTask{}
+	var _ BaseElement = &TManualTask{}
+	var _ BaseElement = &TTask{}
 	var _ BaseElement = &TParallelGateway{}
 	var _ BaseElement = &TExclusiveGateway{}
 	var _ BaseElement = &TIntermediateCatchEvent{}
 	var _ BaseElement = &TIntermediateThrowEvent{}
 	var _ BaseElement = &TEventBasedGateway{}
 	var _ BaseElement = &TInclusiveGateway{}
+	var _ BaseElement = &TComplexGateway{}
+	var _ GatewayElement = &TComplexGateway{}
+	var _ BaseElement = &TBoundaryEvent{}
 }
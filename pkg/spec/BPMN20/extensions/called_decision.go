@@ -0,0 +1,6 @@
+package extensions
+
+type TCalledDecision struct {
+	DecisionId     string `xml:"decisionId,attr"`
+	ResultVariable string `xml:"resultVariable,attr"`
+}
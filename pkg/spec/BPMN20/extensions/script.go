@@ -0,0 +1,6 @@
+package extensions
+
+type TScript struct {
+	Expression     string `xml:"expression,attr"`
+	ResultVariable string `xml:"resultVariable,attr"`
+}
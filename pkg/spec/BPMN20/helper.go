@@ -58,6 +58,24 @@ func FindBaseElementsById(processElement ProcessElement, id string) (elements []
 	for _, task := range processElement.GetUserTasks() {
 		appendWhenIdMatches(Ptr[BaseElement](task))
 	}
+	for _, task := range processElement.GetBusinessRuleTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
+	for _, task := range processElement.GetScriptTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
+	for _, task := range processElement.GetSendTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
+	for _, task := range processElement.GetReceiveTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
+	for _, task := range processElement.GetManualTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
+	for _, task := range processElement.GetTasks() {
+		appendWhenIdMatches(Ptr[BaseElement](task))
+	}
 	for _, parallelGateway := range processElement.GetParallelGateway() {
 		appendWhenIdMatches(Ptr[BaseElement](parallelGateway))
 	}
@@ -76,6 +94,12 @@ func FindBaseElementsById(processElement ProcessElement, id string) (elements []
 	for _, inclusiveGateway := range processElement.GetInclusiveGateway() {
 		appendWhenIdMatches(Ptr[BaseElement](inclusiveGateway))
 	}
+	for _, complexGateway := range processElement.GetComplexGateway() {
+		appendWhenIdMatches(Ptr[BaseElement](complexGateway))
+	}
+	for _, boundaryEvent := range processElement.GetBoundaryEvents() {
+		appendWhenIdMatches(Ptr[BaseElement](boundaryEvent))
+	}
 	for _, subProcess := range processElement.GetSubProcess() {
 		appendWhenIdMatches(Ptr[BaseElement](subProcess))
 		// search recursively for further elements
@@ -95,6 +119,92 @@ func (flow TSequenceFlow) GetConditionExpression() string {
 	return html.UnescapeString(flow.ConditionExpression[0].Text)
 }
 
+// GetConditionExpressionLanguage returns the language of the embedded expression,
+// or an empty string, when it has none. There will be a panic thrown, in case none exists!
+func (flow TSequenceFlow) GetConditionExpressionLanguage() string {
+	return flow.ConditionExpression[0].Language
+}
+
+// HasActivationCondition returns true, if there's exactly 1 activation condition present (as by the spec)
+// and there's some non-whitespace-characters available
+func (complexGateway TComplexGateway) HasActivationCondition() bool {
+	return len(complexGateway.ActivationCondition) == 1 && len(strings.TrimSpace(complexGateway.ActivationCondition[0].Text)) > 0
+}
+
+// GetActivationCondition returns the embedded expression. There will be a panic thrown, in case none exists!
+func (complexGateway TComplexGateway) GetActivationCondition() string {
+	return html.UnescapeString(complexGateway.ActivationCondition[0].Text)
+}
+
+// GetActivationConditionLanguage returns the language of the embedded expression,
+// or an empty string, when it has none. There will be a panic thrown, in case none exists!
+func (complexGateway TComplexGateway) GetActivationConditionLanguage() string {
+	return complexGateway.ActivationCondition[0].Language
+}
+
+// HasCondition returns true, if there's exactly 1 condition present (as by the spec)
+// and there's some non-whitespace-characters available
+func (definition TConditionalEventDefinition) HasCondition() bool {
+	return len(definition.Condition) == 1 && len(strings.TrimSpace(definition.Condition[0].Text)) > 0
+}
+
+// GetCondition returns the embedded expression. There will be a panic thrown, in case none exists!
+func (definition TConditionalEventDefinition) GetCondition() string {
+	return html.UnescapeString(definition.Condition[0].Text)
+}
+
+// GetConditionLanguage returns the language of the embedded expression,
+// or an empty string, when it has none. There will be a panic thrown, in case none exists!
+func (definition TConditionalEventDefinition) GetConditionLanguage() string {
+	return definition.Condition[0].Language
+}
+
+// GetStandardLoopCharacteristics returns the standard loop characteristics, or nil, when the activity doesn't loop
+func (activity TActivity) GetStandardLoopCharacteristics() *TStandardLoopCharacteristics {
+	return activity.StandardLoopCharacteristics
+}
+
+// HasLoopCondition returns true, if there's exactly 1 loop condition present (as by the spec)
+// and there's some non-whitespace-characters available
+func (loop TStandardLoopCharacteristics) HasLoopCondition() bool {
+	return len(loop.LoopCondition) == 1 && len(strings.TrimSpace(loop.LoopCondition[0].Text)) > 0
+}
+
+// GetLoopCondition returns the embedded expression. There will be a panic thrown, in case none exists!
+func (loop TStandardLoopCharacteristics) GetLoopCondition() string {
+	return html.UnescapeString(loop.LoopCondition[0].Text)
+}
+
+// GetLoopConditionLanguage returns the language of the embedded expression,
+// or an empty string, when it has none. There will be a panic thrown, in case none exists!
+func (loop TStandardLoopCharacteristics) GetLoopConditionLanguage() string {
+	return loop.LoopCondition[0].Language
+}
+
+// Interrupting is true, when the start event of an event sub-process interrupts its parent scope (default: true)
+func (startEvent TStartEvent) Interrupting() bool {
+	return startEvent.IsInterrupting == nil || *startEvent.IsInterrupting
+}
+
+// Interrupting is true, when the boundary event cancels the activity, which it's attached to (default: true)
+func (boundaryEvent TBoundaryEvent) Interrupting() bool {
+	return boundaryEvent.CancelActivity == nil || *boundaryEvent.CancelActivity
+}
+
+// appendTransactions returns the sub-processes, followed by the transactions, which are marked as such;
+// transactions are sub-processes with special outcome semantics, so the engine handles them alike
+func appendTransactions(subProcesses []TSubProcess, transactions []TSubProcess) []TSubProcess {
+	if len(transactions) == 0 {
+		return subProcesses
+	}
+	result := append([]TSubProcess{}, subProcesses...)
+	for _, transaction := range transactions {
+		transaction.IsTransaction = true
+		result = append(result, transaction)
+	}
+	return result
+}
+
 func Ptr[T any](v T) *T {
 	return &v
 }
@@ -0,0 +1,82 @@
+package DMN13
+
+type HitPolicy string
+type BuiltinAggregator string
+
+const (
+	Unique      HitPolicy = "UNIQUE"
+	First       HitPolicy = "FIRST"
+	Priority    HitPolicy = "PRIORITY"
+	Any         HitPolicy = "ANY"
+	Collect     HitPolicy = "COLLECT"
+	RuleOrder   HitPolicy = "RULE ORDER"
+	OutputOrder HitPolicy = "OUTPUT ORDER"
+
+	Sum   BuiltinAggregator = "SUM"
+	Count BuiltinAggregator = "COUNT"
+	Min   BuiltinAggregator = "MIN"
+	Max   BuiltinAggregator = "MAX"
+)
+
+type TDefinitions struct {
+	Id        string      `xml:"id,attr"`
+	Name      string      `xml:"name,attr"`
+	Namespace string      `xml:"namespace,attr"`
+	Exporter  string      `xml:"exporter,attr"`
+	Decisions []TDecision `xml:"decision"`
+}
+
+type TDecision struct {
+	Id            string          `xml:"id,attr"`
+	Name          string          `xml:"name,attr"`
+	DecisionTable *TDecisionTable `xml:"decisionTable"`
+}
+
+type TDecisionTable struct {
+	Id          string            `xml:"id,attr"`
+	HitPolicy   HitPolicy         `xml:"hitPolicy,attr"`
+	Aggregation BuiltinAggregator `xml:"aggregation,attr"`
+	Inputs      []TInputClause    `xml:"input"`
+	Outputs     []TOutputClause   `xml:"output"`
+	Rules       []TRule           `xml:"rule"`
+}
+
+type TInputClause struct {
+	Id              string             `xml:"id,attr"`
+	Label           string             `xml:"label,attr"`
+	InputExpression TLiteralExpression `xml:"inputExpression"`
+}
+
+type TOutputClause struct {
+	Id      string `xml:"id,attr"`
+	Label   string `xml:"label,attr"`
+	Name    string `xml:"name,attr"`
+	TypeRef string `xml:"typeRef,attr"`
+}
+
+type TRule struct {
+	Id            string               `xml:"id,attr"`
+	InputEntries  []TUnaryTests        `xml:"inputEntry"`
+	OutputEntries []TLiteralExpression `xml:"outputEntry"`
+}
+
+type TLiteralExpression struct {
+	Id                 string `xml:"id,attr"`
+	TypeRef            string `xml:"typeRef,attr"`
+	ExpressionLanguage string `xml:"expressionLanguage,attr"`
+	Text               string `xml:"text"`
+}
+
+type TUnaryTests struct {
+	Id                 string `xml:"id,attr"`
+	ExpressionLanguage string `xml:"expressionLanguage,attr"`
+	Text               string `xml:"text"`
+}
+
+// GetHitPolicy returns the hit policy, which is UNIQUE, when none is defined (as by the spec)
+func (table TDecisionTable) GetHitPolicy() HitPolicy {
+	if table.HitPolicy == "" {
+		return Unique
+	}
+	return table.HitPolicy
+}
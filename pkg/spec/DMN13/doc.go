@@ -0,0 +1,3 @@
+// Package DMN13 contains structs to parse DMN (XML) files,
+// based on specification DMN v1.3, see https://www.omg.org/spec/DMN/
+package DMN13
@@ -2,41 +2,62 @@ package tests
 
 import (
 	_ "embed"
-	"github.com/corbym/gocrest/is"
-	"github.com/corbym/gocrest/then"
-	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine"
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
+
+	"github.com/corbym/gocrest/has"
+	"github.com/corbym/gocrest/is"
+	"github.com/corbym/gocrest/then"
+	"github.com/nitram509/lib-bpmn-engine/pkg/bpmn_engine"
 )
 
-func Test_unmarshalled_v1_contains_all_fields(t *testing.T) {
-	tests := []struct {
-		referenceFile string
-	}{
-		{"marshal-reference-v1/jobs.json"},
-		{"marshal-reference-v1/intermediate-catch-event.json"},
-		{"marshal-reference-v1/message-intermediate-timer-event.json"},
-		{"marshal-reference-v1/parallel-gateway-flow.json"},
-		{"marshal-reference-v1/remain.json"},
-	}
-	for _, test := range tests {
-		testName := path.Base(test.referenceFile)
-		t.Run(testName, func(t *testing.T) {
-			// setup
-			referenceBytes, err := os.ReadFile(test.referenceFile)
-			then.AssertThat(t, err, is.Nil())
-
-			// given
-			engine, err := bpmn_engine.Unmarshal(referenceBytes)
-			then.AssertThat(t, err, is.Nil())
-
-			// when
-			marshalledBytes := engine.Marshal()
-
-			equal, err := JSONBytesEqual(referenceBytes, marshalledBytes)
-			then.AssertThat(t, err, is.Nil())
-			then.AssertThat(t, equal, is.True())
-		})
+// Test_unmarshalled_reference_files_of_all_versions_contain_all_fields checks each reference file per serializer version.
+// Files of the current version must marshal to the same data again; files of older versions must be
+// migrated and marshal to the data found in the 'migrated' sub-folder.
+func Test_unmarshalled_reference_files_of_all_versions_contain_all_fields(t *testing.T) {
+	for version := 1; version <= bpmn_engine.CurrentSerializerVersion; version++ {
+		referenceFolder := fmt.Sprintf("marshal-reference-v%d", version)
+		referenceFiles, err := filepath.Glob(path.Join(referenceFolder, "*.json"))
+		then.AssertThat(t, err, is.Nil())
+		then.AssertThat(t, referenceFiles, has.Length(is.GreaterThan(0)))
+		for _, referenceFile := range referenceFiles {
+			expectedFile := referenceFile
+			if version < bpmn_engine.CurrentSerializerVersion {
+				expectedFile = path.Join(referenceFolder, "migrated", path.Base(referenceFile))
+			}
+			t.Run(referenceFile, func(t *testing.T) {
+				// setup
+				referenceBytes, err := os.ReadFile(referenceFile)
+				then.AssertThat(t, err, is.Nil())
+				expectedBytes, err := os.ReadFile(expectedFile)
+				then.AssertThat(t, err, is.Nil())
+
+				// given
+				engine, err := bpmn_engine.Unmarshal(referenceBytes)
+				then.AssertThat(t, err, is.Nil())
+
+				// when
+				marshalledBytes := engine.Marshal()
+
+				equal, err := MarshalledBytesEqual(expectedBytes, marshalledBytes)
+				then.AssertThat(t, err, is.Nil())
+				then.AssertThat(t, equal, is.True())
+			})
+		}
 	}
 }
+
+func Test_unmarshal_rejects_newer_serializer_version(t *testing.T) {
+	// given
+	data := fmt.Sprintf(`{"v":%d,"n":"newer"}`, bpmn_engine.CurrentSerializerVersion+1)
+
+	// when
+	_, err := bpmn_engine.Unmarshal([]byte(data))
+
+	// then
+	then.AssertThat(t, err, is.Not(is.Nil()))
+	then.AssertThat(t, err.Error(), is.ValueContaining("newer than the supported version"))
+}
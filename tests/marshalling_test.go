@@ -262,3 +262,33 @@ func Test_Marshal_Unmarshal_IntermediateTimerEvents_message_is_completing(t *tes
 	then.AssertThat(t, pii.ActivityState, is.EqualTo(bpmn_engine.Completed))
 	then.AssertThat(t, cp.CallPath, is.EqualTo("task-for-message"))
 }
+
+func Test_Marshal_Unmarshal_published_messages_are_preserved(t *testing.T) {
+	// setup
+	bpmnEngine := bpmn_engine.New()
+
+	// given
+	pi, err := bpmnEngine.LoadFromFile("../test-cases/simple-intermediate-message-catch-event.bpmn")
+	then.AssertThat(t, err, is.Nil())
+	instance, err := bpmnEngine.CreateInstance(pi.ProcessKey, nil)
+	then.AssertThat(t, err, is.Nil())
+	err = bpmnEngine.PublishEventForInstance(instance.GetInstanceKey(), "msg", map[string]interface{}{"foo": "bar"})
+	then.AssertThat(t, err, is.Nil())
+
+	// when
+	bytes := bpmnEngine.Marshal()
+
+	if enableJsonDataDump {
+		_ = os.WriteFile("temp.marshal.caught-events.json", bytes, 0644)
+	}
+
+	// when
+	bpmnEngine, err = bpmn_engine.Unmarshal(bytes)
+	then.AssertThat(t, err, is.Nil())
+
+	// then
+	instance, err = bpmnEngine.RunOrContinueInstance(instance.GetInstanceKey())
+	then.AssertThat(t, err, is.Nil())
+	then.AssertThat(t, instance.GetState(), is.EqualTo(bpmn_engine.Completed))
+	then.AssertThat(t, instance.GetVariable("foo"), is.EqualTo("bar"))
+}
@@ -1,9 +1,12 @@
 package tests
 
 import (
+	"compress/flate"
+	"encoding/ascii85"
 	"encoding/json"
 	"io"
 	"reflect"
+	"strings"
 )
 
 // JSONEqual compares the JSON from two Readers.
@@ -31,3 +34,36 @@ func JSONBytesEqual(a, b []byte) (bool, error) {
 	}
 	return reflect.DeepEqual(j2, j), nil
 }
+
+// MarshalledBytesEqual compares two marshalled BPMN engines, like JSONBytesEqual does.
+// Since the compressed BPMN data differs between compressor implementations,
+// the decompressed BPMN data gets compared instead.
+func MarshalledBytesEqual(a, b []byte) (bool, error) {
+	var j, j2 map[string]interface{}
+	if err := json.Unmarshal(a, &j); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &j2); err != nil {
+		return false, err
+	}
+	if err := decompressBpmnData(j); err != nil {
+		return false, err
+	}
+	if err := decompressBpmnData(j2); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(j2, j), nil
+}
+
+func decompressBpmnData(engine map[string]interface{}) error {
+	processReferences, _ := engine["pr"].([]interface{})
+	for _, pr := range processReferences {
+		reference := pr.(map[string]interface{})
+		data, err := io.ReadAll(flate.NewReader(ascii85.NewDecoder(strings.NewReader(reference["d"].(string)))))
+		if err != nil {
+			return err
+		}
+		reference["d"] = string(data)
+	}
+	return nil
+}